@@ -0,0 +1,116 @@
+// Command bootstrap membuat akun admin pertama
+// Register publik tidak pernah membuat admin, jadi admin awal dibuat lewat CLI ini
+//
+// Contoh:
+//
+//	go run ./cmd/bootstrap -username admin -email admin@example.com -phone 081234567890
+//
+// Password dibaca dari env BOOTSTRAP_ADMIN_PASSWORD, atau dari stdin jika env kosong
+package main
+
+import (
+	"bufio"
+	"flag"
+	"fmt"
+	"log"
+	"os"
+	"strings"
+
+	"github.com/LutfiyaAinurrahmanP/boilerplate_fiber_restful_api/internal/config"
+	"github.com/LutfiyaAinurrahmanP/boilerplate_fiber_restful_api/internal/models"
+	"github.com/LutfiyaAinurrahmanP/boilerplate_fiber_restful_api/internal/repositories"
+	"github.com/LutfiyaAinurrahmanP/boilerplate_fiber_restful_api/internal/services"
+	"github.com/LutfiyaAinurrahmanP/boilerplate_fiber_restful_api/internal/utils"
+	"github.com/LutfiyaAinurrahmanP/boilerplate_fiber_restful_api/internal/validators"
+	"github.com/LutfiyaAinurrahmanP/boilerplate_fiber_restful_api/pkg/database"
+)
+
+func main() {
+	username := flag.String("username", "", "admin username")
+	email := flag.String("email", "", "admin email")
+	phone := flag.String("phone", "", "admin phone number")
+	force := flag.Bool("force", false, "create the admin even if another admin already exists")
+	flag.Parse()
+
+	cfg, err := config.LoadConfig()
+	if err != nil {
+		log.Fatalf("❌ Failed to load config: %v", err)
+	}
+	utils.SetDefaultPhoneCountryCode(cfg.PhoneDefaultCountryCode)
+	models.SetUniquenessScope(cfg.UserUniquenessScope)
+
+	db, err := database.NewMySQLConnection(cfg.GetDSN())
+	if err != nil {
+		log.Fatalf("❌ Failed to connect to database: %v", err)
+	}
+
+	migrator := database.NewMigrator(db)
+	if err := migrator.AutoMigrate(&models.User{}, &models.Role{}, &models.Permission{}, &models.Organization{}, &models.OrganizationMember{}); err != nil {
+		log.Fatalf("❌ Failed to run migrations: %v", err)
+	}
+	if err := migrator.SeedRolesAndPermissions(); err != nil {
+		log.Fatalf("❌ Failed to seed roles: %v", err)
+	}
+	if err := migrator.SeedDefaultOrganization(); err != nil {
+		log.Fatalf("❌ Failed to seed default organization: %v", err)
+	}
+
+	userRepo := repositories.NewUserRepository(db)
+
+	// Default-nya hanya untuk admin pertama, -force untuk menambah admin lain
+	if !*force {
+		query := &validators.ListUserQuery{Role: models.RoleAdmin}
+		query.SetDefaults()
+		_, total, err := userRepo.FindAll(query)
+		if err != nil {
+			log.Fatalf("❌ Failed to check existing admins: %v", err)
+		}
+		if total > 0 {
+			log.Fatalf("❌ An admin already exists. Use /admin/user/create or pass -force.")
+		}
+	}
+
+	password, err := readPassword()
+	if err != nil {
+		log.Fatalf("❌ Failed to read password: %v", err)
+	}
+
+	req := &validators.CreateUserRequest{
+		Username:        *username,
+		Email:           *email,
+		Phone:           *phone,
+		Password:        password,
+		ConfirmPassword: password,
+		Role:            models.RoleAdmin,
+	}
+	if err := validators.ValidateStruct(req); err != nil {
+		for field, message := range validators.FormatValidationError(err) {
+			log.Printf("   - %s: %s", field, message)
+		}
+		log.Fatalf("❌ Invalid admin data")
+	}
+
+	// CreateUser tidak memakai session revoker, admin pertama masuk ke organisasi default
+	// Bootstrap berjalan sebagai admin, role store dipakai untuk mengecek role yang diberikan
+	roleService := services.NewRoleService(repositories.NewRoleRepository(db), cfg.RoleCacheTTL)
+	userService := services.NewUserService(userRepo, nil, roleService).ForOrganization(models.DefaultOrganizationID)
+	user, err := userService.CreateUser(models.RoleAdmin, req)
+	if err != nil {
+		log.Fatalf("❌ Failed to create admin: %v", err)
+	}
+
+	log.Printf("✅ Admin %q created with ID %d", user.Username, user.ID)
+}
+
+func readPassword() (string, error) {
+	if password := os.Getenv("BOOTSTRAP_ADMIN_PASSWORD"); password != "" {
+		return password, nil
+	}
+
+	fmt.Fprint(os.Stderr, "Admin password: ")
+	line, err := bufio.NewReader(os.Stdin).ReadString('\n')
+	if err != nil && line == "" {
+		return "", err
+	}
+	return strings.TrimRight(line, "\r\n"), nil
+}
@@ -1,14 +1,26 @@
 package main
 
 import (
+	"context"
 	"fmt"
 	"log"
+	"os"
+	"os/signal"
+	"syscall"
 
+	"github.com/LutfiyaAinurrahmanP/boilerplate_fiber_restful_api/internal/authz"
+	"github.com/LutfiyaAinurrahmanP/boilerplate_fiber_restful_api/internal/cache"
 	"github.com/LutfiyaAinurrahmanP/boilerplate_fiber_restful_api/internal/config"
 	"github.com/LutfiyaAinurrahmanP/boilerplate_fiber_restful_api/internal/handlers"
+	"github.com/LutfiyaAinurrahmanP/boilerplate_fiber_restful_api/internal/jwtauth"
+	"github.com/LutfiyaAinurrahmanP/boilerplate_fiber_restful_api/internal/mailer"
 	"github.com/LutfiyaAinurrahmanP/boilerplate_fiber_restful_api/internal/models"
+	"github.com/LutfiyaAinurrahmanP/boilerplate_fiber_restful_api/internal/oidc"
 	"github.com/LutfiyaAinurrahmanP/boilerplate_fiber_restful_api/internal/repositories"
 	"github.com/LutfiyaAinurrahmanP/boilerplate_fiber_restful_api/internal/services"
+	"github.com/LutfiyaAinurrahmanP/boilerplate_fiber_restful_api/internal/sms"
+	"github.com/LutfiyaAinurrahmanP/boilerplate_fiber_restful_api/internal/utils"
+	"github.com/LutfiyaAinurrahmanP/boilerplate_fiber_restful_api/internal/workers"
 	"github.com/LutfiyaAinurrahmanP/boilerplate_fiber_restful_api/pkg/database"
 	"github.com/gofiber/fiber/v2"
 	"github.com/gofiber/fiber/v2/middleware/cors"
@@ -26,6 +38,19 @@ func main() {
 	}
 	log.Println("✅ Configuration loaded successfully")
 
+	// Nomor telepon format nasional (08xx) dinormalisasi ke E.164 dengan kode negara ini
+	utils.SetDefaultPhoneCountryCode(cfg.PhoneDefaultCountryCode)
+
+	// Uniqueness username / email / phone global atau per organisasi, dipakai hook model dan migrasi
+	models.SetUniquenessScope(cfg.UserUniquenessScope)
+
+	// Load JWT signing & verification keys
+	keySet, err := jwtauth.LoadKeySet(cfg)
+	if err != nil {
+		log.Fatalf("❌ Failed to load JWT keys: %v", err)
+	}
+	tokenManager := jwtauth.NewManager(cfg, keySet)
+
 	// ============================================
 	// 2. INITIALIZE DATABASE CONNECTION
 	// ============================================
@@ -41,8 +66,34 @@ func main() {
 	migrator := database.NewMigrator(db)
 
 	modelsToMigrate := []interface{}{
-		&models.User{}, // Model User dengan field role
-		&models.TokenBlacklist{}, // Token blacklist untuk logout
+		&models.User{},               // Model User dengan field role
+		&models.TokenBlacklist{},     // Token blacklist untuk logout
+		&models.RefreshToken{},       // Refresh token (rotation + reuse detection)
+		&models.Session{},            // Session per login (device, IP, last seen)
+		&models.PasswordResetToken{}, // Token reset password (sekali pakai)
+		&models.VerificationCode{},   // Kode verifikasi email / telepon
+		&models.RecoveryCode{},       // Recovery code 2FA (sekali pakai)
+		&models.MFAChallenge{},       // Token "mfa pending" setelah login
+		&models.TwoFactorPolicy{},    // Role yang wajib 2FA
+		&models.LoginAttempt{},       // Counter login gagal per username / IP
+		&models.LockoutEvent{},       // Riwayat lockout & unlock
+		&models.Invitation{},         // Undangan onboarding dari admin
+		&models.UserIdentity{},       // Akun identity provider OIDC yang terhubung ke user
+		&models.OIDCLoginState{},     // State, nonce & PKCE verifier selama login OIDC
+		&models.APIKey{},             // API key / personal access token (hash)
+
+		// OAuth2 authorization server untuk aplikasi internal
+		&models.OAuthClient{},            // Client terdaftar (secret disimpan sebagai hash)
+		&models.OAuthAuthorizationCode{}, // Authorization code sekali pakai (PKCE)
+		&models.OAuthToken{},             // Access token (jti) & refresh token (hash) yang diterbitkan
+
+		// Role & permission (tabel role_permissions dibuat otomatis, di-seed setelah migrasi)
+		&models.Role{},
+		&models.Permission{},
+
+		// Multi-tenancy (organisasi default di-seed dan keanggotaan user lama diisi setelah migrasi)
+		&models.Organization{},
+		&models.OrganizationMember{},
 	}
 
 	if err := migrator.RunMigrations(modelsToMigrate...); err != nil {
@@ -57,14 +108,82 @@ func main() {
 	// Repository Layer
 	userRepo := repositories.NewUserRepository(db)
 	tokenRepo := repositories.NewTokenRepository(db)
+	if cfg.TokenCacheEnabled {
+		var bloom *cache.BloomFilter
+		if cfg.TokenCacheBloom {
+			bloom = cache.NewBloomFilter(cfg.TokenCacheBloomCapacity, 0.01)
+		}
+		tokenRepo, err = repositories.NewCachedTokenRepository(tokenRepo, cache.NewMemoryStore(), bloom, cfg.TokenCacheNegativeTTL)
+		if err != nil {
+			log.Fatalf("❌ Failed to initialize token cache: %v", err)
+		}
+	}
+	refreshTokenRepo := repositories.NewRefreshTokenRepository(db)
+	sessionRepo := repositories.NewSessionRepository(db)
+	passwordResetRepo := repositories.NewPasswordResetRepository(db)
+	verificationCodeRepo := repositories.NewVerificationCodeRepository(db)
+	recoveryCodeRepo := repositories.NewRecoveryCodeRepository(db)
+	mfaChallengeRepo := repositories.NewMFAChallengeRepository(db)
+	twoFactorPolicyRepo := repositories.NewTwoFactorPolicyRepository(db)
+	loginAttemptRepo := repositories.NewLoginAttemptRepository(db, cfg.LoginFailureWindow)
+	invitationRepo := repositories.NewInvitationRepository(db)
+	userIdentityRepo := repositories.NewUserIdentityRepository(db)
+	oidcStateRepo := repositories.NewOIDCStateRepository(db)
+	apiKeyRepo := repositories.NewAPIKeyRepository(db)
+	oauthClientRepo := repositories.NewOAuthClientRepository(db)
+	oauthCodeRepo := repositories.NewOAuthCodeRepository(db)
+	oauthTokenRepo := repositories.NewOAuthTokenRepository(db)
+	roleRepo := repositories.NewRoleRepository(db)
+	organizationRepo := repositories.NewOrganizationRepository(db)
+
+	// Mailer (smtp / file / log)
+	mail, err := mailer.New(cfg)
+	if err != nil {
+		log.Fatalf("❌ Failed to initialize mailer: %v", err)
+	}
+
+	// SMS sender (log)
+	smsSender, err := sms.New(cfg)
+	if err != nil {
+		log.Fatalf("❌ Failed to initialize sms sender: %v", err)
+	}
 
 	// Service Layer
-	authService := services.NewAuthService(userRepo, tokenRepo, cfg.JWTSecret)
-	userService := services.NewUserService(userRepo)
+	// Role store dipasang lebih dulu agar validasi role (models.ValidateRole / tag "role") membaca tabel roles
+	roleService := services.NewRoleService(roleRepo, cfg.RoleCacheTTL)
+	models.SetRoleLookup(roleService)
+
+	verificationService := services.NewVerificationService(userRepo, verificationCodeRepo, mail, smsSender, cfg, organizationRepo)
+	twoFactorService := services.NewTwoFactorService(userRepo, recoveryCodeRepo, twoFactorPolicyRepo, sessionRepo, cfg)
+	loginGuard := services.NewLoginGuard(userRepo, loginAttemptRepo, cfg)
+	registrationPolicy := services.NewRegistrationPolicy(cfg)
+	authService := services.NewAuthService(userRepo, tokenRepo, refreshTokenRepo, sessionRepo, cfg, tokenManager, verificationService, mfaChallengeRepo, twoFactorService, loginGuard, registrationPolicy, organizationRepo)
+	userService := services.NewUserService(userRepo, authService, roleService)
+	passwordResetService := services.NewPasswordResetService(userRepo, passwordResetRepo, authService, mail, cfg, organizationRepo)
+	invitationService := services.NewInvitationService(invitationRepo, userRepo, userService, mail, cfg, roleService)
+	oidcService := services.NewOIDCService(oidc.NewRegistry(cfg), userIdentityRepo, oidcStateRepo, userRepo, authService, registrationPolicy, cfg)
+	apiKeyService := services.NewAPIKeyService(apiKeyRepo, userRepo, roleService, cfg)
+	oauthService := services.NewOAuthService(oauthClientRepo, oauthCodeRepo, oauthTokenRepo, userRepo, tokenManager, cfg)
+	organizationService := services.NewOrganizationService(organizationRepo, userRepo, roleService)
+
+	// Policy engine untuk aturan otorisasi berbasis data (lihat internal/authz/rules.go)
+	policy := authz.NewEngine(authz.DefaultRules(organizationRepo, organizationRepo)...)
 
 	// Handler Layer
 	authHandler := handlers.NewAuthHandler(authService)
-	userHandler := handlers.NewUserHandler(userService)
+	userHandler := handlers.NewUserHandler(userService, policy)
+	jwksHandler := handlers.NewJWKSHandler(keySet)
+	sessionHandler := handlers.NewSessionHandler(authService, userService)
+	passwordResetHandler := handlers.NewPasswordResetHandler(passwordResetService)
+	verificationHandler := handlers.NewVerificationHandler(verificationService)
+	twoFactorHandler := handlers.NewTwoFactorHandler(twoFactorService)
+	lockoutHandler := handlers.NewLockoutHandler(loginGuard, userService)
+	invitationHandler := handlers.NewInvitationHandler(invitationService)
+	oidcHandler := handlers.NewOIDCHandler(oidcService, cfg)
+	apiKeyHandler := handlers.NewAPIKeyHandler(apiKeyService)
+	oauthHandler := handlers.NewOAuthHandler(oauthService, cfg)
+	roleHandler := handlers.NewRoleHandler(roleService)
+	organizationHandler := handlers.NewOrganizationHandler(organizationService, authService)
 
 	log.Println("✅ Dependencies initialized successfully")
 
@@ -110,7 +229,7 @@ func main() {
 	app.Use(cors.New(cors.Config{
 		AllowOrigins:     "*",
 		AllowMethods:     "GET,POST,PUT,PATCH,DELETE,OPTIONS",
-		AllowHeaders:     "Origin, Content-Type, Accept, Authorization",
+		AllowHeaders:     "Origin, Content-Type, Accept, Authorization, X-API-Key",
 		AllowCredentials: false,
 		MaxAge:           3600,
 	}))
@@ -123,10 +242,27 @@ func main() {
 	log.Println("🔧 Registering application routes...")
 
 	routeConfig := &RouteConfig{
-		AuthHandler: authHandler,
-		UserHandler: userHandler,
-		JWTSecret:   cfg.JWTSecret,
-		TokenRepo: tokenRepo,
+		AuthHandler:          authHandler,
+		UserHandler:          userHandler,
+		JWKSHandler:          jwksHandler,
+		SessionHandler:       sessionHandler,
+		PasswordResetHandler: passwordResetHandler,
+		VerificationHandler:  verificationHandler,
+		TwoFactorHandler:     twoFactorHandler,
+		LockoutHandler:       lockoutHandler,
+		InvitationHandler:    invitationHandler,
+		OIDCHandler:          oidcHandler,
+		APIKeyHandler:        apiKeyHandler,
+		OAuthHandler:         oauthHandler,
+		RoleHandler:          roleHandler,
+		OrganizationHandler:  organizationHandler,
+		RoleService:          roleService,
+		TokenManager:         tokenManager,
+		TokenRepo:            tokenRepo,
+		SessionRepo:          sessionRepo,
+		UserRepo:             userRepo,
+		TwoFactorPolicyRepo:  twoFactorPolicyRepo,
+		APIKeyRepo:           apiKeyRepo,
 	}
 
 	SetupRoutes(app, routeConfig)
@@ -134,24 +270,80 @@ func main() {
 	log.Println("✅ Routes registered successfully")
 
 	// ============================================
-	// 8. START SERVER
+	// 8. START BACKGROUND WORKERS
+	// ============================================
+	// ctx di-cancel saat menerima SIGINT / SIGTERM
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	tokenJanitor := workers.NewTokenJanitor(cfg.TokenCleanupInterval, cfg.TokenCleanupBatchSize).
+		AddTarget("token_blacklist", tokenRepo).
+		AddTarget("refresh_tokens", refreshTokenRepo).
+		AddTarget("password_reset_tokens", passwordResetRepo).
+		AddTarget("verification_codes", verificationCodeRepo).
+		AddTarget("mfa_challenges", mfaChallengeRepo).
+		AddTarget("login_attempts", loginAttemptRepo).
+		AddTarget("oidc_login_states", oidcStateRepo).
+		AddTarget("api_keys", apiKeyRepo).
+		AddTarget("oauth_authorization_codes", oauthCodeRepo).
+		AddTarget("oauth_tokens", oauthTokenRepo)
+	tokenJanitor.Start(ctx)
+
+	// ============================================
+	// 9. START SERVER
 	// ============================================
 	port := fmt.Sprintf(":%s", cfg.AppPort)
 
 	log.Println("========================================")
 	log.Printf("🚀 Server starting on http://localhost%s", port)
 	log.Printf("📝 Environment: %s", cfg.AppEnv)
+	log.Printf("📝 Registration mode: %s", registrationPolicy.Mode())
+	log.Printf("📝 OIDC providers: %v", oidcService.Providers())
+	log.Printf("📝 User uniqueness scope: %s", cfg.UserUniquenessScope)
 	log.Println("========================================")
 	log.Println("📚 Available Endpoints:")
 	log.Println("")
 	log.Println("   🔓 Public (No Auth):")
+	log.Println("   - GET  /.well-known/jwks.json")
 	log.Println("   - POST /auth/register")
+	log.Println("   - POST /auth/register/invite")
 	log.Println("   - POST /auth/login")
+	log.Println("   - POST /auth/refresh")
+	log.Println("   - POST /auth/forgot-password")
+	log.Println("   - POST /auth/reset-password")
+	log.Println("   - POST /auth/verify/send")
+	log.Println("   - POST /auth/verify/confirm")
+	log.Println("   - POST /auth/2fa/verify")
+	log.Println("   - GET  /auth/oidc/providers")
+	log.Println("   - GET  /auth/oidc/:provider/login")
+	log.Println("   - GET  /auth/oidc/:provider/callback")
+	log.Println("   - GET  /.well-known/oauth-authorization-server")
+	log.Println("   - GET  /oauth/authorize (redirects to consent page)")
+	log.Println("   - GET  /oauth/authorize/details")
+	log.Println("   - POST /oauth/token (client authentication)")
+	log.Println("   - POST /oauth/introspect (client authentication)")
+	log.Println("   - POST /oauth/revoke (client authentication)")
 	log.Println("")
 	log.Println("   🔒 Auth Required:")
-	log.Println("   - POST /auth/logout")
+	log.Println("   - POST   /auth/logout")
+	log.Println("   - GET    /auth/sessions")
+	log.Println("   - DELETE /auth/sessions (log out everywhere)")
+	log.Println("   - DELETE /auth/sessions/:id")
+	log.Println("   - POST   /auth/2fa/enroll")
+	log.Println("   - POST   /auth/2fa/confirm")
+	log.Println("   - POST   /auth/2fa/disable")
+	log.Println("   - POST   /auth/2fa/recovery-codes")
+	log.Println("   - POST   /auth/oidc/:provider/link")
+	log.Println("   - GET    /auth/oidc/identities")
+	log.Println("   - DELETE /auth/oidc/identities/:id")
+	log.Println("   - GET    /auth/api-keys")
+	log.Println("   - POST   /auth/api-keys")
+	log.Println("   - DELETE /auth/api-keys/:id")
+	log.Println("   - GET    /auth/organizations")
+	log.Println("   - POST   /auth/organizations/switch")
+	log.Println("   - POST   /oauth/authorize (consent decision)")
 	log.Println("")
-	log.Println("   👑 Admin Only:")
+	log.Println("   👑 Admin (per permission):")
 	log.Println("   - GET    /admin/dashboard")
 	log.Println("   - GET    /admin/user (list active users)")
 	log.Println("   - GET    /admin/user/deleted (list deleted users)")
@@ -161,15 +353,60 @@ func main() {
 	log.Println("   - DELETE /admin/user/:id (soft delete)")
 	log.Println("   - DELETE /admin/user/permanent/:id (hard delete)")
 	log.Println("   - POST   /admin/user/restore/:id (restore)")
+	log.Println("   - POST   /admin/user/logout/:id (force logout)")
+	log.Println("   - POST   /admin/user/unlock/:id (unlock after lockout)")
+	log.Println("   - GET    /admin/user/lockouts/:id (lockout history)")
+	log.Println("   - GET    /admin/invitations")
+	log.Println("   - POST   /admin/invitations")
+	log.Println("   - POST   /admin/invitations/:id/resend")
+	log.Println("   - DELETE /admin/invitations/:id")
+	log.Println("   - GET    /admin/2fa/policies")
+	log.Println("   - PUT    /admin/2fa/policies")
+	log.Println("   - GET    /admin/oauth/clients")
+	log.Println("   - POST   /admin/oauth/clients")
+	log.Println("   - GET    /admin/oauth/clients/:id")
+	log.Println("   - PUT    /admin/oauth/clients/:id")
+	log.Println("   - POST   /admin/oauth/clients/:id/secret")
+	log.Println("   - DELETE /admin/oauth/clients/:id (disable + revoke tokens)")
+	log.Println("   - GET    /admin/permissions")
+	log.Println("   - GET    /admin/roles")
+	log.Println("   - POST   /admin/roles")
+	log.Println("   - GET    /admin/roles/:id")
+	log.Println("   - PUT    /admin/roles/:id")
+	log.Println("   - DELETE /admin/roles/:id")
+	log.Println("   - GET    /admin/organizations")
+	log.Println("   - POST   /admin/organizations")
+	log.Println("   - GET    /admin/organizations/:id")
+	log.Println("   - PUT    /admin/organizations/:id")
+	log.Println("   - GET    /admin/organizations/:id/members")
+	log.Println("   - POST   /admin/organizations/:id/members")
+	log.Println("   - PUT    /admin/organizations/:id/members/:userId")
+	log.Println("   - DELETE /admin/organizations/:id/members/:userId")
 	log.Println("")
 	log.Println("   👤 User Only:")
 	log.Println("   - GET /user/dashboard")
 	log.Println("   - GET /user/profile")
 	log.Println("   - PUT /user/profile/update")
+	log.Println("   - PUT /user/profile/change-password")
 	log.Println("========================================")
 	log.Println("Press Ctrl+C to shutdown server")
 
-	if err := app.Listen(port); err != nil {
-		log.Fatalf("❌ Failed to start server: %v", err)
+	go func() {
+		if err := app.Listen(port); err != nil {
+			log.Fatalf("❌ Failed to start server: %v", err)
+		}
+	}()
+
+	// ============================================
+	// 10. GRACEFUL SHUTDOWN
+	// ============================================
+	<-ctx.Done()
+	log.Println("🛑 Shutting down server...")
+
+	if err := app.Shutdown(); err != nil {
+		log.Printf("❌ Failed to shutdown server: %v", err)
 	}
+	tokenJanitor.Wait()
+
+	log.Println("✅ Server stopped")
 }
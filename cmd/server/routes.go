@@ -2,20 +2,47 @@ package main
 
 import (
 	"github.com/LutfiyaAinurrahmanP/boilerplate_fiber_restful_api/internal/handlers"
+	"github.com/LutfiyaAinurrahmanP/boilerplate_fiber_restful_api/internal/jwtauth"
 	"github.com/LutfiyaAinurrahmanP/boilerplate_fiber_restful_api/internal/middlewares"
+	"github.com/LutfiyaAinurrahmanP/boilerplate_fiber_restful_api/internal/models"
 	"github.com/LutfiyaAinurrahmanP/boilerplate_fiber_restful_api/internal/repositories"
+	"github.com/LutfiyaAinurrahmanP/boilerplate_fiber_restful_api/internal/services"
 	"github.com/gofiber/fiber/v2"
 )
 
 type RouteConfig struct {
-	AuthHandler *handlers.AuthHandler
-	UserHandler *handlers.UserHandler
-	JWTSecret   string
-	TokenRepo repositories.TokenRepository
+	AuthHandler          *handlers.AuthHandler
+	UserHandler          *handlers.UserHandler
+	JWKSHandler          *handlers.JWKSHandler
+	SessionHandler       *handlers.SessionHandler
+	PasswordResetHandler *handlers.PasswordResetHandler
+	VerificationHandler  *handlers.VerificationHandler
+	TwoFactorHandler     *handlers.TwoFactorHandler
+	LockoutHandler       *handlers.LockoutHandler
+	InvitationHandler    *handlers.InvitationHandler
+	OIDCHandler          *handlers.OIDCHandler
+	APIKeyHandler        *handlers.APIKeyHandler
+	OAuthHandler         *handlers.OAuthHandler
+	RoleHandler          *handlers.RoleHandler
+	OrganizationHandler  *handlers.OrganizationHandler
+	TokenManager         *jwtauth.Manager
+	TokenRepo            repositories.TokenRepository
+	SessionRepo          repositories.SessionRepository
+	UserRepo             repositories.UserRepository
+	TwoFactorPolicyRepo  repositories.TwoFactorPolicyRepository
+	APIKeyRepo           repositories.APIKeyRepository
+	RoleService          services.RoleService
 }
 
 // SetupRoutes mendaftarkan semua routes ke Fiber app
 func SetupRoutes(app *fiber.App, config *RouteConfig) {
+	// JWT authentication middleware (dipakai bersama oleh semua protected routes)
+	jwtAuth := middlewares.JWTAuthMiddleware(config.TokenManager, config.TokenRepo, config.SessionRepo, config.UserRepo)
+
+	// JWT atau API key (Authorization: ApiKey <key> / X-API-Key), hanya untuk route /admin dan /user
+	// Route /auth tetap hanya menerima JWT agar API key tidak bisa membuat key baru atau mengubah login
+	jwtOrAPIKey := middlewares.JWTOrAPIKeyMiddleware(jwtAuth, middlewares.APIKeyAuthMiddleware(config.APIKeyRepo, config.UserRepo))
+
 	// ============================================
 	// ROOT & HEALTH CHECK ENDPOINTS
 	// ============================================
@@ -36,6 +63,12 @@ func SetupRoutes(app *fiber.App, config *RouteConfig) {
 		})
 	})
 
+	// GET /.well-known/jwks.json - Public verification keys (JWKS)
+	app.Get("/.well-known/jwks.json", config.JWKSHandler.GetJWKS)
+
+	// GET /.well-known/oauth-authorization-server - OAuth2 authorization server metadata (RFC 8414)
+	app.Get("/.well-known/oauth-authorization-server", config.OAuthHandler.Metadata)
+
 	// ============================================
 	// PUBLIC ROUTES - No Authentication Required
 	// ============================================
@@ -43,28 +76,159 @@ func SetupRoutes(app *fiber.App, config *RouteConfig) {
 	// Authentication routes (public)
 	auth := app.Group("/auth")
 	{
-		// POST /auth/register - Public registration (default role: user)
+		// POST /auth/register - Public registration (always role user, depends on REGISTRATION_MODE)
 		auth.Post("/register", config.AuthHandler.Register)
 
+		// POST /auth/register/invite - Register by accepting an admin invitation
+		auth.Post("/register/invite", config.InvitationHandler.AcceptInvitation)
+
 		// POST /auth/login - Login and get JWT token
 		auth.Post("/login", config.AuthHandler.Login)
 
+		// POST /auth/refresh - Rotate refresh token and get new access token
+		auth.Post("/refresh", config.AuthHandler.Refresh)
+
+		// POST /auth/forgot-password - Send password reset link (same response for unknown emails)
+		auth.Post("/forgot-password", config.PasswordResetHandler.ForgotPassword)
+
+		// POST /auth/reset-password - Reset password with single-use token
+		auth.Post("/reset-password", config.PasswordResetHandler.ResetPassword)
+
+		// POST /auth/verify/send - Send (or resend) email / phone verification code (rate limited)
+		auth.Post("/verify/send", config.VerificationHandler.SendCode)
+
+		// POST /auth/verify/confirm - Confirm email / phone with verification code
+		auth.Post("/verify/confirm", config.VerificationHandler.ConfirmCode)
+
+		// POST /auth/2fa/verify - Exchange "mfa pending" token + TOTP / recovery code for tokens
+		auth.Post("/2fa/verify", config.AuthHandler.VerifyTwoFactor)
+
 		// POST /auth/logout - Logout (client-side operation)
 		auth.Post("/logout",
-			middlewares.JWTAuthMiddleware(config.JWTSecret, config.TokenRepo),
+			jwtAuth,
 			config.AuthHandler.Logout,
 		)
+
+		// Session management (log out everywhere)
+		sessions := auth.Group("/sessions")
+		sessions.Use(jwtAuth)
+		{
+			// GET /auth/sessions - List own active sessions
+			sessions.Get("/", config.SessionHandler.GetSessions)
+
+			// DELETE /auth/sessions - Revoke all own sessions
+			sessions.Delete("/", config.SessionHandler.RevokeAllSessions)
+
+			// DELETE /auth/sessions/:id - Revoke one own session
+			sessions.Delete("/:id", config.SessionHandler.RevokeSession)
+		}
+
+		// Two-factor authentication (TOTP) management
+		twoFactor := auth.Group("/2fa")
+		twoFactor.Use(jwtAuth)
+		{
+			// POST /auth/2fa/enroll - Generate TOTP secret and otpauth URI
+			twoFactor.Post("/enroll", config.TwoFactorHandler.Enroll)
+
+			// POST /auth/2fa/confirm - Enable 2FA with first TOTP code, returns recovery codes
+			twoFactor.Post("/confirm", config.TwoFactorHandler.Confirm)
+
+			// POST /auth/2fa/disable - Disable 2FA (password + TOTP / recovery code)
+			twoFactor.Post("/disable", config.TwoFactorHandler.Disable)
+
+			// POST /auth/2fa/recovery-codes - Regenerate recovery codes
+			twoFactor.Post("/recovery-codes", config.TwoFactorHandler.RegenerateRecoveryCodes)
+		}
+
+		// OpenID Connect login (Google, Microsoft, identity provider perusahaan, dll)
+		oidcRoutes := auth.Group("/oidc")
+		{
+			// GET /auth/oidc/providers - List configured identity providers
+			oidcRoutes.Get("/providers", config.OIDCHandler.GetProviders)
+
+			// GET /auth/oidc/identities - List identity providers linked to own account
+			oidcRoutes.Get("/identities", jwtAuth, config.OIDCHandler.GetIdentities)
+
+			// DELETE /auth/oidc/identities/:id - Unlink identity provider from own account
+			oidcRoutes.Delete("/identities/:id", jwtAuth, config.OIDCHandler.UnlinkIdentity)
+
+			// GET /auth/oidc/:provider/login - Redirect to identity provider (authorization code + PKCE)
+			oidcRoutes.Get("/:provider/login", config.OIDCHandler.Login)
+
+			// GET /auth/oidc/:provider/callback - Redirect target from identity provider, returns tokens
+			oidcRoutes.Get("/:provider/callback", config.OIDCHandler.Callback)
+
+			// POST /auth/oidc/:provider/link - Start linking identity provider to own account
+			oidcRoutes.Post("/:provider/link", jwtAuth, config.OIDCHandler.Link)
+		}
+
+		// Organisasi milik user dan organisasi aktif session
+		organizations := auth.Group("/organizations")
+		organizations.Use(jwtAuth)
+		{
+			// GET /auth/organizations - List organizations the user belongs to (with role per organization)
+			organizations.Get("/", config.OrganizationHandler.GetMyOrganizations)
+
+			// POST /auth/organizations/switch - Switch active organization of the current session, returns new tokens
+			organizations.Post("/switch", middlewares.RequireSession(), config.OrganizationHandler.SwitchOrganization)
+		}
+
+		// API keys / personal access tokens untuk CI dan script
+		apiKeys := auth.Group("/api-keys")
+		apiKeys.Use(jwtAuth)
+		apiKeys.Use(middlewares.RequireTwoFactorPolicy(config.TwoFactorPolicyRepo))
+		{
+			// GET /auth/api-keys - List own API keys (with last used time)
+			apiKeys.Get("/", config.APIKeyHandler.GetAPIKeys)
+
+			// POST /auth/api-keys - Create API key (secret is shown once)
+			apiKeys.Post("/", config.APIKeyHandler.CreateAPIKey)
+
+			// DELETE /auth/api-keys/:id - Revoke own API key
+			apiKeys.Delete("/:id", config.APIKeyHandler.RevokeAPIKey)
+		}
+	}
+
+	// OAuth2 authorization server untuk aplikasi internal (format response mengikuti RFC)
+	oauth := app.Group("/oauth")
+	{
+		// GET /oauth/authorize - Validate authorization request and redirect to consent page
+		oauth.Get("/authorize", config.OAuthHandler.Authorize)
+
+		// GET /oauth/authorize/details - Client name and requested scopes for the consent page
+		oauth.Get("/authorize/details", config.OAuthHandler.GetAuthorizationDetails)
+
+		// POST /oauth/authorize - Approve / deny as the logged in user, returns redirect URL with code
+		oauth.Post("/authorize", jwtAuth, config.OAuthHandler.ApproveAuthorization)
+
+		// POST /oauth/token - authorization_code (PKCE), refresh_token, client_credentials
+		oauth.Post("/token", config.OAuthHandler.Token)
+
+		// POST /oauth/introspect - Token introspection for resource servers (RFC 7662)
+		oauth.Post("/introspect", config.OAuthHandler.Introspect)
+
+		// POST /oauth/revoke - Token revocation (RFC 7009)
+		oauth.Post("/revoke", config.OAuthHandler.Revoke)
 	}
 
 	// ============================================
-	// ADMIN ROUTES - Admin Role Required
+	// ADMIN ROUTES - Permission Required
 	// ============================================
 	// Prefix: /admin
-	// Middleware: JWT Authentication + Admin Role
+	// Middleware: JWT Authentication + permission admin.access + permission per route
+	// Permission dibaca dari role store (tabel roles / role_permissions)
+	// Semua query user di-scope ke organisasi aktif pemanggil (claim "org" / organisasi asal pemilik API key)
 
 	admin := app.Group("/admin")
-	admin.Use(middlewares.JWTAuthMiddleware(config.JWTSecret, config.TokenRepo)) // Require authentication
-	admin.Use(middlewares.RequireAdmin())                      // Require admin role
+	admin.Use(jwtOrAPIKey)                                                 // Require authentication (JWT or API key)
+	admin.Use(middlewares.LoadPermissions(config.RoleService))             // Load permissions of the caller's role
+	admin.Use(middlewares.RequirePermission(models.PermissionAdminAccess)) // Require access to the admin area
+	// Require 2FA jika diwajibkan untuk role admin
+	admin.Use(middlewares.RequireTwoFactorPolicy(config.TwoFactorPolicyRepo))
+	// API key wajib punya scope admin, plus read (GET) / write (method lain)
+	admin.Use(middlewares.RequireAPIKeyScope(models.APIKeyScopeAdmin))
+	admin.Use(middlewares.RequireAPIKeyMethodScope())
+	admin.Use(middlewares.RequireOrganization())
 	{
 		// GET /admin/dashboard - Admin dashboard
 		// TODO: Implement admin dashboard handler
@@ -79,7 +243,7 @@ func SetupRoutes(app *fiber.App, config *RouteConfig) {
 				"data": fiber.Map{
 					"admin_id":       userID,
 					"admin_username": username,
-					"role":           "admin",
+					"role":           middlewares.GetRoleFromContext(c),
 				},
 			})
 		})
@@ -90,30 +254,143 @@ func SetupRoutes(app *fiber.App, config *RouteConfig) {
 		{
 			// GET /admin/user - List all users with pagination, search, filter
 			// Query params: page, limit, search, role, sort, sort_by
-			user.Get("/", config.UserHandler.GetAllUsers)
+			user.Get("/", middlewares.RequirePermission(models.PermissionUsersRead), config.UserHandler.GetAllUsers)
 
 			// POST /admin/user/create - Create new user (admin can choose role)
-			user.Post("/create", config.UserHandler.CreateUser)
+			user.Post("/create", middlewares.RequirePermission(models.PermissionUsersCreate), config.UserHandler.CreateUser)
 
 			// GET /admin/user/deleted - List all soft deleted users
-			user.Get("/deleted", config.UserHandler.GetAllDeletedUsers)
+			user.Get("/deleted", middlewares.RequirePermission(models.PermissionUsersRead), config.UserHandler.GetAllDeletedUsers)
 
 			// GET /admin/user/:id - Get specific user by ID
-			user.Get("/:id", config.UserHandler.GetUserByID)
+			user.Get("/:id", middlewares.RequirePermission(models.PermissionUsersRead), config.UserHandler.GetUserByID)
 
 			// PUT /admin/user/update/:id - Update user by ID
-			user.Put("/update/:id", config.UserHandler.UpdateUser)
+			user.Put("/update/:id", middlewares.RequirePermission(models.PermissionUsersUpdate), config.UserHandler.UpdateUser)
 
 			// DELETE /admin/user/:id - Soft delete user by ID
-			user.Delete("/:id", config.UserHandler.DeleteUser)
+			user.Delete("/:id", middlewares.RequirePermission(models.PermissionUsersDeactivate), config.UserHandler.DeleteUser)
 
 			// DELETE /admin/user/permanent/:id - Hard delete user (permanent)
-			user.Delete("/permanent/:id", config.UserHandler.HardDeleteUser)
+			user.Delete("/permanent/:id", middlewares.RequirePermission(models.PermissionUsersDelete), config.UserHandler.HardDeleteUser)
 
 			// POST /admin/user/restore/:id - Restore soft deleted user
-			user.Post("/restore/:id", config.UserHandler.RestoreUser)
+			user.Post("/restore/:id", middlewares.RequirePermission(models.PermissionUsersDeactivate), config.UserHandler.RestoreUser)
+
+			// POST /admin/user/logout/:id - Force logout user from all sessions
+			user.Post("/logout/:id", middlewares.RequirePermission(models.PermissionUsersLogout), config.SessionHandler.ForceLogoutUser)
+
+			// POST /admin/user/unlock/:id - Unlock account locked by failed logins
+			user.Post("/unlock/:id", middlewares.RequirePermission(models.PermissionUsersUnlock), config.LockoutHandler.UnlockUser)
+
+			// GET /admin/user/lockouts/:id - Lockout / unlock history
+			user.Get("/lockouts/:id", middlewares.RequirePermission(models.PermissionUsersRead), config.LockoutHandler.GetLockoutEvents)
+		}
+
+		// Invitation Routes (Admin)
+		// Prefix: /admin/invitations
+		invitations := admin.Group("/invitations")
+		invitations.Use(middlewares.RequirePermission(models.PermissionInvitationsManage))
+		{
+			// GET /admin/invitations - List invitations (query: status=pending|accepted|revoked|expired|all)
+			invitations.Get("/", config.InvitationHandler.GetInvitations)
+
+			// POST /admin/invitations - Create invitation and send invite link
+			invitations.Post("/", config.InvitationHandler.CreateInvitation)
+
+			// POST /admin/invitations/:id/resend - Send a new invite link
+			invitations.Post("/:id/resend", config.InvitationHandler.ResendInvitation)
+
+			// DELETE /admin/invitations/:id - Revoke pending invitation
+			invitations.Delete("/:id", config.InvitationHandler.RevokeInvitation)
+		}
+
+		// OAuth2 Client Routes (Admin)
+		// Prefix: /admin/oauth/clients
+		oauthClients := admin.Group("/oauth/clients")
+		oauthClients.Use(middlewares.RequirePermission(models.PermissionOAuthClientsManage))
+		{
+			// GET /admin/oauth/clients - List registered clients
+			oauthClients.Get("/", config.OAuthHandler.GetClients)
+
+			// POST /admin/oauth/clients - Register client (secret is shown once)
+			oauthClients.Post("/", config.OAuthHandler.CreateClient)
+
+			// GET /admin/oauth/clients/:id - Get client by ID
+			oauthClients.Get("/:id", config.OAuthHandler.GetClient)
+
+			// PUT /admin/oauth/clients/:id - Update name, redirect URIs, grant types, scopes
+			oauthClients.Put("/:id", config.OAuthHandler.UpdateClient)
+
+			// POST /admin/oauth/clients/:id/secret - Rotate client secret
+			oauthClients.Post("/:id/secret", config.OAuthHandler.RotateClientSecret)
+
+			// DELETE /admin/oauth/clients/:id - Disable client and revoke its tokens
+			oauthClients.Delete("/:id", config.OAuthHandler.DisableClient)
 		}
 
+		// Role & Permission Routes (Admin)
+		// Prefix: /admin/roles
+		roles := admin.Group("/roles")
+		roles.Use(middlewares.RequirePermission(models.PermissionRolesManage))
+		{
+			// GET /admin/roles - List roles with their permissions
+			roles.Get("/", config.RoleHandler.GetRoles)
+
+			// POST /admin/roles - Create role (only permissions the caller has)
+			roles.Post("/", config.RoleHandler.CreateRole)
+
+			// GET /admin/roles/:id - Get role by ID
+			roles.Get("/:id", config.RoleHandler.GetRole)
+
+			// PUT /admin/roles/:id - Update description / replace permissions
+			roles.Put("/:id", config.RoleHandler.UpdateRole)
+
+			// DELETE /admin/roles/:id - Delete role (not system roles, not assigned to users)
+			roles.Delete("/:id", config.RoleHandler.DeleteRole)
+		}
+
+		// Organization Routes (Admin)
+		// Prefix: /admin/organizations
+		// Anggota organisasi default mengelola semua organisasi, organisasi lain hanya organisasinya sendiri
+		organizations := admin.Group("/organizations")
+		organizations.Use(middlewares.RequirePermission(models.PermissionOrganizationsManage))
+		{
+			// GET /admin/organizations - List organizations
+			organizations.Get("/", config.OrganizationHandler.GetOrganizations)
+
+			// POST /admin/organizations - Create organization (default organization only)
+			organizations.Post("/", config.OrganizationHandler.CreateOrganization)
+
+			// GET /admin/organizations/:id - Get organization by ID
+			organizations.Get("/:id", config.OrganizationHandler.GetOrganization)
+
+			// PUT /admin/organizations/:id - Rename organization
+			organizations.Put("/:id", config.OrganizationHandler.UpdateOrganization)
+
+			// GET /admin/organizations/:id/members - List members with their role in the organization
+			organizations.Get("/:id/members", config.OrganizationHandler.GetMembers)
+
+			// POST /admin/organizations/:id/members - Add existing user as member (default organization only)
+			organizations.Post("/:id/members", config.OrganizationHandler.AddMember)
+
+			// PUT /admin/organizations/:id/members/:userId - Change member role
+			organizations.Put("/:id/members/:userId", config.OrganizationHandler.UpdateMember)
+
+			// DELETE /admin/organizations/:id/members/:userId - Remove member (not from their home organization)
+			organizations.Delete("/:id/members/:userId", config.OrganizationHandler.RemoveMember)
+		}
+
+		// GET /admin/permissions - Permission catalog
+		admin.Get("/permissions", middlewares.RequirePermission(models.PermissionRolesManage), config.RoleHandler.GetPermissions)
+
+		// Two-factor policy per role
+		// GET /admin/2fa/policies - List roles that require 2FA
+		admin.Get("/2fa/policies", middlewares.RequirePermission(models.PermissionSecurityManage), config.TwoFactorHandler.GetPolicies)
+
+		// PUT /admin/2fa/policies - Require / stop requiring 2FA for a role
+		admin.Put("/2fa/policies", middlewares.RequirePermission(models.PermissionSecurityManage), config.TwoFactorHandler.SetPolicy)
+
 		// Future admin routes bisa ditambahkan di sini
 		// admin.Get("/reports", config.ReportHandler.GetReports)
 		// admin.Get("/settings", config.SettingHandler.GetSettings)
@@ -121,14 +398,18 @@ func SetupRoutes(app *fiber.App, config *RouteConfig) {
 	}
 
 	// ============================================
-	// USER ROUTES - User Role Required
+	// USER ROUTES - User Role or Higher Required
 	// ============================================
 	// Prefix: /user
-	// Middleware: JWT Authentication + User Role
+	// Middleware: JWT Authentication + Role level >= user (admin dan role lain di atas user ikut bisa akses)
 
 	userRoute := app.Group("/user")
-	userRoute.Use(middlewares.JWTAuthMiddleware(config.JWTSecret, config.TokenRepo)) // Require authentication
-	userRoute.Use(middlewares.RequireUser())                       // Require user role
+	userRoute.Use(jwtOrAPIKey) // Require authentication (JWT or API key)
+	userRoute.Use(middlewares.RequireMinimumRole(config.RoleService, models.RoleUser))
+	// Require 2FA jika diwajibkan untuk role user
+	userRoute.Use(middlewares.RequireTwoFactorPolicy(config.TwoFactorPolicyRepo))
+	// API key wajib punya scope read (GET) / write (method lain)
+	userRoute.Use(middlewares.RequireAPIKeyMethodScope())
 	{
 		// GET /user/dashboard - User dashboard
 		// TODO: Implement user dashboard handler
@@ -143,7 +424,7 @@ func SetupRoutes(app *fiber.App, config *RouteConfig) {
 				"data": fiber.Map{
 					"user_id":       userID,
 					"user_username": username,
-					"role":          "user",
+					"role":          middlewares.GetRoleFromContext(c),
 				},
 			})
 		})
@@ -157,8 +438,10 @@ func SetupRoutes(app *fiber.App, config *RouteConfig) {
 			// PUT /user/profile/update - Update own profile
 			profile.Put("/update", config.UserHandler.UpdateProfile)
 
+			// PUT /user/profile/change-password - Change own password (revokes other sessions)
+			profile.Put("/change-password", middlewares.RequireSession(), config.UserHandler.ChangePassword)
+
 			// Future profile routes
-			// profile.Put("/change-password", config.UserHandler.ChangePassword)
 			// profile.Post("/avatar", config.UserHandler.UploadAvatar)
 		}
 
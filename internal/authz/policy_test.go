@@ -0,0 +1,167 @@
+package authz
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/LutfiyaAinurrahmanP/boilerplate_fiber_restful_api/internal/models"
+	"github.com/LutfiyaAinurrahmanP/boilerplate_fiber_restful_api/internal/utils"
+	"gorm.io/gorm"
+)
+
+// fakeMembers adalah MemberCounter dan MembershipLookup in-memory
+type fakeMembers struct {
+	admins  int64
+	members map[uint]bool // userID -> anggota organisasi subject
+	err     error
+}
+
+func (f *fakeMembers) CountActiveMembers(organizationID uint, role string) (int64, error) {
+	return f.admins, f.err
+}
+
+func (f *fakeMembers) FindMember(organizationID, userID uint) (*models.OrganizationMember, error) {
+	if f.err != nil {
+		return nil, f.err
+	}
+	if !f.members[userID] {
+		return nil, gorm.ErrRecordNotFound
+	}
+	return &models.OrganizationMember{OrganizationID: organizationID, UserID: userID}, nil
+}
+
+func userWithID(id uint, role string) *models.User {
+	user := &models.User{Role: role}
+	user.ID = id
+	return user
+}
+
+func TestEngineAuthorize(t *testing.T) {
+	calls := []string{}
+	rule := func(name string, result error) Rule {
+		return Rule{
+			Name:    name,
+			Actions: []string{"test.action"},
+			Check: func(req Request) error {
+				calls = append(calls, name)
+				return result
+			},
+		}
+	}
+	evalErr := errors.New("lookup failed")
+
+	tests := []struct {
+		name      string
+		rules     []Rule
+		action    string
+		wantRule  string
+		wantErr   error
+		wantCalls []string
+	}{
+		{"no rules allows", nil, "test.action", "", nil, []string{}},
+		{"other action allows", []Rule{rule("deny", Deny("no"))}, "other.action", "", nil, []string{}},
+		{"all rules pass", []Rule{rule("a", nil), rule("b", nil)}, "test.action", "", nil, []string{"a", "b"}},
+		{"first denial wins", []Rule{rule("a", nil), rule("b", Deny("no")), rule("c", Deny("no"))}, "test.action", "b", nil, []string{"a", "b"}},
+		{"evaluation error stops", []Rule{rule("a", evalErr), rule("b", nil)}, "test.action", "", evalErr, []string{"a"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			calls = []string{}
+			err := NewEngine(tt.rules...).Authorize(Subject{UserID: 1}, tt.action, nil)
+
+			if len(calls) != len(tt.wantCalls) {
+				t.Fatalf("rules called = %v, want %v", calls, tt.wantCalls)
+			}
+			for i := range calls {
+				if calls[i] != tt.wantCalls[i] {
+					t.Fatalf("rules called = %v, want %v", calls, tt.wantCalls)
+				}
+			}
+
+			switch {
+			case tt.wantRule != "":
+				var denied *DeniedError
+				if !errors.As(err, &denied) || denied.Rule != tt.wantRule {
+					t.Errorf("Authorize() error = %v, want denial by %s", err, tt.wantRule)
+				}
+			case tt.wantErr != nil:
+				if !errors.Is(err, tt.wantErr) || IsDenied(err) {
+					t.Errorf("Authorize() error = %v, want %v", err, tt.wantErr)
+				}
+			default:
+				if err != nil {
+					t.Errorf("Authorize() error = %v, want nil", err)
+				}
+			}
+		})
+	}
+}
+
+func TestDefaultRules(t *testing.T) {
+	admin := Subject{UserID: 1, Role: models.RoleAdmin, OrganizationID: 10}
+	support := Subject{UserID: 2, Role: RoleSupport, OrganizationID: 10}
+
+	tests := []struct {
+		name      string
+		members   *fakeMembers
+		subject   Subject
+		action    string
+		resource  interface{}
+		wantCode  string
+		wantDeny  bool
+		wantError bool
+	}{
+		{"delete other user", &fakeMembers{}, admin, ActionDeleteUser, userWithID(5, models.RoleUser), "", false, false},
+		{"delete self", &fakeMembers{}, admin, ActionDeleteUser, userWithID(1, models.RoleAdmin), "", true, false},
+		{"hard delete self", &fakeMembers{}, admin, ActionHardDeleteUser, userWithID(1, models.RoleAdmin), "", true, false},
+		{"delete with wrong resource", &fakeMembers{}, admin, ActionDeleteUser, "user", "", false, true},
+
+		{"demote one of two admins", &fakeMembers{admins: 2}, admin, ActionChangeRole,
+			RoleChange{User: userWithID(5, models.RoleAdmin), OrganizationID: 10, Role: models.RoleUser}, "", false, false},
+		{"demote last admin", &fakeMembers{admins: 1}, admin, ActionChangeRole,
+			RoleChange{User: userWithID(5, models.RoleAdmin), OrganizationID: 10, Role: models.RoleUser}, utils.ErrorCodeLastAdmin, true, false},
+		{"keep last admin as admin", &fakeMembers{admins: 1}, admin, ActionChangeRole,
+			RoleChange{User: userWithID(5, models.RoleAdmin), OrganizationID: 10, Role: models.RoleAdmin}, "", false, false},
+		{"promote user", &fakeMembers{admins: 1}, admin, ActionChangeRole,
+			RoleChange{User: userWithID(5, models.RoleUser), OrganizationID: 10, Role: models.RoleAdmin}, "", false, false},
+		{"count fails", &fakeMembers{err: errors.New("db down")}, admin, ActionChangeRole,
+			RoleChange{User: userWithID(5, models.RoleAdmin), OrganizationID: 10, Role: models.RoleUser}, "", false, true},
+
+		{"support views member", &fakeMembers{members: map[uint]bool{5: true}}, support, ActionViewUser, userWithID(5, models.RoleUser), "", false, false},
+		{"support views non-member", &fakeMembers{}, support, ActionViewUser, userWithID(5, models.RoleUser), "", true, false},
+		{"admin views non-member", &fakeMembers{}, admin, ActionViewUser, userWithID(5, models.RoleUser), "", false, false},
+		{"membership lookup fails", &fakeMembers{err: errors.New("db down")}, support, ActionViewUser, userWithID(5, models.RoleUser), "", false, true},
+		{"update has no rules", &fakeMembers{}, admin, ActionUpdateUser, userWithID(1, models.RoleAdmin), "", false, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			engine := NewEngine(DefaultRules(tt.members, tt.members)...)
+			err := engine.Authorize(tt.subject, tt.action, tt.resource)
+
+			var denied *DeniedError
+			isDenied := errors.As(err, &denied)
+			switch {
+			case tt.wantDeny:
+				if !isDenied {
+					t.Fatalf("Authorize() error = %v, want denial", err)
+				}
+				if denied.Code != tt.wantCode {
+					t.Errorf("denial code = %q, want %q", denied.Code, tt.wantCode)
+				}
+				if denied.Rule == "" {
+					t.Error("denial has no rule name")
+				}
+			case tt.wantError:
+				if err == nil || isDenied {
+					t.Errorf("Authorize() error = %v, want evaluation error", err)
+				}
+			default:
+				if err != nil {
+					t.Errorf("Authorize() error = %v, want nil", err)
+				}
+			}
+		})
+	}
+}
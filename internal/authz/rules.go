@@ -0,0 +1,112 @@
+package authz
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/LutfiyaAinurrahmanP/boilerplate_fiber_restful_api/internal/models"
+	"github.com/LutfiyaAinurrahmanP/boilerplate_fiber_restful_api/internal/utils"
+	"gorm.io/gorm"
+)
+
+// RoleSupport adalah role helpdesk yang dibatasi OwnOrganizationOnly di DefaultRules
+// Role ini tidak di-seed, aturan hanya berlaku jika role dengan nama tersebut dibuat lewat /admin/roles
+const RoleSupport = "support"
+
+// RoleChange adalah resource ActionChangeRole: User diberi Role baru di organisasi OrganizationID
+type RoleChange struct {
+	User           *models.User
+	OrganizationID uint
+	Role           string
+}
+
+// MemberCounter menghitung anggota aktif (user belum dihapus) dengan role tertentu (repositories.OrganizationRepository)
+type MemberCounter interface {
+	CountActiveMembers(organizationID uint, role string) (int64, error)
+}
+
+// MembershipLookup mencari keanggotaan user di organisasi (repositories.OrganizationRepository)
+type MembershipLookup interface {
+	FindMember(organizationID, userID uint) (*models.OrganizationMember, error)
+}
+
+// DefaultRules adalah aturan bawaan aplikasi
+func DefaultRules(members MemberCounter, memberships MembershipLookup) []Rule {
+	return []Rule{
+		CannotDeleteSelf(),
+		CannotDemoteLastAdmin(members),
+		OwnOrganizationOnly(RoleSupport, memberships),
+	}
+}
+
+// CannotDeleteSelf menolak soft delete maupun hard delete akun sendiri
+func CannotDeleteSelf() Rule {
+	return Rule{
+		Name:    "cannot_delete_self",
+		Actions: []string{ActionDeleteUser, ActionHardDeleteUser},
+		Check: func(req Request) error {
+			user, ok := req.Resource.(*models.User)
+			if !ok {
+				return fmt.Errorf("unexpected resource %T for %s", req.Resource, req.Action)
+			}
+			if user.ID == req.Subject.UserID {
+				return Deny("you cannot delete your own account")
+			}
+			return nil
+		},
+	}
+}
+
+// CannotDemoteLastAdmin menolak perubahan role admin terakhir di organisasi menjadi role lain
+// Ini hanya pengecekan awal, UserService tetap menjaga invariant yang sama secara transaksional
+func CannotDemoteLastAdmin(members MemberCounter) Rule {
+	return Rule{
+		Name:    "cannot_demote_last_admin",
+		Actions: []string{ActionChangeRole},
+		Check: func(req Request) error {
+			change, ok := req.Resource.(RoleChange)
+			if !ok {
+				return fmt.Errorf("unexpected resource %T for %s", req.Resource, req.Action)
+			}
+			if change.User.Role != models.RoleAdmin || change.Role == models.RoleAdmin {
+				return nil
+			}
+
+			admins, err := members.CountActiveMembers(change.OrganizationID, models.RoleAdmin)
+			if err != nil {
+				return fmt.Errorf("failed to count admins: %w", err)
+			}
+			if admins <= 1 {
+				return DenyWithCode(utils.ErrorCodeLastAdmin, "cannot remove the last active admin")
+			}
+			return nil
+		},
+	}
+}
+
+// OwnOrganizationOnly membatasi role tertentu agar hanya bisa melihat user yang menjadi anggota organisasi aktifnya
+// Query admin sudah di-scope ke organisasi (UserService.ForOrganization), rule ini menjaga aturan yang sama
+// di level policy sehingga tidak bergantung pada scoping repository saja. List user tidak dievaluasi per baris
+func OwnOrganizationOnly(role string, memberships MembershipLookup) Rule {
+	return Rule{
+		Name:    "own_organization_only:" + role,
+		Actions: []string{ActionViewUser},
+		Check: func(req Request) error {
+			if req.Subject.Role != role {
+				return nil
+			}
+			user, ok := req.Resource.(*models.User)
+			if !ok {
+				return fmt.Errorf("unexpected resource %T for %s", req.Resource, req.Action)
+			}
+
+			if _, err := memberships.FindMember(req.Subject.OrganizationID, user.ID); err != nil {
+				if errors.Is(err, gorm.ErrRecordNotFound) {
+					return Deny(role + " can only view users of their own organization")
+				}
+				return fmt.Errorf("failed to find membership: %w", err)
+			}
+			return nil
+		},
+	}
+}
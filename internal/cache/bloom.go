@@ -0,0 +1,69 @@
+package cache
+
+import (
+	"crypto/sha256"
+	"encoding/binary"
+	"math"
+	"sync"
+)
+
+// BloomFilter menjawab "pasti tidak ada" tanpa akses database
+// Jawaban "mungkin ada" tetap harus dicek ke source of truth
+type BloomFilter struct {
+	mu     sync.RWMutex
+	bits   []uint64
+	size   uint64
+	hashes uint64
+}
+
+// NewBloomFilter membuat filter untuk kapasitas item dan false positive rate tertentu
+func NewBloomFilter(capacity int, falsePositiveRate float64) *BloomFilter {
+	if capacity < 1 {
+		capacity = 1
+	}
+	n := float64(capacity)
+	m := math.Ceil(-n * math.Log(falsePositiveRate) / (math.Ln2 * math.Ln2))
+	k := math.Max(1, math.Round(m/n*math.Ln2))
+
+	size := uint64(m)
+	return &BloomFilter{
+		bits:   make([]uint64, (size+63)/64),
+		size:   size,
+		hashes: uint64(k),
+	}
+}
+
+func (b *BloomFilter) Add(key string) {
+	h1, h2 := bloomHashes(key)
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	for i := uint64(0); i < b.hashes; i++ {
+		pos := (h1 + i*h2) % b.size
+		b.bits[pos/64] |= 1 << (pos % 64)
+	}
+}
+
+func (b *BloomFilter) MightContain(key string) bool {
+	h1, h2 := bloomHashes(key)
+
+	b.mu.RLock()
+	defer b.mu.RUnlock()
+
+	for i := uint64(0); i < b.hashes; i++ {
+		pos := (h1 + i*h2) % b.size
+		if b.bits[pos/64]&(1<<(pos%64)) == 0 {
+			return false
+		}
+	}
+	return true
+}
+
+// bloomHashes memakai double hashing (Kirsch-Mitzenmacher) dari satu SHA-256
+func bloomHashes(key string) (uint64, uint64) {
+	sum := sha256.Sum256([]byte(key))
+	h1 := binary.BigEndian.Uint64(sum[0:8])
+	h2 := binary.BigEndian.Uint64(sum[8:16]) | 1
+	return h1, h2
+}
@@ -0,0 +1,61 @@
+package cache
+
+import (
+	"fmt"
+	"testing"
+)
+
+func TestBloomFilterNoFalseNegatives(t *testing.T) {
+	tests := []struct {
+		name     string
+		capacity int
+		items    int
+	}{
+		{"small", 10, 10},
+		{"at capacity", 1000, 1000},
+		{"over capacity", 100, 1000},
+		{"invalid capacity", 0, 5},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			filter := NewBloomFilter(tt.capacity, 0.01)
+			for i := 0; i < tt.items; i++ {
+				filter.Add(fmt.Sprintf("token-%d", i))
+			}
+			for i := 0; i < tt.items; i++ {
+				if !filter.MightContain(fmt.Sprintf("token-%d", i)) {
+					t.Fatalf("MightContain(token-%d) = false after Add", i)
+				}
+			}
+		})
+	}
+}
+
+func TestBloomFilterFalsePositiveRate(t *testing.T) {
+	const capacity = 10000
+	filter := NewBloomFilter(capacity, 0.01)
+	for i := 0; i < capacity; i++ {
+		filter.Add(fmt.Sprintf("revoked-%d", i))
+	}
+
+	falsePositives := 0
+	const probes = 10000
+	for i := 0; i < probes; i++ {
+		if filter.MightContain(fmt.Sprintf("active-%d", i)) {
+			falsePositives++
+		}
+	}
+
+	// Target 1%, toleransi 2x untuk variasi acak
+	if rate := float64(falsePositives) / probes; rate > 0.02 {
+		t.Errorf("false positive rate = %.4f, want <= 0.02", rate)
+	}
+}
+
+func TestBloomFilterEmpty(t *testing.T) {
+	filter := NewBloomFilter(100, 0.01)
+	if filter.MightContain("anything") {
+		t.Error("empty filter reports MightContain = true")
+	}
+}
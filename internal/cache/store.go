@@ -0,0 +1,80 @@
+package cache
+
+import (
+	"sync"
+	"time"
+)
+
+// Store adalah key-value cache dengan TTL
+// Interface sengaja dibuat kecil agar backend Redis-compatible bisa dipasang tanpa mengubah pemakai
+type Store interface {
+	Get(key string) (value string, found bool, err error)
+	Set(key, value string, ttl time.Duration) error
+	Delete(key string) error
+}
+
+// sweepEvery menentukan seberapa sering entry expired dibersihkan (dihitung per Set)
+const sweepEvery = 1024
+
+type memoryEntry struct {
+	value     string
+	expiresAt time.Time
+}
+
+// MemoryStore adalah implementasi Store in-process (tidak dibagi antar instance)
+type MemoryStore struct {
+	mu      sync.RWMutex
+	entries map[string]memoryEntry
+	sets    int
+}
+
+func NewMemoryStore() *MemoryStore {
+	return &MemoryStore{
+		entries: make(map[string]memoryEntry),
+	}
+}
+
+func (s *MemoryStore) Get(key string) (string, bool, error) {
+	s.mu.RLock()
+	entry, ok := s.entries[key]
+	s.mu.RUnlock()
+
+	if !ok || time.Now().After(entry.expiresAt) {
+		return "", false, nil
+	}
+	return entry.value, true, nil
+}
+
+func (s *MemoryStore) Set(key, value string, ttl time.Duration) error {
+	if ttl <= 0 {
+		return nil
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.entries[key] = memoryEntry{value: value, expiresAt: time.Now().Add(ttl)}
+
+	s.sets++
+	if s.sets%sweepEvery == 0 {
+		s.sweep()
+	}
+	return nil
+}
+
+func (s *MemoryStore) Delete(key string) error {
+	s.mu.Lock()
+	delete(s.entries, key)
+	s.mu.Unlock()
+	return nil
+}
+
+// sweep menghapus semua entry expired, harus dipanggil dengan lock
+func (s *MemoryStore) sweep() {
+	now := time.Now()
+	for key, entry := range s.entries {
+		if now.After(entry.expiresAt) {
+			delete(s.entries, key)
+		}
+	}
+}
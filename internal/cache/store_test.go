@@ -0,0 +1,79 @@
+package cache
+
+import (
+	"testing"
+	"time"
+)
+
+func TestMemoryStore(t *testing.T) {
+	tests := []struct {
+		name      string
+		ttl       time.Duration
+		wait      time.Duration
+		wantFound bool
+	}{
+		{"within ttl", time.Minute, 0, true},
+		{"expired", 10 * time.Millisecond, 30 * time.Millisecond, false},
+		{"zero ttl is not stored", 0, 0, false},
+		{"negative ttl is not stored", -time.Second, 0, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			store := NewMemoryStore()
+			if err := store.Set("key", "value", tt.ttl); err != nil {
+				t.Fatal(err)
+			}
+			time.Sleep(tt.wait)
+
+			value, found, err := store.Get("key")
+			if err != nil {
+				t.Fatal(err)
+			}
+			if found != tt.wantFound {
+				t.Fatalf("Get() found = %v, want %v", found, tt.wantFound)
+			}
+			if found && value != "value" {
+				t.Errorf("Get() value = %q, want %q", value, "value")
+			}
+		})
+	}
+}
+
+func TestMemoryStoreOverwriteAndDelete(t *testing.T) {
+	store := NewMemoryStore()
+	_ = store.Set("key", "old", time.Minute)
+	_ = store.Set("key", "new", time.Minute)
+
+	if value, _, _ := store.Get("key"); value != "new" {
+		t.Errorf("Get() after overwrite = %q, want %q", value, "new")
+	}
+
+	if err := store.Delete("key"); err != nil {
+		t.Fatal(err)
+	}
+	if _, found, _ := store.Get("key"); found {
+		t.Error("Get() found key after Delete()")
+	}
+	if err := store.Delete("missing"); err != nil {
+		t.Errorf("Delete() of missing key error = %v", err)
+	}
+}
+
+func TestMemoryStoreSweep(t *testing.T) {
+	store := NewMemoryStore()
+	_ = store.Set("expired", "1", time.Millisecond)
+	time.Sleep(5 * time.Millisecond)
+
+	// Sweep berjalan setiap sweepEvery kali Set
+	for i := 0; i < sweepEvery; i++ {
+		_ = store.Set("live", "1", time.Minute)
+	}
+
+	store.mu.RLock()
+	_, stillThere := store.entries["expired"]
+	store.mu.RUnlock()
+	if stillThere {
+		t.Error("expired entry was not swept")
+	}
+}
@@ -4,20 +4,113 @@ import (
 	"fmt"
 	"github.com/joho/godotenv"
 	"os"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Mode registrasi publik (REGISTRATION_MODE)
+const (
+	RegistrationOpen     = "open"     // Siapa saja bisa register
+	RegistrationInvite   = "invite"   // Hanya lewat undangan admin
+	RegistrationDomain   = "domain"   // Hanya email dengan domain di REGISTRATION_ALLOWED_DOMAINS
+	RegistrationDisabled = "disabled" // Register publik ditutup
 )
 
 // Struct untuk seluruh akses konfigurasi aplikasi
 type Config struct {
-	AppName    string
-	AppEnv     string
-	AppPort    string
-	DBHost     string
-	DBPort     string
-	DBUser     string
-	DBPassword string
-	DBName     string
-	JWTSecret  string
-	JWTExpire  string
+	AppName           string
+	AppEnv            string
+	AppPort           string
+	DBHost            string
+	DBPort            string
+	DBUser            string
+	DBPassword        string
+	DBName            string
+	JWTSecret         string
+	JWTExpire         time.Duration // Umur access token
+	JWTRefreshExpire  time.Duration // Umur refresh token
+	JWTIssuer         string        // Claim "iss" yang diterbitkan dan diwajibkan
+	JWTAudience       string        // Claim "aud" yang diterbitkan dan diwajibkan
+	JWTLeeway         time.Duration // Toleransi clock skew saat validasi exp/nbf/iat
+	JWTAlgorithm      string        // HS256 (default), RS256 atau EdDSA
+	JWTSigningKeyFile string        // Private key PEM untuk RS256/EdDSA
+	JWTVerifyKeyFiles string        // Public key PEM tambahan (dipisah koma) untuk rotasi key
+
+	TokenCleanupInterval  time.Duration // Interval janitor token expired
+	TokenCleanupBatchSize int           // Jumlah baris maksimal per DELETE
+
+	TokenCacheEnabled       bool          // Cache in-memory di depan lookup token blacklist
+	TokenCacheBloom         bool          // Bloom filter untuk lookup negatif (hanya untuk single instance)
+	TokenCacheBloomCapacity int           // Perkiraan jumlah token blacklist aktif
+	TokenCacheNegativeTTL   time.Duration // Lama token yang belum di-revoke di-cache (0 = nonaktif)
+
+	MailDriver   string // smtp, file, atau log (default)
+	MailFrom     string
+	MailFileDir  string // Folder output untuk driver file
+	SMTPHost     string
+	SMTPPort     string
+	SMTPUsername string
+	SMTPPassword string
+
+	PasswordResetTTL time.Duration // Umur link reset password
+	PasswordResetURL string        // URL halaman reset password (token ditambahkan sebagai query)
+
+	SMSDriver string // log (default)
+
+	VerificationCodeTTL     time.Duration // Umur kode verifikasi email / telepon
+	VerificationResendDelay time.Duration // Jeda minimal antar pengiriman kode
+	VerificationMaxPerHour  int           // Maksimal kode yang dikirim per jam per channel
+	VerificationMaxAttempts int           // Maksimal percobaan salah per kode
+	RequireVerifiedEmail    bool          // Login ditolak jika email belum diverifikasi
+
+	MFAChallengeTTL        time.Duration // Umur token "mfa pending" setelah login
+	MFAMaxAttempts         int           // Maksimal kode 2FA salah per token "mfa pending"
+	TwoFactorRecoveryCodes int           // Jumlah recovery code yang dibuat
+
+	LoginFailureWindow   time.Duration // Rentang waktu login gagal dihitung berturut-turut
+	LoginBackoffAfter    int           // Jumlah gagal sebelum backoff per username dimulai
+	LoginBackoffBase     time.Duration // Jeda awal backoff, dikali dua setiap gagal berikutnya
+	LoginMaxFailures     int           // Jumlah gagal per username sebelum akun dikunci
+	LoginIPMaxFailures   int           // Jumlah gagal per IP sebelum IP dikunci
+	LoginLockoutDuration time.Duration // Lama penguncian
+
+	RegistrationMode           string   // open (default), invite, domain, atau disabled
+	RegistrationAllowedDomains []string // Domain email yang diizinkan untuk mode domain
+
+	InvitationTTL time.Duration // Umur default link undangan
+	InvitationURL string        // URL halaman terima undangan (token ditambahkan sebagai query)
+
+	PhoneDefaultCountryCode string // Kode negara untuk nomor format nasional (diawali 0)
+
+	OIDCProviders []OIDCProvider // Identity provider dari OIDC_PROVIDERS (contoh: google,corp)
+	OIDCStateTTL  time.Duration  // Umur state, nonce, dan PKCE verifier selama login OIDC
+
+	APIKeyDefaultTTL time.Duration // Umur API key jika expires_in_days tidak diisi
+	APIKeyMaxTTL     time.Duration // Umur maksimal API key
+	APIKeyMaxPerUser int           // Jumlah maksimal API key aktif per user
+
+	OAuthBaseURL         string        // URL publik service ini, dipakai untuk metadata /.well-known/oauth-authorization-server
+	OAuthConsentURL      string        // URL halaman persetujuan (query /oauth/authorize diteruskan apa adanya)
+	OAuthCodeTTL         time.Duration // Umur authorization code
+	OAuthAccessTokenTTL  time.Duration // Umur access token untuk client OAuth2
+	OAuthRefreshTokenTTL time.Duration // Umur refresh token untuk client OAuth2
+
+	RoleCacheTTL time.Duration // Umur cache role -> permission (perubahan dari instance lain berlaku setelah ini)
+
+	UserUniquenessScope string // global (default): username / email / phone unik di semua organisasi, organization: unik per organisasi
+}
+
+// OIDCProvider adalah konfigurasi satu identity provider OpenID Connect
+// Dibaca dari env OIDC_<NAME>_*, contoh OIDC_GOOGLE_ISSUER untuk provider "google"
+type OIDCProvider struct {
+	Name         string
+	Issuer       string // URL issuer, discovery dibaca dari <issuer>/.well-known/openid-configuration
+	ClientID     string
+	ClientSecret string   // Kosong untuk public client (hanya PKCE)
+	RedirectURL  string   // URL callback API ini: <base-url>/auth/oidc/<name>/callback
+	Scopes       []string // Default: openid email profile
+	LinkByEmail  bool     // Hubungkan ke akun lokal dengan email yang sama jika IdP menyatakan email terverifikasi
 }
 
 // LoadConfig membaci env variables dan mengembalikan ke Config struct
@@ -32,21 +125,268 @@ func LoadConfig() (*Config, error) {
 	// Membuat instance Config
 	// os.Getenv() untuk membaca nilai environment variable
 	config := &Config{
-		AppName:    os.Getenv("APP_NAME"),
-		AppEnv:     os.Getenv("APP_ENV"),
-		AppPort:    os.Getenv("APP_PORT"),
-		DBHost:     os.Getenv("DB_HOST"),
-		DBPort:     os.Getenv("DB_PORT"),
-		DBUser:     os.Getenv("DB_USER"),
-		DBPassword: os.Getenv("DB_PASSWORD"),
-		DBName:     os.Getenv("DB_NAME"),
-		JWTSecret:  os.Getenv("JWT_SECRET"),
-		JWTExpire:  os.Getenv("JWT_EXPIRE"),
+		AppName:                 os.Getenv("APP_NAME"),
+		AppEnv:                  os.Getenv("APP_ENV"),
+		AppPort:                 os.Getenv("APP_PORT"),
+		DBHost:                  os.Getenv("DB_HOST"),
+		DBPort:                  os.Getenv("DB_PORT"),
+		DBUser:                  os.Getenv("DB_USER"),
+		DBPassword:              os.Getenv("DB_PASSWORD"),
+		DBName:                  os.Getenv("DB_NAME"),
+		JWTSecret:               os.Getenv("JWT_SECRET"),
+		JWTIssuer:               os.Getenv("JWT_ISSUER"),
+		JWTAudience:             os.Getenv("JWT_AUDIENCE"),
+		JWTAlgorithm:            os.Getenv("JWT_ALGORITHM"),
+		JWTSigningKeyFile:       os.Getenv("JWT_SIGNING_KEY_FILE"),
+		JWTVerifyKeyFiles:       os.Getenv("JWT_VERIFY_KEY_FILES"),
+		MailDriver:              os.Getenv("MAIL_DRIVER"),
+		MailFrom:                getEnv("MAIL_FROM", "noreply@localhost"),
+		MailFileDir:             getEnv("MAIL_FILE_DIR", "storage/mail"),
+		SMTPHost:                os.Getenv("SMTP_HOST"),
+		SMTPPort:                getEnv("SMTP_PORT", "587"),
+		SMTPUsername:            os.Getenv("SMTP_USERNAME"),
+		SMTPPassword:            os.Getenv("SMTP_PASSWORD"),
+		PasswordResetURL:        getEnv("PASSWORD_RESET_URL", "http://localhost:3000/reset-password"),
+		SMSDriver:               os.Getenv("SMS_DRIVER"),
+		RegistrationMode:        strings.ToLower(getEnv("REGISTRATION_MODE", RegistrationOpen)),
+		InvitationURL:           getEnv("INVITATION_URL", "http://localhost:3000/accept-invite"),
+		PhoneDefaultCountryCode: getEnv("PHONE_DEFAULT_COUNTRY_CODE", "62"),
+		OAuthConsentURL:         getEnv("OAUTH_CONSENT_URL", "http://localhost:3000/oauth/consent"),
+	}
+	config.OAuthBaseURL = strings.TrimSuffix(getEnv("OAUTH_BASE_URL", "http://localhost:"+getEnv("APP_PORT", "3000")), "/")
+
+	// Parse durasi token, default dipakai jika env tidak di-set
+	var err error
+	if config.JWTExpire, err = getEnvDuration("JWT_EXPIRE", 15*time.Minute); err != nil {
+		return nil, err
+	}
+	if config.JWTRefreshExpire, err = getEnvDuration("JWT_REFRESH_EXPIRE", 7*24*time.Hour); err != nil {
+		return nil, err
+	}
+	if config.JWTLeeway, err = getEnvNonNegativeDuration("JWT_LEEWAY", 30*time.Second); err != nil {
+		return nil, err
+	}
+	if config.TokenCleanupInterval, err = getEnvDuration("TOKEN_CLEANUP_INTERVAL", time.Hour); err != nil {
+		return nil, err
+	}
+	if config.TokenCleanupBatchSize, err = getEnvInt("TOKEN_CLEANUP_BATCH_SIZE", 1000); err != nil {
+		return nil, err
+	}
+	if config.PasswordResetTTL, err = getEnvDuration("PASSWORD_RESET_TTL", 30*time.Minute); err != nil {
+		return nil, err
+	}
+	if config.TokenCacheEnabled, err = getEnvBool("TOKEN_CACHE_ENABLED", true); err != nil {
+		return nil, err
+	}
+	if config.TokenCacheBloom, err = getEnvBool("TOKEN_CACHE_BLOOM", false); err != nil {
+		return nil, err
+	}
+	if config.TokenCacheBloomCapacity, err = getEnvInt("TOKEN_CACHE_BLOOM_CAPACITY", 100000); err != nil {
+		return nil, err
+	}
+	if config.TokenCacheNegativeTTL, err = getEnvNonNegativeDuration("TOKEN_CACHE_NEGATIVE_TTL", 5*time.Second); err != nil {
+		return nil, err
+	}
+	if config.VerificationCodeTTL, err = getEnvDuration("VERIFICATION_CODE_TTL", 15*time.Minute); err != nil {
+		return nil, err
+	}
+	if config.VerificationResendDelay, err = getEnvDuration("VERIFICATION_RESEND_DELAY", time.Minute); err != nil {
+		return nil, err
+	}
+	if config.VerificationMaxPerHour, err = getEnvInt("VERIFICATION_MAX_PER_HOUR", 5); err != nil {
+		return nil, err
+	}
+	if config.VerificationMaxAttempts, err = getEnvInt("VERIFICATION_MAX_ATTEMPTS", 5); err != nil {
+		return nil, err
+	}
+	if config.RequireVerifiedEmail, err = getEnvBool("REQUIRE_VERIFIED_EMAIL", false); err != nil {
+		return nil, err
+	}
+	if config.MFAChallengeTTL, err = getEnvDuration("MFA_CHALLENGE_TTL", 5*time.Minute); err != nil {
+		return nil, err
+	}
+	if config.MFAMaxAttempts, err = getEnvInt("MFA_MAX_ATTEMPTS", 5); err != nil {
+		return nil, err
+	}
+	if config.TwoFactorRecoveryCodes, err = getEnvInt("TWO_FACTOR_RECOVERY_CODES", 10); err != nil {
+		return nil, err
+	}
+	if config.LoginFailureWindow, err = getEnvDuration("LOGIN_FAILURE_WINDOW", 15*time.Minute); err != nil {
+		return nil, err
+	}
+	if config.LoginBackoffAfter, err = getEnvInt("LOGIN_BACKOFF_AFTER", 3); err != nil {
+		return nil, err
+	}
+	if config.LoginBackoffBase, err = getEnvDuration("LOGIN_BACKOFF_BASE", time.Second); err != nil {
+		return nil, err
+	}
+	if config.LoginMaxFailures, err = getEnvInt("LOGIN_MAX_FAILURES", 10); err != nil {
+		return nil, err
+	}
+	if config.LoginIPMaxFailures, err = getEnvInt("LOGIN_IP_MAX_FAILURES", 50); err != nil {
+		return nil, err
+	}
+	if config.LoginLockoutDuration, err = getEnvDuration("LOGIN_LOCKOUT_DURATION", 15*time.Minute); err != nil {
+		return nil, err
+	}
+	if config.InvitationTTL, err = getEnvDuration("INVITATION_TTL", 72*time.Hour); err != nil {
+		return nil, err
+	}
+	if config.OIDCStateTTL, err = getEnvDuration("OIDC_STATE_TTL", 10*time.Minute); err != nil {
+		return nil, err
+	}
+	if config.OIDCProviders, err = loadOIDCProviders(); err != nil {
+		return nil, err
+	}
+	if config.APIKeyDefaultTTL, err = getEnvDuration("API_KEY_DEFAULT_TTL", 90*24*time.Hour); err != nil {
+		return nil, err
+	}
+	if config.APIKeyMaxTTL, err = getEnvDuration("API_KEY_MAX_TTL", 365*24*time.Hour); err != nil {
+		return nil, err
+	}
+	if config.APIKeyMaxPerUser, err = getEnvInt("API_KEY_MAX_PER_USER", 20); err != nil {
+		return nil, err
+	}
+	if config.APIKeyDefaultTTL > config.APIKeyMaxTTL {
+		return nil, fmt.Errorf("API_KEY_DEFAULT_TTL must not exceed API_KEY_MAX_TTL")
+	}
+	if config.OAuthCodeTTL, err = getEnvDuration("OAUTH_CODE_TTL", time.Minute); err != nil {
+		return nil, err
+	}
+	if config.OAuthAccessTokenTTL, err = getEnvDuration("OAUTH_ACCESS_TOKEN_TTL", time.Hour); err != nil {
+		return nil, err
+	}
+	if config.OAuthRefreshTokenTTL, err = getEnvDuration("OAUTH_REFRESH_TOKEN_TTL", 30*24*time.Hour); err != nil {
+		return nil, err
+	}
+	if config.RoleCacheTTL, err = getEnvDuration("ROLE_CACHE_TTL", 30*time.Second); err != nil {
+		return nil, err
+	}
+
+	switch config.RegistrationMode {
+	case RegistrationOpen, RegistrationInvite, RegistrationDomain, RegistrationDisabled:
+	default:
+		return nil, fmt.Errorf("invalid REGISTRATION_MODE: %q", config.RegistrationMode)
+	}
+	for _, domain := range strings.Split(os.Getenv("REGISTRATION_ALLOWED_DOMAINS"), ",") {
+		if domain = strings.ToLower(strings.TrimSpace(domain)); domain != "" {
+			config.RegistrationAllowedDomains = append(config.RegistrationAllowedDomains, domain)
+		}
+	}
+	if config.RegistrationMode == RegistrationDomain && len(config.RegistrationAllowedDomains) == 0 {
+		return nil, fmt.Errorf("REGISTRATION_ALLOWED_DOMAINS is required when REGISTRATION_MODE=domain")
+	}
+
+	config.UserUniquenessScope = strings.ToLower(getEnv("USER_UNIQUENESS_SCOPE", "global"))
+	if config.UserUniquenessScope != "global" && config.UserUniquenessScope != "organization" {
+		return nil, fmt.Errorf("invalid USER_UNIQUENESS_SCOPE: %q", config.UserUniquenessScope)
 	}
 
 	return config, nil
 }
 
+// loadOIDCProviders membaca daftar provider dari OIDC_PROVIDERS beserta env OIDC_<NAME>_*
+func loadOIDCProviders() ([]OIDCProvider, error) {
+	var providers []OIDCProvider
+	seen := map[string]bool{}
+
+	for _, name := range strings.Split(os.Getenv("OIDC_PROVIDERS"), ",") {
+		name = strings.ToLower(strings.TrimSpace(name))
+		if name == "" || seen[name] {
+			continue
+		}
+		seen[name] = true
+
+		prefix := "OIDC_" + strings.ToUpper(strings.ReplaceAll(name, "-", "_")) + "_"
+		provider := OIDCProvider{
+			Name:         name,
+			Issuer:       os.Getenv(prefix + "ISSUER"),
+			ClientID:     os.Getenv(prefix + "CLIENT_ID"),
+			ClientSecret: os.Getenv(prefix + "CLIENT_SECRET"),
+			RedirectURL:  os.Getenv(prefix + "REDIRECT_URL"),
+			Scopes:       strings.Fields(getEnv(prefix+"SCOPES", "openid email profile")),
+		}
+		if provider.Issuer == "" || provider.ClientID == "" || provider.RedirectURL == "" {
+			return nil, fmt.Errorf("%sISSUER, %sCLIENT_ID and %sREDIRECT_URL are required", prefix, prefix, prefix)
+		}
+
+		var err error
+		if provider.LinkByEmail, err = getEnvBool(prefix+"LINK_BY_EMAIL", false); err != nil {
+			return nil, err
+		}
+		providers = append(providers, provider)
+	}
+	return providers, nil
+}
+
+// getEnv membaca env variable string, fallback dipakai jika kosong
+func getEnv(key, fallback string) string {
+	if value := os.Getenv(key); value != "" {
+		return value
+	}
+	return fallback
+}
+
+// getEnvDuration membaca env variable berformat durasi Go (contoh: 15m, 24h) yang harus positif
+// Jika env kosong, nilai fallback yang dikembalikan
+func getEnvDuration(key string, fallback time.Duration) (time.Duration, error) {
+	duration, err := getEnvNonNegativeDuration(key, fallback)
+	if err != nil {
+		return 0, err
+	}
+	if duration <= 0 {
+		return 0, fmt.Errorf("invalid duration for %s: must be greater than 0", key)
+	}
+	return duration, nil
+}
+
+// getEnvNonNegativeDuration sama dengan getEnvDuration tetapi menerima 0 (misalnya JWT_LEEWAY=0s berarti tanpa toleransi)
+func getEnvNonNegativeDuration(key string, fallback time.Duration) (time.Duration, error) {
+	value := os.Getenv(key)
+	if value == "" {
+		return fallback, nil
+	}
+
+	duration, err := time.ParseDuration(value)
+	if err != nil {
+		return 0, fmt.Errorf("invalid duration for %s: %w", key, err)
+	}
+	if duration < 0 {
+		return 0, fmt.Errorf("invalid duration for %s: must not be negative", key)
+	}
+	return duration, nil
+}
+
+// getEnvInt membaca env variable berupa angka positif
+// Jika env kosong, nilai fallback yang dikembalikan
+func getEnvInt(key string, fallback int) (int, error) {
+	value := os.Getenv(key)
+	if value == "" {
+		return fallback, nil
+	}
+
+	number, err := strconv.Atoi(value)
+	if err != nil || number < 1 {
+		return 0, fmt.Errorf("invalid number for %s: %q", key, value)
+	}
+	return number, nil
+}
+
+// getEnvBool membaca env variable boolean (true/false/1/0)
+// Jika env kosong, nilai fallback yang dikembalikan
+func getEnvBool(key string, fallback bool) (bool, error) {
+	value := os.Getenv(key)
+	if value == "" {
+		return fallback, nil
+	}
+
+	b, err := strconv.ParseBool(value)
+	if err != nil {
+		return false, fmt.Errorf("invalid boolean for %s: %q", key, value)
+	}
+	return b, nil
+}
+
 // GetDSN menghasilkan Data Source Name untuk koneksi MySQL
 // DSN adalah string koneksi yang berisi info host, port, user, password, dan database
 func (c *Config) GetDSN() string {
@@ -0,0 +1,75 @@
+package handlers
+
+import (
+	"strconv"
+	"strings"
+
+	"github.com/LutfiyaAinurrahmanP/boilerplate_fiber_restful_api/internal/middlewares"
+	"github.com/LutfiyaAinurrahmanP/boilerplate_fiber_restful_api/internal/services"
+	"github.com/LutfiyaAinurrahmanP/boilerplate_fiber_restful_api/internal/utils"
+	"github.com/LutfiyaAinurrahmanP/boilerplate_fiber_restful_api/internal/validators"
+	"github.com/gofiber/fiber/v2"
+)
+
+type APIKeyHandler struct {
+	apiKeyService services.APIKeyService
+}
+
+func NewAPIKeyHandler(apiKeyService services.APIKeyService) *APIKeyHandler {
+	return &APIKeyHandler{
+		apiKeyService: apiKeyService,
+	}
+}
+
+// CreateAPIKey membuat API key baru, secret hanya ditampilkan sekali di response ini
+func (h *APIKeyHandler) CreateAPIKey(c *fiber.Ctx) error {
+	var req validators.CreateAPIKeyRequest
+	if err := validators.ParseAndValidate(c, &req); err != nil {
+		if validationErrors := validators.FormatValidationError(err); len(validationErrors) > 0 {
+			return utils.BadRequestResponse(c, "Validation failed", validationErrors)
+		}
+		return utils.BadRequestResponse(c, err.Error(), nil)
+	}
+
+	created, err := h.apiKeyService.CreateAPIKey(middlewares.GetUserIDFromContext(c), middlewares.IsMFAVerified(c), &req)
+	if err != nil {
+		return h.handleError(c, err, "Failed to create API key")
+	}
+
+	return utils.CreatedResponse(c, "API key created. Store the secret now, it will not be shown again.", created)
+}
+
+func (h *APIKeyHandler) GetAPIKeys(c *fiber.Ctx) error {
+	keys, err := h.apiKeyService.ListAPIKeys(middlewares.GetUserIDFromContext(c))
+	if err != nil {
+		return utils.InternalServerErrorResponse(c, "Failed to fetch API keys")
+	}
+	return utils.SuccessResponse(c, "API keys retrieved successfully", keys)
+}
+
+func (h *APIKeyHandler) RevokeAPIKey(c *fiber.Ctx) error {
+	id, err := strconv.ParseUint(c.Params("id"), 10, 32)
+	if err != nil {
+		return utils.BadRequestResponse(c, "Invalid API key ID", nil)
+	}
+
+	if err := h.apiKeyService.RevokeAPIKey(middlewares.GetUserIDFromContext(c), uint(id)); err != nil {
+		return h.handleError(c, err, "Failed to revoke API key")
+	}
+	return utils.SuccessResponse(c, "API key revoked successfully", nil)
+}
+
+func (h *APIKeyHandler) handleError(c *fiber.Ctx, err error, fallback string) error {
+	errorMessage := err.Error()
+	if errorMessage == "api key not found" || errorMessage == "user not found" {
+		return utils.NotFoundResponse(c, errorMessage)
+	}
+	if errorMessage == "admin scope requires admin access" ||
+		strings.HasPrefix(errorMessage, "expires_in_days must not exceed") {
+		return utils.BadRequestResponse(c, errorMessage, nil)
+	}
+	if strings.HasPrefix(errorMessage, "api key limit reached") {
+		return utils.ConflictResponse(c, errorMessage)
+	}
+	return utils.InternalServerErrorResponse(c, fallback)
+}
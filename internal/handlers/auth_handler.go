@@ -39,6 +39,11 @@ func (h *AuthHandler) Register(c *fiber.Ctx) error {
 			errorMessage == "phone already exists" {
 			return utils.ConflictResponse(c, errorMessage)
 		}
+		if errorMessage == "registration is disabled" ||
+			errorMessage == "registration is by invitation only" ||
+			errorMessage == "email domain is not allowed to register" {
+			return utils.ForbiddenResponse(c, errorMessage)
+		}
 		return utils.InternalServerErrorResponse(c, "Failed to register user")
 	}
 	return utils.CreatedResponse(c, "User registered successfully", fiber.Map{
@@ -56,23 +61,110 @@ func (h *AuthHandler) Login(c *fiber.Ctx) error {
 		return utils.BadRequestResponse(c, err.Error(), nil)
 	}
 
-	token, user, err := h.authService.Login(&req)
+	result, err := h.authService.Login(&req, services.ClientInfo{
+		Device:    req.Device,
+		IPAddress: c.IP(),
+		UserAgent: truncate(c.Get(fiber.HeaderUserAgent), 255),
+	})
 	if err != nil {
 		errorMessage := err.Error()
 		if errorMessage == "invalid username or password" {
 			return utils.UnauthorizedResponse(c, errorMessage)
 		}
+		if errorMessage == "email not verified" ||
+			errorMessage == "not a member of this organization" {
+			return utils.ForbiddenResponse(c, errorMessage)
+		}
+		if errorMessage == "organization not found" {
+			return utils.NotFoundResponse(c, errorMessage)
+		}
+		if errorMessage == "too many failed login attempts, try again later" {
+			return utils.TooManyRequestsResponse(c, errorMessage)
+		}
 		if errors.Is(err, gorm.ErrRecordNotFound) {
 			return utils.UnauthorizedResponse(c, "Invalid username or password")
 		}
 		return utils.InternalServerErrorResponse(c, "Failed to login")
 	}
+
+	// 2FA aktif: client harus menukar mfa_token di POST /auth/2fa/verify
+	if result.MFAToken != "" {
+		return utils.SuccessResponse(c, "Two-factor authentication required", fiber.Map{
+			"mfa_required": true,
+			"mfa_token":    result.MFAToken,
+			"expires_in":   result.MFAExpiresIn,
+		})
+	}
+
+	return utils.SuccessResponse(c, "Login succesful", fiber.Map{
+		"token":         result.Tokens.AccessToken,
+		"refresh_token": result.Tokens.RefreshToken,
+		"token_type":    result.Tokens.TokenType,
+		"expires_in":    result.Tokens.ExpiresIn,
+		"user":          result.User,
+	})
+}
+
+// VerifyTwoFactor menyelesaikan login untuk user dengan 2FA aktif
+func (h *AuthHandler) VerifyTwoFactor(c *fiber.Ctx) error {
+	var req validators.VerifyTwoFactorRequest
+	if err := validators.ParseAndValidate(c, &req); err != nil {
+		if validationErrors := validators.FormatValidationError(err); len(validationErrors) > 0 {
+			return utils.BadRequestResponse(c, "Validation failed", validationErrors)
+		}
+		return utils.BadRequestResponse(c, err.Error(), nil)
+	}
+
+	tokens, user, err := h.authService.VerifyTwoFactor(&req)
+	if err != nil {
+		errorMessage := err.Error()
+		if errorMessage == "invalid or expired mfa token" ||
+			errorMessage == "invalid two-factor code" {
+			return utils.UnauthorizedResponse(c, errorMessage)
+		}
+		if errorMessage == "not a member of this organization" {
+			return utils.ForbiddenResponse(c, errorMessage)
+		}
+		if errorMessage == "too many failed login attempts, try again later" {
+			return utils.TooManyRequestsResponse(c, errorMessage)
+		}
+		return utils.InternalServerErrorResponse(c, "Failed to verify two-factor code")
+	}
+
 	return utils.SuccessResponse(c, "Login succesful", fiber.Map{
-		"token": token,
-		"user":  user,
+		"token":         tokens.AccessToken,
+		"refresh_token": tokens.RefreshToken,
+		"token_type":    tokens.TokenType,
+		"expires_in":    tokens.ExpiresIn,
+		"user":          user,
 	})
 }
 
+func (h *AuthHandler) Refresh(c *fiber.Ctx) error {
+	var req validators.RefreshTokenRequest
+
+	if err := validators.ParseAndValidate(c, &req); err != nil {
+		if validationErrors := validators.FormatValidationError(err); len(validationErrors) > 0 {
+			return utils.BadRequestResponse(c, "Validation failed", validationErrors)
+		}
+		return utils.BadRequestResponse(c, err.Error(), nil)
+	}
+
+	tokens, err := h.authService.Refresh(req.RefreshToken)
+	if err != nil {
+		errorMessage := err.Error()
+		if errorMessage == "invalid refresh token" ||
+			errorMessage == "refresh token expired" ||
+			errorMessage == "refresh token reuse detected" ||
+			errorMessage == "not a member of this organization" {
+			return utils.UnauthorizedResponse(c, errorMessage)
+		}
+		return utils.InternalServerErrorResponse(c, "Failed to refresh token")
+	}
+
+	return utils.SuccessResponse(c, "Token refreshed successfully", tokens)
+}
+
 func (h *AuthHandler) Logout(c *fiber.Ctx) error {
 	// 1. Get token dari Authorization header
 	authHeader := c.Get("Authorization")
@@ -109,3 +201,11 @@ func (h *AuthHandler) Logout(c *fiber.Ctx) error {
 	// 5. Response sukses
 	return utils.SuccessResponse(c, "Logout successful. Token has been revoked.", nil)
 }
+
+// truncate memotong string agar muat di kolom database
+func truncate(value string, max int) string {
+	if len(value) > max {
+		return value[:max]
+	}
+	return value
+}
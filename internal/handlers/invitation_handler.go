@@ -0,0 +1,134 @@
+package handlers
+
+import (
+	"strconv"
+	"strings"
+
+	"github.com/LutfiyaAinurrahmanP/boilerplate_fiber_restful_api/internal/middlewares"
+	"github.com/LutfiyaAinurrahmanP/boilerplate_fiber_restful_api/internal/services"
+	"github.com/LutfiyaAinurrahmanP/boilerplate_fiber_restful_api/internal/utils"
+	"github.com/LutfiyaAinurrahmanP/boilerplate_fiber_restful_api/internal/validators"
+	"github.com/gofiber/fiber/v2"
+)
+
+type InvitationHandler struct {
+	invitationService services.InvitationService
+}
+
+func NewInvitationHandler(invitationService services.InvitationService) *InvitationHandler {
+	return &InvitationHandler{
+		invitationService: invitationService,
+	}
+}
+
+func (h *InvitationHandler) CreateInvitation(c *fiber.Ctx) error {
+	var req validators.CreateInvitationRequest
+	if err := validators.ParseAndValidate(c, &req); err != nil {
+		if validationErrors := validators.FormatValidationError(err); len(validationErrors) > 0 {
+			return utils.BadRequestResponse(c, "Validation failed", validationErrors)
+		}
+		return utils.BadRequestResponse(c, err.Error(), nil)
+	}
+
+	invitation, err := h.invitationService.CreateInvitation(middlewares.GetUserIDFromContext(c), middlewares.GetOrganizationIDFromContext(c), middlewares.GetRoleFromContext(c), &req)
+	if err != nil {
+		errorMessage := err.Error()
+		if errorMessage == "invalid role" {
+			return utils.BadRequestResponse(c, errorMessage, nil)
+		}
+		if errorMessage == "cannot assign a role above your own role" {
+			return utils.ForbiddenResponse(c, errorMessage)
+		}
+		if errorMessage == "email already exists" ||
+			errorMessage == "pending invitation already exists for this email" {
+			return utils.ConflictResponse(c, errorMessage)
+		}
+		return utils.InternalServerErrorResponse(c, "Failed to create invitation")
+	}
+
+	return utils.CreatedResponse(c, "Invitation sent successfully", invitation)
+}
+
+func (h *InvitationHandler) GetInvitations(c *fiber.Ctx) error {
+	var query validators.ListInvitationQuery
+	if err := c.QueryParser(&query); err != nil {
+		return utils.BadRequestResponse(c, "Invalid query parameters", nil)
+	}
+	if err := validators.ValidateStruct(&query); err != nil {
+		return utils.BadRequestResponse(c, "Validation failed", validators.FormatValidationError(err))
+	}
+
+	invitations, err := h.invitationService.ListInvitations(middlewares.GetOrganizationIDFromContext(c), &query)
+	if err != nil {
+		return utils.InternalServerErrorResponse(c, "Failed to fetch invitations")
+	}
+
+	return utils.SuccessResponse(c, "Invitations retrieved successfully", invitations)
+}
+
+func (h *InvitationHandler) ResendInvitation(c *fiber.Ctx) error {
+	id, err := strconv.ParseUint(c.Params("id"), 10, 32)
+	if err != nil {
+		return utils.BadRequestResponse(c, "Invalid invitation ID", nil)
+	}
+
+	invitation, err := h.invitationService.ResendInvitation(middlewares.GetOrganizationIDFromContext(c), uint(id))
+	if err != nil {
+		return h.handleError(c, err, "Failed to resend invitation")
+	}
+
+	return utils.SuccessResponse(c, "Invitation resent successfully", invitation)
+}
+
+func (h *InvitationHandler) RevokeInvitation(c *fiber.Ctx) error {
+	id, err := strconv.ParseUint(c.Params("id"), 10, 32)
+	if err != nil {
+		return utils.BadRequestResponse(c, "Invalid invitation ID", nil)
+	}
+
+	if err := h.invitationService.RevokeInvitation(middlewares.GetOrganizationIDFromContext(c), uint(id)); err != nil {
+		return h.handleError(c, err, "Failed to revoke invitation")
+	}
+
+	return utils.SuccessResponse(c, "Invitation revoked successfully", nil)
+}
+
+// AcceptInvitation membuat akun dari link undangan (public)
+func (h *InvitationHandler) AcceptInvitation(c *fiber.Ctx) error {
+	var req validators.AcceptInvitationRequest
+	if err := validators.ParseAndValidate(c, &req); err != nil {
+		if validationErrors := validators.FormatValidationError(err); len(validationErrors) > 0 {
+			return utils.BadRequestResponse(c, "Validation failed", validationErrors)
+		}
+		return utils.BadRequestResponse(c, err.Error(), nil)
+	}
+
+	user, err := h.invitationService.AcceptInvitation(&req)
+	if err != nil {
+		errorMessage := err.Error()
+		if errorMessage == "invalid or expired invitation" {
+			return utils.BadRequestResponse(c, errorMessage, nil)
+		}
+		if errorMessage == "username already exists" ||
+			errorMessage == "email already exists" ||
+			errorMessage == "phone already exists" {
+			return utils.ConflictResponse(c, errorMessage)
+		}
+		return utils.InternalServerErrorResponse(c, "Failed to accept invitation")
+	}
+
+	return utils.CreatedResponse(c, "User registered successfully", fiber.Map{
+		"user": user,
+	})
+}
+
+func (h *InvitationHandler) handleError(c *fiber.Ctx, err error, fallback string) error {
+	errorMessage := err.Error()
+	if errorMessage == "invitation not found" {
+		return utils.NotFoundResponse(c, errorMessage)
+	}
+	if strings.HasPrefix(errorMessage, "invitation already ") {
+		return utils.ConflictResponse(c, errorMessage)
+	}
+	return utils.InternalServerErrorResponse(c, fallback)
+}
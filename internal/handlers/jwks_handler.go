@@ -0,0 +1,23 @@
+package handlers
+
+import (
+	"github.com/LutfiyaAinurrahmanP/boilerplate_fiber_restful_api/internal/jwtauth"
+	"github.com/gofiber/fiber/v2"
+)
+
+type JWKSHandler struct {
+	keySet *jwtauth.KeySet
+}
+
+func NewJWKSHandler(keySet *jwtauth.KeySet) *JWKSHandler {
+	return &JWKSHandler{
+		keySet: keySet,
+	}
+}
+
+// GetJWKS mengembalikan public key untuk verifikasi token secara offline oleh service lain
+// Response mengikuti format RFC 7517 (bukan format Response standar aplikasi)
+func (h *JWKSHandler) GetJWKS(c *fiber.Ctx) error {
+	c.Set(fiber.HeaderCacheControl, "public, max-age=300")
+	return c.JSON(h.keySet.JWKS())
+}
@@ -0,0 +1,79 @@
+package handlers
+
+import (
+	"strconv"
+
+	"github.com/LutfiyaAinurrahmanP/boilerplate_fiber_restful_api/internal/middlewares"
+	"github.com/LutfiyaAinurrahmanP/boilerplate_fiber_restful_api/internal/services"
+	"github.com/LutfiyaAinurrahmanP/boilerplate_fiber_restful_api/internal/utils"
+	"github.com/gofiber/fiber/v2"
+)
+
+type LockoutHandler struct {
+	loginGuard  services.LoginGuard
+	userService services.UserService
+}
+
+func NewLockoutHandler(loginGuard services.LoginGuard, userService services.UserService) *LockoutHandler {
+	return &LockoutHandler{
+		loginGuard:  loginGuard,
+		userService: userService,
+	}
+}
+
+// UnlockUser dipakai admin untuk membuka kunci akun setelah terlalu banyak login gagal
+func (h *LockoutHandler) UnlockUser(c *fiber.Ctx) error {
+	id, err := strconv.ParseUint(c.Params("id"), 10, 32)
+	if err != nil {
+		return utils.BadRequestResponse(c, "Invalid user ID", nil)
+	}
+
+	if err := h.ensureMember(c, uint(id)); err != nil {
+		if err.Error() == "user not found" {
+			return utils.NotFoundResponse(c, err.Error())
+		}
+		return utils.InternalServerErrorResponse(c, "Failed to fetch user")
+	}
+
+	if err := h.loginGuard.Unlock(middlewares.GetUserIDFromContext(c), uint(id)); err != nil {
+		errorMessage := err.Error()
+		if errorMessage == "user not found" {
+			return utils.NotFoundResponse(c, errorMessage)
+		}
+		if errorMessage == "account is not locked" {
+			return utils.ConflictResponse(c, errorMessage)
+		}
+		return utils.InternalServerErrorResponse(c, "Failed to unlock account")
+	}
+
+	return utils.SuccessResponse(c, "Account unlocked successfully", nil)
+}
+
+// GetLockoutEvents menampilkan riwayat lockout / unlock sebuah akun
+func (h *LockoutHandler) GetLockoutEvents(c *fiber.Ctx) error {
+	id, err := strconv.ParseUint(c.Params("id"), 10, 32)
+	if err != nil {
+		return utils.BadRequestResponse(c, "Invalid user ID", nil)
+	}
+
+	if err := h.ensureMember(c, uint(id)); err != nil {
+		if err.Error() == "user not found" {
+			return utils.NotFoundResponse(c, err.Error())
+		}
+		return utils.InternalServerErrorResponse(c, "Failed to fetch user")
+	}
+
+	events, err := h.loginGuard.ListLockoutEvents(uint(id))
+	if err != nil {
+		return utils.InternalServerErrorResponse(c, "Failed to fetch lockout events")
+	}
+
+	return utils.SuccessResponse(c, "Lockout events retrieved successfully", events)
+}
+
+// ensureMember memastikan user target adalah anggota organisasi pemanggil ("user not found" jika bukan)
+func (h *LockoutHandler) ensureMember(c *fiber.Ctx, userID uint) error {
+	users := h.userService.ForOrganization(middlewares.GetOrganizationIDFromContext(c))
+	_, err := users.GetUserByID(userID)
+	return err
+}
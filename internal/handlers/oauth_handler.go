@@ -0,0 +1,287 @@
+package handlers
+
+import (
+	"encoding/base64"
+	"errors"
+	"net/url"
+	"strconv"
+	"strings"
+
+	"github.com/LutfiyaAinurrahmanP/boilerplate_fiber_restful_api/internal/config"
+	"github.com/LutfiyaAinurrahmanP/boilerplate_fiber_restful_api/internal/middlewares"
+	"github.com/LutfiyaAinurrahmanP/boilerplate_fiber_restful_api/internal/services"
+	"github.com/LutfiyaAinurrahmanP/boilerplate_fiber_restful_api/internal/utils"
+	"github.com/LutfiyaAinurrahmanP/boilerplate_fiber_restful_api/internal/validators"
+	"github.com/gofiber/fiber/v2"
+)
+
+type OAuthHandler struct {
+	oauthService services.OAuthService
+	cfg          *config.Config
+}
+
+func NewOAuthHandler(oauthService services.OAuthService, cfg *config.Config) *OAuthHandler {
+	return &OAuthHandler{
+		oauthService: oauthService,
+		cfg:          cfg,
+	}
+}
+
+// ============================================
+// PROTOCOL ENDPOINTS (format response mengikuti RFC, bukan format standar API)
+// ============================================
+
+// Metadata menampilkan authorization server metadata (RFC 8414)
+func (h *OAuthHandler) Metadata(c *fiber.Ctx) error {
+	return c.JSON(h.oauthService.Metadata())
+}
+
+// Authorize memvalidasi authorization request lalu me-redirect browser ke halaman consent
+// Query string diteruskan apa adanya, halaman consent memanggil GET /oauth/authorize/details dan POST /oauth/authorize
+func (h *OAuthHandler) Authorize(c *fiber.Ctx) error {
+	var req validators.OAuthAuthorizeRequest
+	if err := c.QueryParser(&req); err != nil {
+		return h.protocolError(c, err)
+	}
+
+	authorization, err := h.oauthService.PrepareAuthorization(&req)
+	if err != nil {
+		return h.protocolError(c, err)
+	}
+	if authorization.ErrorRedirect != "" {
+		return c.Redirect(authorization.ErrorRedirect, fiber.StatusFound)
+	}
+
+	return c.Redirect(h.cfg.OAuthConsentURL+"?"+string(c.Request().URI().QueryString()), fiber.StatusFound)
+}
+
+// Token menukar authorization code / refresh token / client credentials dengan access token (RFC 6749 section 3.2)
+func (h *OAuthHandler) Token(c *fiber.Ctx) error {
+	var req validators.OAuthTokenRequest
+	if err := c.BodyParser(&req); err != nil {
+		return h.protocolError(c, err)
+	}
+
+	response, err := h.oauthService.Token(h.clientCredentials(c), &req)
+	if err != nil {
+		return h.protocolError(c, err)
+	}
+
+	h.noStore(c)
+	return c.JSON(response)
+}
+
+// Introspect menampilkan status token untuk resource server (RFC 7662)
+func (h *OAuthHandler) Introspect(c *fiber.Ctx) error {
+	result, err := h.oauthService.Introspect(h.clientCredentials(c), c.FormValue("token"), c.FormValue("token_type_hint"))
+	if err != nil {
+		return h.protocolError(c, err)
+	}
+
+	h.noStore(c)
+	return c.JSON(result)
+}
+
+// Revoke mencabut access token atau refresh token (RFC 7009), selalu 200 untuk token yang tidak dikenal
+func (h *OAuthHandler) Revoke(c *fiber.Ctx) error {
+	if err := h.oauthService.Revoke(h.clientCredentials(c), c.FormValue("token"), c.FormValue("token_type_hint")); err != nil {
+		return h.protocolError(c, err)
+	}
+	return c.SendStatus(fiber.StatusOK)
+}
+
+// ============================================
+// CONSENT ENDPOINTS (dipanggil halaman consent, format response standar API)
+// ============================================
+
+// GetAuthorizationDetails menampilkan nama client dan scope yang diminta untuk halaman consent
+// Jika error_redirect terisi, halaman consent langsung mengarahkan browser ke URL tersebut
+func (h *OAuthHandler) GetAuthorizationDetails(c *fiber.Ctx) error {
+	var req validators.OAuthAuthorizeRequest
+	if err := c.QueryParser(&req); err != nil {
+		return utils.BadRequestResponse(c, "Invalid query parameters", nil)
+	}
+
+	authorization, err := h.oauthService.PrepareAuthorization(&req)
+	if err != nil {
+		return h.handleError(c, err, "Failed to validate authorization request")
+	}
+	return utils.SuccessResponse(c, "Authorization request is valid", authorization)
+}
+
+// ApproveAuthorization mencatat keputusan user (approve true / false) dan mengembalikan URL redirect ke client
+func (h *OAuthHandler) ApproveAuthorization(c *fiber.Ctx) error {
+	var req validators.OAuthAuthorizeRequest
+	if err := c.BodyParser(&req); err != nil {
+		return utils.BadRequestResponse(c, "Invalid request body", nil)
+	}
+
+	redirectTo, err := h.oauthService.Authorize(middlewares.GetUserIDFromContext(c), &req)
+	if err != nil {
+		return h.handleError(c, err, "Failed to authorize client")
+	}
+	return utils.SuccessResponse(c, "Redirect the user to continue", fiber.Map{
+		"redirect_to": redirectTo,
+	})
+}
+
+// ============================================
+// CLIENT MANAGEMENT (ADMIN)
+// ============================================
+
+func (h *OAuthHandler) GetClients(c *fiber.Ctx) error {
+	clients, err := h.oauthService.ListClients()
+	if err != nil {
+		return utils.InternalServerErrorResponse(c, "Failed to fetch oauth clients")
+	}
+	return utils.SuccessResponse(c, "OAuth clients retrieved successfully", clients)
+}
+
+// CreateClient mendaftarkan client, secret (client confidential) hanya ditampilkan sekali di response ini
+func (h *OAuthHandler) CreateClient(c *fiber.Ctx) error {
+	var req validators.CreateOAuthClientRequest
+	if err := validators.ParseAndValidate(c, &req); err != nil {
+		if validationErrors := validators.FormatValidationError(err); len(validationErrors) > 0 {
+			return utils.BadRequestResponse(c, "Validation failed", validationErrors)
+		}
+		return utils.BadRequestResponse(c, err.Error(), nil)
+	}
+
+	created, err := h.oauthService.CreateClient(middlewares.GetUserIDFromContext(c), &req)
+	if err != nil {
+		return h.handleError(c, err, "Failed to create oauth client")
+	}
+	return utils.CreatedResponse(c, "OAuth client created. Store the client secret now, it will not be shown again.", created)
+}
+
+func (h *OAuthHandler) GetClient(c *fiber.Ctx) error {
+	id, err := strconv.ParseUint(c.Params("id"), 10, 32)
+	if err != nil {
+		return utils.BadRequestResponse(c, "Invalid client ID", nil)
+	}
+
+	client, err := h.oauthService.GetClient(uint(id))
+	if err != nil {
+		return h.handleError(c, err, "Failed to fetch oauth client")
+	}
+	return utils.SuccessResponse(c, "OAuth client retrieved successfully", client)
+}
+
+func (h *OAuthHandler) UpdateClient(c *fiber.Ctx) error {
+	id, err := strconv.ParseUint(c.Params("id"), 10, 32)
+	if err != nil {
+		return utils.BadRequestResponse(c, "Invalid client ID", nil)
+	}
+
+	var req validators.UpdateOAuthClientRequest
+	if err := validators.ParseAndValidate(c, &req); err != nil {
+		if validationErrors := validators.FormatValidationError(err); len(validationErrors) > 0 {
+			return utils.BadRequestResponse(c, "Validation failed", validationErrors)
+		}
+		return utils.BadRequestResponse(c, err.Error(), nil)
+	}
+
+	client, err := h.oauthService.UpdateClient(uint(id), &req)
+	if err != nil {
+		return h.handleError(c, err, "Failed to update oauth client")
+	}
+	return utils.SuccessResponse(c, "OAuth client updated successfully", client)
+}
+
+func (h *OAuthHandler) RotateClientSecret(c *fiber.Ctx) error {
+	id, err := strconv.ParseUint(c.Params("id"), 10, 32)
+	if err != nil {
+		return utils.BadRequestResponse(c, "Invalid client ID", nil)
+	}
+
+	rotated, err := h.oauthService.RotateClientSecret(uint(id))
+	if err != nil {
+		return h.handleError(c, err, "Failed to rotate client secret")
+	}
+	return utils.SuccessResponse(c, "Client secret rotated. Store the new secret now, it will not be shown again.", rotated)
+}
+
+// DisableClient menonaktifkan client dan me-revoke semua token miliknya
+func (h *OAuthHandler) DisableClient(c *fiber.Ctx) error {
+	id, err := strconv.ParseUint(c.Params("id"), 10, 32)
+	if err != nil {
+		return utils.BadRequestResponse(c, "Invalid client ID", nil)
+	}
+
+	if err := h.oauthService.DisableClient(uint(id)); err != nil {
+		return h.handleError(c, err, "Failed to disable oauth client")
+	}
+	return utils.SuccessResponse(c, "OAuth client disabled and its tokens revoked", nil)
+}
+
+// clientCredentials membaca client_secret_basic (header Authorization) atau client_secret_post / public client (body)
+// Nilai di header Basic di-URL-encode sesuai RFC 6749 section 2.3.1
+func (h *OAuthHandler) clientCredentials(c *fiber.Ctx) services.OAuthClientCredentials {
+	parts := strings.SplitN(c.Get(fiber.HeaderAuthorization), " ", 2)
+	if len(parts) == 2 && strings.EqualFold(parts[0], "Basic") {
+		decoded, err := base64.StdEncoding.DecodeString(strings.TrimSpace(parts[1]))
+		if err == nil {
+			if id, secret, ok := strings.Cut(string(decoded), ":"); ok {
+				clientID, errID := url.QueryUnescape(id)
+				clientSecret, errSecret := url.QueryUnescape(secret)
+				if errID == nil && errSecret == nil {
+					return services.OAuthClientCredentials{ClientID: clientID, ClientSecret: clientSecret}
+				}
+			}
+		}
+		return services.OAuthClientCredentials{}
+	}
+
+	return services.OAuthClientCredentials{
+		ClientID:     c.FormValue("client_id"),
+		ClientSecret: c.FormValue("client_secret"),
+	}
+}
+
+// protocolError mengirim error dengan format OAuth2 {"error", "error_description"}
+func (h *OAuthHandler) protocolError(c *fiber.Ctx, err error) error {
+	var oauthErr *services.OAuthError
+	if !errors.As(err, &oauthErr) {
+		var fiberErr *fiber.Error
+		if errors.As(err, &fiberErr) {
+			oauthErr = &services.OAuthError{Status: fiber.StatusBadRequest, Code: "invalid_request", Description: "malformed request"}
+		} else {
+			oauthErr = &services.OAuthError{Status: fiber.StatusInternalServerError, Code: "server_error", Description: "internal server error"}
+		}
+	}
+
+	if oauthErr.Status == fiber.StatusUnauthorized {
+		c.Set(fiber.HeaderWWWAuthenticate, `Basic realm="oauth"`)
+	}
+	h.noStore(c)
+	return c.Status(oauthErr.Status).JSON(fiber.Map{
+		"error":             oauthErr.Code,
+		"error_description": oauthErr.Description,
+	})
+}
+
+func (h *OAuthHandler) noStore(c *fiber.Ctx) {
+	c.Set(fiber.HeaderCacheControl, "no-store")
+	c.Set(fiber.HeaderPragma, "no-cache")
+}
+
+func (h *OAuthHandler) handleError(c *fiber.Ctx, err error, fallback string) error {
+	var oauthErr *services.OAuthError
+	if errors.As(err, &oauthErr) {
+		return utils.BadRequestResponse(c, oauthErr.Description, fiber.Map{"error": oauthErr.Code})
+	}
+
+	errorMessage := err.Error()
+	if errorMessage == "oauth client not found" || errorMessage == "user not found" {
+		return utils.NotFoundResponse(c, errorMessage)
+	}
+	if errorMessage == "public clients do not have a secret" ||
+		strings.HasPrefix(errorMessage, "redirect_uris is required") ||
+		strings.HasPrefix(errorMessage, "refresh_token grant requires") ||
+		strings.HasPrefix(errorMessage, "client_credentials grant requires") ||
+		strings.HasPrefix(errorMessage, "invalid redirect uri") ||
+		strings.HasPrefix(errorMessage, "redirect uri must") {
+		return utils.BadRequestResponse(c, errorMessage, nil)
+	}
+	return utils.InternalServerErrorResponse(c, fallback)
+}
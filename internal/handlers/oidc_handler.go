@@ -0,0 +1,185 @@
+package handlers
+
+import (
+	"crypto/subtle"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/LutfiyaAinurrahmanP/boilerplate_fiber_restful_api/internal/config"
+	"github.com/LutfiyaAinurrahmanP/boilerplate_fiber_restful_api/internal/middlewares"
+	"github.com/LutfiyaAinurrahmanP/boilerplate_fiber_restful_api/internal/services"
+	"github.com/LutfiyaAinurrahmanP/boilerplate_fiber_restful_api/internal/utils"
+	"github.com/gofiber/fiber/v2"
+)
+
+// oidcStateCookie mengikat state OIDC ke browser yang memulai login (mencegah login CSRF)
+const oidcStateCookie = "oidc_state"
+
+type OIDCHandler struct {
+	oidcService services.OIDCService
+	cfg         *config.Config
+}
+
+func NewOIDCHandler(oidcService services.OIDCService, cfg *config.Config) *OIDCHandler {
+	return &OIDCHandler{
+		oidcService: oidcService,
+		cfg:         cfg,
+	}
+}
+
+// GetProviders menampilkan identity provider yang bisa dipakai untuk login
+func (h *OIDCHandler) GetProviders(c *fiber.Ctx) error {
+	return utils.SuccessResponse(c, "OIDC providers retrieved successfully", fiber.Map{
+		"providers": h.oidcService.Providers(),
+	})
+}
+
+// Login me-redirect browser ke halaman login identity provider
+func (h *OIDCHandler) Login(c *fiber.Ctx) error {
+	authorization, err := h.oidcService.BeginLogin(c.Params("provider"))
+	if err != nil {
+		return h.handleError(c, err, "Failed to start oidc login")
+	}
+
+	h.setStateCookie(c, authorization.State)
+	return c.Redirect(authorization.URL, fiber.StatusFound)
+}
+
+// Link memulai flow untuk menghubungkan identity provider ke akun yang sedang login
+// Client membuka authorization_url di browser yang sama (cookie state harus ikut terkirim saat callback)
+func (h *OIDCHandler) Link(c *fiber.Ctx) error {
+	authorization, err := h.oidcService.BeginLink(c.Params("provider"), middlewares.GetUserIDFromContext(c))
+	if err != nil {
+		return h.handleError(c, err, "Failed to start oidc link")
+	}
+
+	h.setStateCookie(c, authorization.State)
+	return utils.SuccessResponse(c, "Open the authorization URL to link your account", fiber.Map{
+		"authorization_url": authorization.URL,
+	})
+}
+
+// Callback menerima redirect dari identity provider lalu login / menghubungkan identitas
+func (h *OIDCHandler) Callback(c *fiber.Ctx) error {
+	if idpError := c.Query("error"); idpError != "" {
+		h.clearStateCookie(c)
+		return utils.BadRequestResponse(c, "Identity provider returned an error", fiber.Map{
+			"error":             idpError,
+			"error_description": c.Query("error_description"),
+		})
+	}
+
+	code := c.Query("code")
+	state := c.Query("state")
+	cookieState := c.Cookies(oidcStateCookie)
+	h.clearStateCookie(c)
+
+	if code == "" || state == "" || subtle.ConstantTimeCompare([]byte(state), []byte(cookieState)) != 1 {
+		return utils.BadRequestResponse(c, "invalid or expired oidc state", nil)
+	}
+
+	result, err := h.oidcService.Callback(c.Params("provider"), code, state, services.ClientInfo{
+		Device:    "oidc:" + c.Params("provider"),
+		IPAddress: c.IP(),
+		UserAgent: truncate(c.Get(fiber.HeaderUserAgent), 255),
+	})
+	if err != nil {
+		return h.handleError(c, err, "Failed to complete oidc login")
+	}
+
+	if result.LinkedIdentity != nil {
+		return utils.SuccessResponse(c, "Identity linked successfully", result.LinkedIdentity)
+	}
+
+	// 2FA aktif: client harus menukar mfa_token di POST /auth/2fa/verify
+	login := result.Login
+	if login.MFAToken != "" {
+		return utils.SuccessResponse(c, "Two-factor authentication required", fiber.Map{
+			"mfa_required": true,
+			"mfa_token":    login.MFAToken,
+			"expires_in":   login.MFAExpiresIn,
+		})
+	}
+
+	return utils.SuccessResponse(c, "Login succesful", fiber.Map{
+		"token":         login.Tokens.AccessToken,
+		"refresh_token": login.Tokens.RefreshToken,
+		"token_type":    login.Tokens.TokenType,
+		"expires_in":    login.Tokens.ExpiresIn,
+		"user":          login.User,
+	})
+}
+
+// GetIdentities menampilkan identity provider yang terhubung ke akun sendiri
+func (h *OIDCHandler) GetIdentities(c *fiber.Ctx) error {
+	identities, err := h.oidcService.ListIdentities(middlewares.GetUserIDFromContext(c))
+	if err != nil {
+		return utils.InternalServerErrorResponse(c, "Failed to fetch identities")
+	}
+	return utils.SuccessResponse(c, "Identities retrieved successfully", identities)
+}
+
+// UnlinkIdentity memutus identity provider dari akun sendiri
+func (h *OIDCHandler) UnlinkIdentity(c *fiber.Ctx) error {
+	id, err := strconv.ParseUint(c.Params("id"), 10, 32)
+	if err != nil {
+		return utils.BadRequestResponse(c, "Invalid identity ID", nil)
+	}
+
+	if err := h.oidcService.UnlinkIdentity(middlewares.GetUserIDFromContext(c), uint(id)); err != nil {
+		return h.handleError(c, err, "Failed to unlink identity")
+	}
+	return utils.SuccessResponse(c, "Identity unlinked successfully", nil)
+}
+
+func (h *OIDCHandler) setStateCookie(c *fiber.Ctx, state string) {
+	// SameSite=Lax: cookie tetap terkirim pada redirect GET dari identity provider
+	c.Cookie(&fiber.Cookie{
+		Name:     oidcStateCookie,
+		Value:    state,
+		Path:     "/",
+		Expires:  time.Now().Add(h.cfg.OIDCStateTTL),
+		HTTPOnly: true,
+		Secure:   h.cfg.AppEnv == "production",
+		SameSite: fiber.CookieSameSiteLaxMode,
+	})
+}
+
+func (h *OIDCHandler) clearStateCookie(c *fiber.Ctx) {
+	c.Cookie(&fiber.Cookie{
+		Name:     oidcStateCookie,
+		Value:    "",
+		Path:     "/",
+		Expires:  time.Unix(0, 0),
+		HTTPOnly: true,
+		Secure:   h.cfg.AppEnv == "production",
+		SameSite: fiber.CookieSameSiteLaxMode,
+	})
+}
+
+func (h *OIDCHandler) handleError(c *fiber.Ctx, err error, fallback string) error {
+	errorMessage := err.Error()
+	switch {
+	case errorMessage == "oidc provider not found" ||
+		errorMessage == "identity not found" ||
+		errorMessage == "user not found":
+		return utils.NotFoundResponse(c, errorMessage)
+	case errorMessage == "invalid or expired oidc state" ||
+		errorMessage == "identity provider did not return an email address":
+		return utils.BadRequestResponse(c, errorMessage, nil)
+	case errorMessage == "oidc login failed" ||
+		errorMessage == "account is not available":
+		return utils.UnauthorizedResponse(c, errorMessage)
+	case errorMessage == "email not verified" ||
+		errorMessage == "not a member of this organization" ||
+		strings.HasPrefix(errorMessage, "registration is ") ||
+		errorMessage == "email domain is not allowed to register":
+		return utils.ForbiddenResponse(c, errorMessage)
+	case strings.HasPrefix(errorMessage, "an account with this email already exists") ||
+		strings.HasPrefix(errorMessage, "identity already linked") ||
+		strings.HasPrefix(errorMessage, "cannot unlink the only sign-in method"):
+		return utils.ConflictResponse(c, errorMessage)
+	}
+	return utils.InternalServerErrorResponse(c, fallback)
+}
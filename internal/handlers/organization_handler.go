@@ -0,0 +1,227 @@
+package handlers
+
+import (
+	"strconv"
+	"strings"
+
+	"github.com/LutfiyaAinurrahmanP/boilerplate_fiber_restful_api/internal/middlewares"
+	"github.com/LutfiyaAinurrahmanP/boilerplate_fiber_restful_api/internal/services"
+	"github.com/LutfiyaAinurrahmanP/boilerplate_fiber_restful_api/internal/utils"
+	"github.com/LutfiyaAinurrahmanP/boilerplate_fiber_restful_api/internal/validators"
+	"github.com/gofiber/fiber/v2"
+)
+
+type OrganizationHandler struct {
+	organizationService services.OrganizationService
+	authService         services.AuthService
+}
+
+func NewOrganizationHandler(organizationService services.OrganizationService, authService services.AuthService) *OrganizationHandler {
+	return &OrganizationHandler{
+		organizationService: organizationService,
+		authService:         authService,
+	}
+}
+
+func (h *OrganizationHandler) GetOrganizations(c *fiber.Ctx) error {
+	organizations, err := h.organizationService.ListOrganizations(middlewares.GetOrganizationIDFromContext(c))
+	if err != nil {
+		return h.handleError(c, err, "Failed to fetch organizations")
+	}
+	return utils.SuccessResponse(c, "Organizations retrieved successfully", organizations)
+}
+
+func (h *OrganizationHandler) GetOrganization(c *fiber.Ctx) error {
+	id, err := strconv.ParseUint(c.Params("id"), 10, 32)
+	if err != nil {
+		return utils.BadRequestResponse(c, "Invalid organization ID", nil)
+	}
+
+	organization, err := h.organizationService.GetOrganization(middlewares.GetOrganizationIDFromContext(c), uint(id))
+	if err != nil {
+		return h.handleError(c, err, "Failed to fetch organization")
+	}
+	return utils.SuccessResponse(c, "Organization retrieved successfully", organization)
+}
+
+func (h *OrganizationHandler) CreateOrganization(c *fiber.Ctx) error {
+	var req validators.CreateOrganizationRequest
+	if err := validators.ParseAndValidate(c, &req); err != nil {
+		if validationErrors := validators.FormatValidationError(err); len(validationErrors) > 0 {
+			return utils.BadRequestResponse(c, "Validation failed", validationErrors)
+		}
+		return utils.BadRequestResponse(c, err.Error(), nil)
+	}
+
+	organization, err := h.organizationService.CreateOrganization(middlewares.GetOrganizationIDFromContext(c), &req)
+	if err != nil {
+		return h.handleError(c, err, "Failed to create organization")
+	}
+	return utils.CreatedResponse(c, "Organization created successfully", organization)
+}
+
+func (h *OrganizationHandler) UpdateOrganization(c *fiber.Ctx) error {
+	id, err := strconv.ParseUint(c.Params("id"), 10, 32)
+	if err != nil {
+		return utils.BadRequestResponse(c, "Invalid organization ID", nil)
+	}
+
+	var req validators.UpdateOrganizationRequest
+	if err := validators.ParseAndValidate(c, &req); err != nil {
+		if validationErrors := validators.FormatValidationError(err); len(validationErrors) > 0 {
+			return utils.BadRequestResponse(c, "Validation failed", validationErrors)
+		}
+		return utils.BadRequestResponse(c, err.Error(), nil)
+	}
+
+	organization, err := h.organizationService.UpdateOrganization(middlewares.GetOrganizationIDFromContext(c), uint(id), &req)
+	if err != nil {
+		return h.handleError(c, err, "Failed to update organization")
+	}
+	return utils.SuccessResponse(c, "Organization updated successfully", organization)
+}
+
+func (h *OrganizationHandler) GetMembers(c *fiber.Ctx) error {
+	id, err := strconv.ParseUint(c.Params("id"), 10, 32)
+	if err != nil {
+		return utils.BadRequestResponse(c, "Invalid organization ID", nil)
+	}
+
+	members, err := h.organizationService.ListMembers(middlewares.GetOrganizationIDFromContext(c), uint(id))
+	if err != nil {
+		return h.handleError(c, err, "Failed to fetch organization members")
+	}
+	return utils.SuccessResponse(c, "Organization members retrieved successfully", members)
+}
+
+// AddMember memberi user dari organisasi lain akses ke organisasi dengan role tertentu
+func (h *OrganizationHandler) AddMember(c *fiber.Ctx) error {
+	id, err := strconv.ParseUint(c.Params("id"), 10, 32)
+	if err != nil {
+		return utils.BadRequestResponse(c, "Invalid organization ID", nil)
+	}
+
+	var req validators.AddOrganizationMemberRequest
+	if err := validators.ParseAndValidate(c, &req); err != nil {
+		if validationErrors := validators.FormatValidationError(err); len(validationErrors) > 0 {
+			return utils.BadRequestResponse(c, "Validation failed", validationErrors)
+		}
+		return utils.BadRequestResponse(c, err.Error(), nil)
+	}
+
+	member, err := h.organizationService.AddMember(middlewares.GetOrganizationIDFromContext(c), middlewares.GetRoleFromContext(c), uint(id), &req)
+	if err != nil {
+		return h.handleError(c, err, "Failed to add organization member")
+	}
+	return utils.CreatedResponse(c, "Organization member added successfully", member)
+}
+
+// UpdateMember mengubah role user di organisasi, token user di-invalidasi
+func (h *OrganizationHandler) UpdateMember(c *fiber.Ctx) error {
+	id, err := strconv.ParseUint(c.Params("id"), 10, 32)
+	if err != nil {
+		return utils.BadRequestResponse(c, "Invalid organization ID", nil)
+	}
+	userID, err := strconv.ParseUint(c.Params("userId"), 10, 32)
+	if err != nil {
+		return utils.BadRequestResponse(c, "Invalid user ID", nil)
+	}
+
+	var req validators.UpdateOrganizationMemberRequest
+	if err := validators.ParseAndValidate(c, &req); err != nil {
+		if validationErrors := validators.FormatValidationError(err); len(validationErrors) > 0 {
+			return utils.BadRequestResponse(c, "Validation failed", validationErrors)
+		}
+		return utils.BadRequestResponse(c, err.Error(), nil)
+	}
+
+	member, err := h.organizationService.UpdateMember(middlewares.GetOrganizationIDFromContext(c), middlewares.GetRoleFromContext(c), uint(id), uint(userID), &req)
+	if err != nil {
+		return h.handleError(c, err, "Failed to update organization member")
+	}
+	return utils.SuccessResponse(c, "Organization member updated successfully", member)
+}
+
+func (h *OrganizationHandler) RemoveMember(c *fiber.Ctx) error {
+	id, err := strconv.ParseUint(c.Params("id"), 10, 32)
+	if err != nil {
+		return utils.BadRequestResponse(c, "Invalid organization ID", nil)
+	}
+	userID, err := strconv.ParseUint(c.Params("userId"), 10, 32)
+	if err != nil {
+		return utils.BadRequestResponse(c, "Invalid user ID", nil)
+	}
+
+	if err := h.organizationService.RemoveMember(middlewares.GetOrganizationIDFromContext(c), uint(id), uint(userID)); err != nil {
+		return h.handleError(c, err, "Failed to remove organization member")
+	}
+	return utils.SuccessResponse(c, "Organization member removed successfully", nil)
+}
+
+// GetMyOrganizations menampilkan organisasi tempat user yang sedang login menjadi anggota
+func (h *OrganizationHandler) GetMyOrganizations(c *fiber.Ctx) error {
+	memberships, err := h.organizationService.ListUserOrganizations(middlewares.GetUserIDFromContext(c))
+	if err != nil {
+		return utils.InternalServerErrorResponse(c, "Failed to fetch organizations")
+	}
+
+	return utils.SuccessResponse(c, "Organizations retrieved successfully", fiber.Map{
+		"organizations":           memberships,
+		"current_organization_id": middlewares.GetOrganizationIDFromContext(c),
+	})
+}
+
+// SwitchOrganization mengganti organisasi aktif session saat ini dan menerbitkan token baru
+func (h *OrganizationHandler) SwitchOrganization(c *fiber.Ctx) error {
+	var req validators.SwitchOrganizationRequest
+	if err := validators.ParseAndValidate(c, &req); err != nil {
+		if validationErrors := validators.FormatValidationError(err); len(validationErrors) > 0 {
+			return utils.BadRequestResponse(c, "Validation failed", validationErrors)
+		}
+		return utils.BadRequestResponse(c, err.Error(), nil)
+	}
+
+	sessionID := ""
+	if claims := middlewares.GetClaimsFromContext(c); claims != nil {
+		sessionID = claims.SessionID
+	}
+
+	tokens, err := h.authService.SwitchOrganization(middlewares.GetUserIDFromContext(c), sessionID, req.OrganizationID)
+	if err != nil {
+		errorMessage := err.Error()
+		if errorMessage == "session not found" {
+			return utils.UnauthorizedResponse(c, errorMessage)
+		}
+		if errorMessage == "not a member of this organization" {
+			return utils.ForbiddenResponse(c, errorMessage)
+		}
+		return utils.InternalServerErrorResponse(c, "Failed to switch organization")
+	}
+
+	return utils.SuccessResponse(c, "Organization switched successfully", tokens)
+}
+
+func (h *OrganizationHandler) handleError(c *fiber.Ctx, err error, fallback string) error {
+	errorMessage := err.Error()
+	if errorMessage == "cannot remove the last active admin" {
+		return utils.ConflictCodeResponse(c, utils.ErrorCodeLastAdmin, errorMessage)
+	}
+	if errorMessage == "organization not found" ||
+		errorMessage == "user not found" ||
+		errorMessage == "member not found" {
+		return utils.NotFoundResponse(c, errorMessage)
+	}
+	if errorMessage == "organization slug already exists" ||
+		errorMessage == "user is already a member of this organization" {
+		return utils.ConflictResponse(c, errorMessage)
+	}
+	if errorMessage == "only the default organization can manage other organizations" ||
+		errorMessage == "cannot remove a user from their home organization" ||
+		errorMessage == "cannot assign a role above your own role" {
+		return utils.ForbiddenResponse(c, errorMessage)
+	}
+	if errorMessage == "invalid role" || strings.HasPrefix(errorMessage, "invalid organization slug") {
+		return utils.BadRequestResponse(c, errorMessage, nil)
+	}
+	return utils.InternalServerErrorResponse(c, fallback)
+}
@@ -0,0 +1,58 @@
+package handlers
+
+import (
+	"strings"
+
+	"github.com/LutfiyaAinurrahmanP/boilerplate_fiber_restful_api/internal/services"
+	"github.com/LutfiyaAinurrahmanP/boilerplate_fiber_restful_api/internal/utils"
+	"github.com/LutfiyaAinurrahmanP/boilerplate_fiber_restful_api/internal/validators"
+	"github.com/gofiber/fiber/v2"
+)
+
+type PasswordResetHandler struct {
+	passwordResetService services.PasswordResetService
+}
+
+func NewPasswordResetHandler(passwordResetService services.PasswordResetService) *PasswordResetHandler {
+	return &PasswordResetHandler{
+		passwordResetService: passwordResetService,
+	}
+}
+
+func (h *PasswordResetHandler) ForgotPassword(c *fiber.Ctx) error {
+	var req validators.ForgotPasswordRequest
+	if err := validators.ParseAndValidate(c, &req); err != nil {
+		if validationErrors := validators.FormatValidationError(err); len(validationErrors) > 0 {
+			return utils.BadRequestResponse(c, "Validation failed", validationErrors)
+		}
+		return utils.BadRequestResponse(c, err.Error(), nil)
+	}
+
+	if err := h.passwordResetService.ForgotPassword(&req); err != nil {
+		return utils.InternalServerErrorResponse(c, "Failed to process request")
+	}
+
+	// Response selalu sama, baik email terdaftar maupun tidak
+	return utils.SuccessResponse(c, "If the email is registered, a password reset link has been sent", nil)
+}
+
+func (h *PasswordResetHandler) ResetPassword(c *fiber.Ctx) error {
+	var req validators.ResetPasswordRequest
+	if err := validators.ParseAndValidate(c, &req); err != nil {
+		if validationErrors := validators.FormatValidationError(err); len(validationErrors) > 0 {
+			return utils.BadRequestResponse(c, "Validation failed", validationErrors)
+		}
+		return utils.BadRequestResponse(c, err.Error(), nil)
+	}
+
+	if err := h.passwordResetService.ResetPassword(&req); err != nil {
+		errorMessage := err.Error()
+		if errorMessage == "invalid or expired reset token" ||
+			strings.HasPrefix(errorMessage, "password must") {
+			return utils.BadRequestResponse(c, errorMessage, nil)
+		}
+		return utils.InternalServerErrorResponse(c, "Failed to reset password")
+	}
+
+	return utils.SuccessResponse(c, "Password has been reset. Please login with your new password.", nil)
+}
@@ -0,0 +1,124 @@
+package handlers
+
+import (
+	"strconv"
+	"strings"
+
+	"github.com/LutfiyaAinurrahmanP/boilerplate_fiber_restful_api/internal/middlewares"
+	"github.com/LutfiyaAinurrahmanP/boilerplate_fiber_restful_api/internal/services"
+	"github.com/LutfiyaAinurrahmanP/boilerplate_fiber_restful_api/internal/utils"
+	"github.com/LutfiyaAinurrahmanP/boilerplate_fiber_restful_api/internal/validators"
+	"github.com/gofiber/fiber/v2"
+)
+
+type RoleHandler struct {
+	roleService services.RoleService
+}
+
+func NewRoleHandler(roleService services.RoleService) *RoleHandler {
+	return &RoleHandler{
+		roleService: roleService,
+	}
+}
+
+func (h *RoleHandler) GetRoles(c *fiber.Ctx) error {
+	roles, err := h.roleService.ListRoles()
+	if err != nil {
+		return utils.InternalServerErrorResponse(c, "Failed to fetch roles")
+	}
+	return utils.SuccessResponse(c, "Roles retrieved successfully", roles)
+}
+
+func (h *RoleHandler) GetRole(c *fiber.Ctx) error {
+	id, err := strconv.ParseUint(c.Params("id"), 10, 32)
+	if err != nil {
+		return utils.BadRequestResponse(c, "Invalid role ID", nil)
+	}
+
+	role, err := h.roleService.GetRole(uint(id))
+	if err != nil {
+		return h.handleError(c, err, "Failed to fetch role")
+	}
+	return utils.SuccessResponse(c, "Role retrieved successfully", role)
+}
+
+func (h *RoleHandler) CreateRole(c *fiber.Ctx) error {
+	var req validators.CreateRoleRequest
+	if err := validators.ParseAndValidate(c, &req); err != nil {
+		if validationErrors := validators.FormatValidationError(err); len(validationErrors) > 0 {
+			return utils.BadRequestResponse(c, "Validation failed", validationErrors)
+		}
+		return utils.BadRequestResponse(c, err.Error(), nil)
+	}
+
+	role, err := h.roleService.CreateRole(middlewares.GetRoleFromContext(c), &req)
+	if err != nil {
+		return h.handleError(c, err, "Failed to create role")
+	}
+	return utils.CreatedResponse(c, "Role created successfully", role)
+}
+
+// UpdateRole mengubah deskripsi dan / atau mengganti seluruh permission role
+func (h *RoleHandler) UpdateRole(c *fiber.Ctx) error {
+	id, err := strconv.ParseUint(c.Params("id"), 10, 32)
+	if err != nil {
+		return utils.BadRequestResponse(c, "Invalid role ID", nil)
+	}
+
+	var req validators.UpdateRoleRequest
+	if err := validators.ParseAndValidate(c, &req); err != nil {
+		if validationErrors := validators.FormatValidationError(err); len(validationErrors) > 0 {
+			return utils.BadRequestResponse(c, "Validation failed", validationErrors)
+		}
+		return utils.BadRequestResponse(c, err.Error(), nil)
+	}
+
+	role, err := h.roleService.UpdateRole(middlewares.GetRoleFromContext(c), uint(id), &req)
+	if err != nil {
+		return h.handleError(c, err, "Failed to update role")
+	}
+	return utils.SuccessResponse(c, "Role updated successfully", role)
+}
+
+// DeleteRole menghapus role yang bukan role sistem dan tidak dipakai user mana pun
+func (h *RoleHandler) DeleteRole(c *fiber.Ctx) error {
+	id, err := strconv.ParseUint(c.Params("id"), 10, 32)
+	if err != nil {
+		return utils.BadRequestResponse(c, "Invalid role ID", nil)
+	}
+
+	if err := h.roleService.DeleteRole(uint(id)); err != nil {
+		return h.handleError(c, err, "Failed to delete role")
+	}
+	return utils.SuccessResponse(c, "Role deleted successfully", nil)
+}
+
+// GetPermissions menampilkan katalog permission yang bisa diberikan ke role
+func (h *RoleHandler) GetPermissions(c *fiber.Ctx) error {
+	permissions, err := h.roleService.ListPermissions()
+	if err != nil {
+		return utils.InternalServerErrorResponse(c, "Failed to fetch permissions")
+	}
+	return utils.SuccessResponse(c, "Permissions retrieved successfully", permissions)
+}
+
+func (h *RoleHandler) handleError(c *fiber.Ctx, err error, fallback string) error {
+	errorMessage := err.Error()
+	if errorMessage == "role not found" {
+		return utils.NotFoundResponse(c, errorMessage)
+	}
+	if errorMessage == "role already exists" || errorMessage == "role is still assigned to users" {
+		return utils.ConflictResponse(c, errorMessage)
+	}
+	if errorMessage == "system role cannot be deleted" ||
+		errorMessage == "permissions of the admin role cannot be changed" ||
+		errorMessage == "level of a system role cannot be changed" ||
+		errorMessage == "cannot assign a level above your own role" ||
+		strings.HasPrefix(errorMessage, "cannot grant a permission") {
+		return utils.ForbiddenResponse(c, errorMessage)
+	}
+	if strings.HasPrefix(errorMessage, "invalid role name") || strings.HasPrefix(errorMessage, "unknown permission") {
+		return utils.BadRequestResponse(c, errorMessage, nil)
+	}
+	return utils.InternalServerErrorResponse(c, fallback)
+}
@@ -0,0 +1,89 @@
+package handlers
+
+import (
+	"strconv"
+
+	"github.com/LutfiyaAinurrahmanP/boilerplate_fiber_restful_api/internal/middlewares"
+	"github.com/LutfiyaAinurrahmanP/boilerplate_fiber_restful_api/internal/services"
+	"github.com/LutfiyaAinurrahmanP/boilerplate_fiber_restful_api/internal/utils"
+	"github.com/gofiber/fiber/v2"
+)
+
+type SessionHandler struct {
+	authService services.AuthService
+	userService services.UserService
+}
+
+func NewSessionHandler(authService services.AuthService, userService services.UserService) *SessionHandler {
+	return &SessionHandler{
+		authService: authService,
+		userService: userService,
+	}
+}
+
+// GetSessions menampilkan semua session aktif milik user yang sedang login
+func (h *SessionHandler) GetSessions(c *fiber.Ctx) error {
+	userID := middlewares.GetUserIDFromContext(c)
+
+	sessions, err := h.authService.ListSessions(userID)
+	if err != nil {
+		return utils.InternalServerErrorResponse(c, "Failed to fetch sessions")
+	}
+
+	currentSessionID := ""
+	if claims := middlewares.GetClaimsFromContext(c); claims != nil {
+		currentSessionID = claims.SessionID
+	}
+
+	return utils.SuccessResponse(c, "Sessions retrieved successfully", fiber.Map{
+		"sessions":           sessions,
+		"current_session_id": currentSessionID,
+	})
+}
+
+func (h *SessionHandler) RevokeSession(c *fiber.Ctx) error {
+	userID := middlewares.GetUserIDFromContext(c)
+
+	if err := h.authService.RevokeSession(userID, c.Params("id")); err != nil {
+		if err.Error() == "session not found" {
+			return utils.NotFoundResponse(c, err.Error())
+		}
+		return utils.InternalServerErrorResponse(c, "Failed to revoke session")
+	}
+
+	return utils.SuccessResponse(c, "Session revoked successfully", nil)
+}
+
+// RevokeAllSessions me-logout user dari semua perangkat, termasuk perangkat saat ini
+func (h *SessionHandler) RevokeAllSessions(c *fiber.Ctx) error {
+	userID := middlewares.GetUserIDFromContext(c)
+
+	if err := h.authService.RevokeAllSessions(userID); err != nil {
+		return utils.InternalServerErrorResponse(c, "Failed to revoke sessions")
+	}
+
+	return utils.SuccessResponse(c, "All sessions revoked successfully", nil)
+}
+
+// ForceLogoutUser dipakai admin untuk me-logout user tertentu dari semua perangkat
+func (h *SessionHandler) ForceLogoutUser(c *fiber.Ctx) error {
+	id, err := strconv.ParseUint(c.Params("id"), 10, 32)
+	if err != nil {
+		return utils.BadRequestResponse(c, "Invalid user ID", nil)
+	}
+
+	// Hanya user yang menjadi anggota organisasi pemanggil
+	users := h.userService.ForOrganization(middlewares.GetOrganizationIDFromContext(c))
+	if _, err := users.GetUserByID(uint(id)); err != nil {
+		if err.Error() == "user not found" {
+			return utils.NotFoundResponse(c, err.Error())
+		}
+		return utils.InternalServerErrorResponse(c, "Failed to fetch user")
+	}
+
+	if err := h.authService.RevokeAllSessions(uint(id)); err != nil {
+		return utils.InternalServerErrorResponse(c, "Failed to revoke user sessions")
+	}
+
+	return utils.SuccessResponse(c, "User has been logged out from all sessions", nil)
+}
@@ -0,0 +1,144 @@
+package handlers
+
+import (
+	"github.com/LutfiyaAinurrahmanP/boilerplate_fiber_restful_api/internal/middlewares"
+	"github.com/LutfiyaAinurrahmanP/boilerplate_fiber_restful_api/internal/services"
+	"github.com/LutfiyaAinurrahmanP/boilerplate_fiber_restful_api/internal/utils"
+	"github.com/LutfiyaAinurrahmanP/boilerplate_fiber_restful_api/internal/validators"
+	"github.com/gofiber/fiber/v2"
+)
+
+type TwoFactorHandler struct {
+	twoFactorService services.TwoFactorService
+}
+
+func NewTwoFactorHandler(twoFactorService services.TwoFactorService) *TwoFactorHandler {
+	return &TwoFactorHandler{
+		twoFactorService: twoFactorService,
+	}
+}
+
+// Enroll membuat secret TOTP baru dan mengembalikan otpauth URI untuk authenticator app
+func (h *TwoFactorHandler) Enroll(c *fiber.Ctx) error {
+	var req validators.EnrollTwoFactorRequest
+	if err := validators.ParseAndValidate(c, &req); err != nil {
+		if validationErrors := validators.FormatValidationError(err); len(validationErrors) > 0 {
+			return utils.BadRequestResponse(c, "Validation failed", validationErrors)
+		}
+		return utils.BadRequestResponse(c, err.Error(), nil)
+	}
+
+	enrollment, err := h.twoFactorService.Enroll(middlewares.GetUserIDFromContext(c), &req)
+	if err != nil {
+		return h.handleError(c, err, "Failed to enroll two-factor authentication")
+	}
+
+	return utils.SuccessResponse(c, "Scan the QR code and confirm with a code from your authenticator app", enrollment)
+}
+
+// Confirm mengaktifkan 2FA, recovery code hanya ditampilkan sekali di response ini
+func (h *TwoFactorHandler) Confirm(c *fiber.Ctx) error {
+	var req validators.TwoFactorCodeRequest
+	if err := validators.ParseAndValidate(c, &req); err != nil {
+		if validationErrors := validators.FormatValidationError(err); len(validationErrors) > 0 {
+			return utils.BadRequestResponse(c, "Validation failed", validationErrors)
+		}
+		return utils.BadRequestResponse(c, err.Error(), nil)
+	}
+
+	sessionID := ""
+	if claims := middlewares.GetClaimsFromContext(c); claims != nil {
+		sessionID = claims.SessionID
+	}
+
+	codes, err := h.twoFactorService.Confirm(middlewares.GetUserIDFromContext(c), sessionID, &req)
+	if err != nil {
+		return h.handleError(c, err, "Failed to enable two-factor authentication")
+	}
+
+	return utils.SuccessResponse(c, "Two-factor authentication enabled. Store the recovery codes in a safe place.", fiber.Map{
+		"recovery_codes": codes,
+	})
+}
+
+func (h *TwoFactorHandler) Disable(c *fiber.Ctx) error {
+	var req validators.DisableTwoFactorRequest
+	if err := validators.ParseAndValidate(c, &req); err != nil {
+		if validationErrors := validators.FormatValidationError(err); len(validationErrors) > 0 {
+			return utils.BadRequestResponse(c, "Validation failed", validationErrors)
+		}
+		return utils.BadRequestResponse(c, err.Error(), nil)
+	}
+
+	if err := h.twoFactorService.Disable(middlewares.GetUserIDFromContext(c), &req); err != nil {
+		return h.handleError(c, err, "Failed to disable two-factor authentication")
+	}
+
+	return utils.SuccessResponse(c, "Two-factor authentication disabled", nil)
+}
+
+func (h *TwoFactorHandler) RegenerateRecoveryCodes(c *fiber.Ctx) error {
+	var req validators.TwoFactorCodeRequest
+	if err := validators.ParseAndValidate(c, &req); err != nil {
+		if validationErrors := validators.FormatValidationError(err); len(validationErrors) > 0 {
+			return utils.BadRequestResponse(c, "Validation failed", validationErrors)
+		}
+		return utils.BadRequestResponse(c, err.Error(), nil)
+	}
+
+	codes, err := h.twoFactorService.RegenerateRecoveryCodes(middlewares.GetUserIDFromContext(c), &req)
+	if err != nil {
+		return h.handleError(c, err, "Failed to regenerate recovery codes")
+	}
+
+	return utils.SuccessResponse(c, "Recovery codes regenerated. Previous codes no longer work.", fiber.Map{
+		"recovery_codes": codes,
+	})
+}
+
+// GetPolicies menampilkan role yang wajib memakai 2FA (admin)
+func (h *TwoFactorHandler) GetPolicies(c *fiber.Ctx) error {
+	policies, err := h.twoFactorService.ListPolicies()
+	if err != nil {
+		return utils.InternalServerErrorResponse(c, "Failed to fetch two-factor policies")
+	}
+
+	return utils.SuccessResponse(c, "Two-factor policies retrieved successfully", policies)
+}
+
+// SetPolicy mewajibkan / membebaskan 2FA untuk sebuah role (admin)
+func (h *TwoFactorHandler) SetPolicy(c *fiber.Ctx) error {
+	var req validators.TwoFactorPolicyRequest
+	if err := validators.ParseAndValidate(c, &req); err != nil {
+		if validationErrors := validators.FormatValidationError(err); len(validationErrors) > 0 {
+			return utils.BadRequestResponse(c, "Validation failed", validationErrors)
+		}
+		return utils.BadRequestResponse(c, err.Error(), nil)
+	}
+
+	policy, err := h.twoFactorService.SetPolicy(middlewares.GetUserIDFromContext(c), &req)
+	if err != nil {
+		if err.Error() == "invalid role" {
+			return utils.BadRequestResponse(c, err.Error(), nil)
+		}
+		return utils.InternalServerErrorResponse(c, "Failed to update two-factor policy")
+	}
+
+	return utils.SuccessResponse(c, "Two-factor policy updated successfully", policy)
+}
+
+func (h *TwoFactorHandler) handleError(c *fiber.Ctx, err error, fallback string) error {
+	switch err.Error() {
+	case "user not found":
+		return utils.NotFoundResponse(c, err.Error())
+	case "password is incorrect", "invalid two-factor code":
+		return utils.BadRequestResponse(c, err.Error(), nil)
+	case "two-factor authentication already enabled",
+		"two-factor authentication not enabled",
+		"two-factor authentication not enrolled":
+		return utils.ConflictResponse(c, err.Error())
+	case "two-factor authentication is required for your role":
+		return utils.ForbiddenResponse(c, err.Error())
+	}
+	return utils.InternalServerErrorResponse(c, fallback)
+}
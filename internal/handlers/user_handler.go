@@ -1,8 +1,11 @@
 package handlers
 
 import (
+	"errors"
 	"strconv"
+	"strings"
 
+	"github.com/LutfiyaAinurrahmanP/boilerplate_fiber_restful_api/internal/authz"
 	"github.com/LutfiyaAinurrahmanP/boilerplate_fiber_restful_api/internal/middlewares"
 	"github.com/LutfiyaAinurrahmanP/boilerplate_fiber_restful_api/internal/services"
 	"github.com/LutfiyaAinurrahmanP/boilerplate_fiber_restful_api/internal/utils"
@@ -12,11 +15,13 @@ import (
 
 type UserHandler struct {
 	userService services.UserService
+	policy      *authz.Engine
 }
 
-func NewUserHandler(userService services.UserService) *UserHandler {
+func NewUserHandler(userService services.UserService, policy *authz.Engine) *UserHandler {
 	return &UserHandler{
 		userService: userService,
+		policy:      policy,
 	}
 }
 
@@ -29,7 +34,7 @@ func (h *UserHandler) CreateUser(c *fiber.Ctx) error {
 		return utils.BadRequestResponse(c, err.Error(), nil)
 	}
 
-	user, err := h.userService.CreateUser(&req)
+	user, err := h.organizationUsers(c).CreateUser(middlewares.GetRoleFromContext(c), &req)
 	if err != nil {
 		errorMessage := err.Error()
 
@@ -42,6 +47,9 @@ func (h *UserHandler) CreateUser(c *fiber.Ctx) error {
 		if errorMessage == "invalid role" {
 			return utils.BadRequestResponse(c, errorMessage, nil)
 		}
+		if errorMessage == "cannot assign a role above your own role" {
+			return utils.ForbiddenResponse(c, errorMessage)
+		}
 
 		return utils.InternalServerErrorResponse(c, "Failed to create user")
 	}
@@ -65,7 +73,27 @@ func (h *UserHandler) UpdateUser(c *fiber.Ctx) error {
 		return utils.BadRequestResponse(c, err.Error(), nil)
 	}
 
-	user, err := h.userService.UpdateUser(uint(id), &req)
+	users := h.organizationUsers(c)
+	user, err := users.GetUserByID(uint(id))
+	if err != nil {
+		if err.Error() == "user not found" {
+			return utils.NotFoundResponse(c, err.Error())
+		}
+		return utils.InternalServerErrorResponse(c, "Failed to fetch user")
+	}
+
+	subject := middlewares.GetSubjectFromContext(c)
+	if err := h.policy.Authorize(subject, authz.ActionUpdateUser, user); err != nil {
+		return h.policyError(c, err)
+	}
+	if req.Role != "" && req.Role != user.Role {
+		change := authz.RoleChange{User: user, OrganizationID: subject.OrganizationID, Role: req.Role}
+		if err := h.policy.Authorize(subject, authz.ActionChangeRole, change); err != nil {
+			return h.policyError(c, err)
+		}
+	}
+
+	user, err = users.UpdateUser(subject.Role, uint(id), &req)
 	if err != nil {
 		errorMessage := err.Error()
 
@@ -82,6 +110,13 @@ func (h *UserHandler) UpdateUser(c *fiber.Ctx) error {
 		if errorMessage == "invalid role" {
 			return utils.BadRequestResponse(c, errorMessage, nil)
 		}
+		if errorMessage == "user belongs to another organization" ||
+			errorMessage == "cannot assign a role above your own role" {
+			return utils.ForbiddenResponse(c, errorMessage)
+		}
+		if errorMessage == "cannot remove the last active admin" {
+			return utils.ConflictCodeResponse(c, utils.ErrorCodeLastAdmin, errorMessage)
+		}
 		return utils.InternalServerErrorResponse(c, "Failed to update user")
 	}
 
@@ -95,15 +130,28 @@ func (h *UserHandler) DeleteUser(c *fiber.Ctx) error {
 		return utils.BadRequestResponse(c, "Invalid user ID", nil)
 	}
 
-	currentUserID := middlewares.GetUserIDFromContext(c)
-	if currentUserID == uint(id) {
-		return utils.BadRequestResponse(c, "You cannot delete your own account", nil)
+	users := h.organizationUsers(c)
+	user, err := users.GetUserByID(uint(id))
+	if err != nil {
+		if err.Error() == "user not found" {
+			return utils.NotFoundResponse(c, err.Error())
+		}
+		return utils.InternalServerErrorResponse(c, "Failed to fetch user")
+	}
+	if err := h.policy.Authorize(middlewares.GetSubjectFromContext(c), authz.ActionDeleteUser, user); err != nil {
+		return h.policyError(c, err)
 	}
 
-	if err := h.userService.DeleteUser(uint(id)); err != nil {
+	if err := users.DeleteUser(uint(id)); err != nil {
 		if err.Error() == "user not found" {
 			return utils.NotFoundResponse(c, err.Error())
 		}
+		if err.Error() == "user belongs to another organization" {
+			return utils.ForbiddenResponse(c, err.Error())
+		}
+		if err.Error() == "cannot remove the last active admin" {
+			return utils.ConflictCodeResponse(c, utils.ErrorCodeLastAdmin, err.Error())
+		}
 		return utils.InternalServerErrorResponse(c, err.Error())
 	}
 	return utils.SuccessResponse(c, "User deleted successfully", nil)
@@ -115,12 +163,28 @@ func (h *UserHandler) HardDeleteUser(c *fiber.Ctx) error {
 		return utils.BadRequestResponse(c, "Invalid user ID", nil)
 	}
 
-	currentUserID := middlewares.GetUserIDFromContext(c)
-	if currentUserID == uint(id) {
-		return utils.BadRequestResponse(c, "You cannot delete your own account", nil)
+	users := h.organizationUsers(c)
+	user, err := users.GetUserByIDWithDeleted(uint(id))
+	if err != nil {
+		if err.Error() == "user not found" {
+			return utils.NotFoundResponse(c, err.Error())
+		}
+		return utils.InternalServerErrorResponse(c, "Failed to fetch user")
+	}
+	if err := h.policy.Authorize(middlewares.GetSubjectFromContext(c), authz.ActionHardDeleteUser, user); err != nil {
+		return h.policyError(c, err)
 	}
 
-	if err := h.userService.HardDeleteUser(uint(id)); err != nil {
+	if err := users.HardDeleteUser(uint(id)); err != nil {
+		if err.Error() == "user not found" {
+			return utils.NotFoundResponse(c, err.Error())
+		}
+		if err.Error() == "user belongs to another organization" {
+			return utils.ForbiddenResponse(c, err.Error())
+		}
+		if err.Error() == "cannot remove the last active admin" {
+			return utils.ConflictCodeResponse(c, utils.ErrorCodeLastAdmin, err.Error())
+		}
 		return utils.InternalServerErrorResponse(c, "Failed to permanetly delete user")
 	}
 
@@ -128,12 +192,18 @@ func (h *UserHandler) HardDeleteUser(c *fiber.Ctx) error {
 }
 
 func (h *UserHandler) RestoreUser(c *fiber.Ctx) error {
-	id, err := strconv.ParseInt(c.Params("id"), 10, 32)
+	id, err := strconv.ParseUint(c.Params("id"), 10, 32)
 	if err != nil {
 		return utils.BadRequestResponse(c, "Invalid user ID", nil)
 	}
 
-	if err := h.userService.RestoreUser(uint(id)); err != nil {
+	if err := h.organizationUsers(c).RestoreUser(uint(id)); err != nil {
+		if err.Error() == "user not found" {
+			return utils.NotFoundResponse(c, err.Error())
+		}
+		if err.Error() == "user belongs to another organization" {
+			return utils.ForbiddenResponse(c, err.Error())
+		}
 		return utils.InternalServerErrorResponse(c, "Failed to restore user")
 	}
 
@@ -146,13 +216,16 @@ func (h *UserHandler) GetUserByID(c *fiber.Ctx) error {
 		return utils.BadRequestResponse(c, "Invalid user Id", nil)
 	}
 
-	user, err := h.userService.GetUserByID(uint(id))
+	user, err := h.organizationUsers(c).GetUserByID(uint(id))
 	if err != nil {
 		if err.Error() == "user not found" {
 			return utils.NotFoundResponse(c, err.Error())
 		}
 		return utils.InternalServerErrorResponse(c, "Failed to fetch user")
 	}
+	if err := h.policy.Authorize(middlewares.GetSubjectFromContext(c), authz.ActionViewUser, user); err != nil {
+		return h.policyError(c, err)
+	}
 
 	return utils.SuccessResponse(c, "User retrieved successfully", fiber.Map{
 		"user": user,
@@ -172,7 +245,7 @@ func (h *UserHandler) GetAllUsers(c *fiber.Ctx) error {
 		}
 	}
 
-	users, meta, err := h.userService.GetAllUsers(&query)
+	users, meta, err := h.organizationUsers(c).GetAllUsers(&query)
 	if err != nil {
 		return utils.InternalServerErrorResponse(c, "Failed to fetch users")
 	}
@@ -195,7 +268,7 @@ func (h *UserHandler) GetAllDeletedUsers(c *fiber.Ctx) error {
 		}
 	}
 
-	users, meta, err := h.userService.GetAllDeletedUsers(&query)
+	users, meta, err := h.organizationUsers(c).GetAllDeletedUsers(&query)
 	if err != nil {
 		return utils.InternalServerErrorResponse(c, "Failed to fetch deleted users")
 	}
@@ -250,3 +323,56 @@ func (h *UserHandler) UpdateProfile(c *fiber.Ctx) error {
 		"profile": user,
 	})
 }
+
+func (h *UserHandler) ChangePassword(c *fiber.Ctx) error {
+	userId := middlewares.GetUserIDFromContext(c)
+	var req validators.ChangePasswordRequest
+
+	if err := validators.ParseAndValidate(c, &req); err != nil {
+		if validationErrors := validators.FormatValidationError(err); len(validationErrors) > 0 {
+			return utils.BadRequestResponse(c, "Validation failed", validationErrors)
+		}
+		return utils.BadRequestResponse(c, err.Error(), nil)
+	}
+
+	sessionID := ""
+	if claims := middlewares.GetClaimsFromContext(c); claims != nil {
+		sessionID = claims.SessionID
+	}
+
+	tokens, err := h.userService.ChangePassword(userId, sessionID, &req)
+	if err != nil {
+		errorMessage := err.Error()
+
+		if errorMessage == "user not found" {
+			return utils.NotFoundResponse(c, errorMessage)
+		}
+		if errorMessage == "old password is incorrect" ||
+			errorMessage == "old password is required" ||
+			errorMessage == "new password must be different from old password" ||
+			strings.HasPrefix(errorMessage, "password must") {
+			return utils.BadRequestResponse(c, errorMessage, nil)
+		}
+		return utils.InternalServerErrorResponse(c, "Failed to change password")
+	}
+
+	return utils.SuccessResponse(c, "Password changed successfully. Other sessions have been logged out.", tokens)
+}
+
+// organizationUsers men-scope endpoint admin ke organisasi aktif pemanggil
+func (h *UserHandler) organizationUsers(c *fiber.Ctx) services.UserService {
+	return h.userService.ForOrganization(middlewares.GetOrganizationIDFromContext(c))
+}
+
+// policyError menulis response untuk error dari policy engine: 403 jika ditolak
+// (409 untuk admin aktif terakhir, sama dengan service), 500 jika rule gagal dievaluasi
+func (h *UserHandler) policyError(c *fiber.Ctx, err error) error {
+	var denied *authz.DeniedError
+	if errors.As(err, &denied) {
+		if denied.Code == utils.ErrorCodeLastAdmin {
+			return utils.ConflictCodeResponse(c, denied.Code, denied.Message)
+		}
+		return utils.ForbiddenResponse(c, denied.Message)
+	}
+	return utils.InternalServerErrorResponse(c, "Failed to evaluate access policy")
+}
@@ -0,0 +1,54 @@
+package handlers
+
+import (
+	"github.com/LutfiyaAinurrahmanP/boilerplate_fiber_restful_api/internal/services"
+	"github.com/LutfiyaAinurrahmanP/boilerplate_fiber_restful_api/internal/utils"
+	"github.com/LutfiyaAinurrahmanP/boilerplate_fiber_restful_api/internal/validators"
+	"github.com/gofiber/fiber/v2"
+)
+
+type VerificationHandler struct {
+	verificationService services.VerificationService
+}
+
+func NewVerificationHandler(verificationService services.VerificationService) *VerificationHandler {
+	return &VerificationHandler{
+		verificationService: verificationService,
+	}
+}
+
+func (h *VerificationHandler) SendCode(c *fiber.Ctx) error {
+	var req validators.SendVerificationRequest
+	if err := validators.ParseAndValidate(c, &req); err != nil {
+		if validationErrors := validators.FormatValidationError(err); len(validationErrors) > 0 {
+			return utils.BadRequestResponse(c, "Validation failed", validationErrors)
+		}
+		return utils.BadRequestResponse(c, err.Error(), nil)
+	}
+
+	if err := h.verificationService.SendCode(&req); err != nil {
+		return utils.InternalServerErrorResponse(c, "Failed to send verification code")
+	}
+
+	// Response selalu sama, baik alamat terdaftar maupun tidak
+	return utils.SuccessResponse(c, "If the address is registered and not yet verified, a verification code has been sent", nil)
+}
+
+func (h *VerificationHandler) ConfirmCode(c *fiber.Ctx) error {
+	var req validators.ConfirmVerificationRequest
+	if err := validators.ParseAndValidate(c, &req); err != nil {
+		if validationErrors := validators.FormatValidationError(err); len(validationErrors) > 0 {
+			return utils.BadRequestResponse(c, "Validation failed", validationErrors)
+		}
+		return utils.BadRequestResponse(c, err.Error(), nil)
+	}
+
+	if err := h.verificationService.ConfirmCode(&req); err != nil {
+		if err.Error() == "invalid or expired verification code" {
+			return utils.BadRequestResponse(c, err.Error(), nil)
+		}
+		return utils.InternalServerErrorResponse(c, "Failed to verify code")
+	}
+
+	return utils.SuccessResponse(c, "Verification successful", nil)
+}
@@ -0,0 +1,45 @@
+package jwtauth
+
+import (
+	"errors"
+	"strconv"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+// Claims adalah isi access token yang dipakai bersama oleh service (issue) dan middleware (parse)
+type Claims struct {
+	jwt.RegisteredClaims
+	UserID    uint   `json:"uid"`
+	Username  string `json:"username"`
+	Role      string `json:"role"`
+	SessionID string `json:"sid"`
+	// TokenVersion harus sama dengan models.User.TokenVersion saat token dipakai
+	TokenVersion uint `json:"ver"`
+	// OrganizationID adalah tenant aktif, Role adalah role user di organisasi tersebut
+	OrganizationID uint `json:"org"`
+}
+
+// Validate dipanggil otomatis oleh jwt parser setelah validasi registered claims
+// Token dengan claim yang hilang atau tipenya salah ditolak di sini, bukan panic di handler
+func (c *Claims) Validate() error {
+	if c.UserID == 0 || c.Subject != strconv.FormatUint(uint64(c.UserID), 10) {
+		return errors.New("invalid subject claim")
+	}
+	if c.Username == "" {
+		return errors.New("missing username claim")
+	}
+	if c.Role == "" {
+		return errors.New("missing role claim")
+	}
+	if c.SessionID == "" {
+		return errors.New("missing session claim")
+	}
+	if c.OrganizationID == 0 {
+		return errors.New("missing organization claim")
+	}
+	if c.ID == "" {
+		return errors.New("missing jti claim")
+	}
+	return nil
+}
@@ -0,0 +1,245 @@
+package jwtauth
+
+import (
+	"crypto"
+	"crypto/ed25519"
+	"crypto/rsa"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math/big"
+	"os"
+	"sort"
+	"strings"
+
+	"github.com/LutfiyaAinurrahmanP/boilerplate_fiber_restful_api/internal/config"
+	"github.com/golang-jwt/jwt/v5"
+)
+
+// KeySet menyimpan key untuk signing token dan semua key yang boleh dipakai untuk verifikasi
+// Dengan lebih dari satu verification key, key bisa di-rotate tanpa downtime:
+// key baru dipakai untuk signing, key lama tetap dipakai verifikasi sampai token lama expired
+type KeySet struct {
+	method     jwt.SigningMethod
+	signingKey interface{}
+	signingKid string
+	verifyKeys map[string]interface{} // kid -> public key (atau secret untuk HMAC)
+}
+
+// JWK adalah representasi public key sesuai RFC 7517
+type JWK struct {
+	Kty string `json:"kty"`
+	Kid string `json:"kid"`
+	Use string `json:"use"`
+	Alg string `json:"alg"`
+	N   string `json:"n,omitempty"`
+	E   string `json:"e,omitempty"`
+	Crv string `json:"crv,omitempty"`
+	X   string `json:"x,omitempty"`
+}
+
+// JWKS adalah JSON Web Key Set yang dipublikasikan di /.well-known/jwks.json
+type JWKS struct {
+	Keys []JWK `json:"keys"`
+}
+
+// NewHMACKeySet membuat KeySet HS256 dari satu shared secret
+func NewHMACKeySet(secret string) *KeySet {
+	return &KeySet{
+		method:     jwt.SigningMethodHS256,
+		signingKey: []byte(secret),
+		verifyKeys: map[string]interface{}{"": []byte(secret)},
+	}
+}
+
+// LoadKeySet membuat KeySet sesuai JWT_ALGORITHM
+// HS256 memakai JWT_SECRET, RS256/EdDSA membaca private key dari JWT_SIGNING_KEY_FILE
+// dan public key tambahan (key lama yang masih berlaku) dari JWT_VERIFY_KEY_FILES
+func LoadKeySet(cfg *config.Config) (*KeySet, error) {
+	switch cfg.JWTAlgorithm {
+	case "", jwt.SigningMethodHS256.Alg():
+		if cfg.JWTSecret == "" {
+			return nil, errors.New("JWT_SECRET is required for HS256")
+		}
+		return NewHMACKeySet(cfg.JWTSecret), nil
+	case jwt.SigningMethodRS256.Alg(), jwt.SigningMethodEdDSA.Alg():
+	default:
+		return nil, fmt.Errorf("unsupported JWT algorithm: %s", cfg.JWTAlgorithm)
+	}
+
+	ks := &KeySet{
+		method:     jwt.GetSigningMethod(cfg.JWTAlgorithm),
+		verifyKeys: make(map[string]interface{}),
+	}
+
+	pemBytes, err := os.ReadFile(cfg.JWTSigningKeyFile)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read signing key: %w", err)
+	}
+
+	var publicKey crypto.PublicKey
+	if ks.method == jwt.SigningMethodRS256 {
+		privateKey, err := jwt.ParseRSAPrivateKeyFromPEM(pemBytes)
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse RSA signing key: %w", err)
+		}
+		ks.signingKey = privateKey
+		publicKey = &privateKey.PublicKey
+	} else {
+		privateKey, err := jwt.ParseEdPrivateKeyFromPEM(pemBytes)
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse Ed25519 signing key: %w", err)
+		}
+		ks.signingKey = privateKey
+		publicKey = privateKey.(ed25519.PrivateKey).Public()
+	}
+
+	ks.signingKid, err = Thumbprint(publicKey)
+	if err != nil {
+		return nil, err
+	}
+	ks.verifyKeys[ks.signingKid] = publicKey
+
+	for _, path := range strings.Split(cfg.JWTVerifyKeyFiles, ",") {
+		path = strings.TrimSpace(path)
+		if path == "" {
+			continue
+		}
+		if err := ks.addVerifyKeyFile(path); err != nil {
+			return nil, err
+		}
+	}
+
+	return ks, nil
+}
+
+func (k *KeySet) addVerifyKeyFile(path string) error {
+	pemBytes, err := os.ReadFile(path)
+	if err != nil {
+		return fmt.Errorf("failed to read verification key %s: %w", path, err)
+	}
+
+	var publicKey crypto.PublicKey
+	if k.method == jwt.SigningMethodRS256 {
+		publicKey, err = jwt.ParseRSAPublicKeyFromPEM(pemBytes)
+	} else {
+		publicKey, err = jwt.ParseEdPublicKeyFromPEM(pemBytes)
+	}
+	if err != nil {
+		return fmt.Errorf("failed to parse verification key %s: %w", path, err)
+	}
+
+	kid, err := Thumbprint(publicKey)
+	if err != nil {
+		return err
+	}
+	k.verifyKeys[kid] = publicKey
+	return nil
+}
+
+// Sign menandatangani claims dan menambahkan header "kid"
+func (k *KeySet) Sign(claims jwt.Claims) (string, error) {
+	return k.SignWithType(claims, "JWT")
+}
+
+// SignWithType sama seperti Sign dengan header "typ" tertentu (misalnya "at+jwt" untuk access token OAuth2)
+func (k *KeySet) SignWithType(claims jwt.Claims, typ string) (string, error) {
+	token := jwt.NewWithClaims(k.method, claims)
+	token.Header["typ"] = typ
+	if k.signingKid != "" {
+		token.Header["kid"] = k.signingKid
+	}
+	return token.SignedString(k.signingKey)
+}
+
+// Keyfunc memilih verification key berdasarkan header "kid"
+func (k *KeySet) Keyfunc(token *jwt.Token) (interface{}, error) {
+	if token.Method.Alg() != k.method.Alg() {
+		return nil, fmt.Errorf("unexpected signing method: %v", token.Header["alg"])
+	}
+
+	kid, _ := token.Header["kid"].(string)
+	key, ok := k.verifyKeys[kid]
+	if !ok {
+		return nil, fmt.Errorf("unknown signing key: %q", kid)
+	}
+	return key, nil
+}
+
+// ValidMethods dipakai sebagai parser option agar algoritma lain ditolak
+func (k *KeySet) ValidMethods() []string {
+	return []string{k.method.Alg()}
+}
+
+// JWKS mengembalikan semua public verification key
+// Untuk HMAC hasilnya kosong karena secret tidak boleh dipublikasikan
+func (k *KeySet) JWKS() JWKS {
+	jwks := JWKS{Keys: []JWK{}}
+	for kid, key := range k.verifyKeys {
+		switch pub := key.(type) {
+		case *rsa.PublicKey:
+			jwks.Keys = append(jwks.Keys, JWK{
+				Kty: "RSA",
+				Kid: kid,
+				Use: "sig",
+				Alg: k.method.Alg(),
+				N:   base64.RawURLEncoding.EncodeToString(pub.N.Bytes()),
+				E:   base64.RawURLEncoding.EncodeToString(big.NewInt(int64(pub.E)).Bytes()),
+			})
+		case ed25519.PublicKey:
+			jwks.Keys = append(jwks.Keys, JWK{
+				Kty: "OKP",
+				Kid: kid,
+				Use: "sig",
+				Alg: k.method.Alg(),
+				Crv: "Ed25519",
+				X:   base64.RawURLEncoding.EncodeToString(pub),
+			})
+		}
+	}
+
+	// Urutan stabil agar response bisa di-cache
+	sort.Slice(jwks.Keys, func(i, j int) bool {
+		return jwks.Keys[i].Kid < jwks.Keys[j].Kid
+	})
+	return jwks
+}
+
+// Thumbprint menghitung JWK thumbprint (RFC 7638) yang dipakai sebagai "kid"
+func Thumbprint(key crypto.PublicKey) (string, error) {
+	var members interface{}
+	switch pub := key.(type) {
+	case *rsa.PublicKey:
+		// Urutan field harus leksikografis sesuai RFC 7638
+		members = struct {
+			E   string `json:"e"`
+			Kty string `json:"kty"`
+			N   string `json:"n"`
+		}{
+			E:   base64.RawURLEncoding.EncodeToString(big.NewInt(int64(pub.E)).Bytes()),
+			Kty: "RSA",
+			N:   base64.RawURLEncoding.EncodeToString(pub.N.Bytes()),
+		}
+	case ed25519.PublicKey:
+		members = struct {
+			Crv string `json:"crv"`
+			Kty string `json:"kty"`
+			X   string `json:"x"`
+		}{
+			Crv: "Ed25519",
+			Kty: "OKP",
+			X:   base64.RawURLEncoding.EncodeToString(pub),
+		}
+	default:
+		return "", fmt.Errorf("unsupported public key type %T", key)
+	}
+
+	b, err := json.Marshal(members)
+	if err != nil {
+		return "", err
+	}
+	sum := sha256.Sum256(b)
+	return base64.RawURLEncoding.EncodeToString(sum[:]), nil
+}
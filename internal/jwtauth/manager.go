@@ -0,0 +1,87 @@
+package jwtauth
+
+import (
+	"errors"
+	"strconv"
+	"time"
+
+	"github.com/LutfiyaAinurrahmanP/boilerplate_fiber_restful_api/internal/config"
+	"github.com/LutfiyaAinurrahmanP/boilerplate_fiber_restful_api/internal/models"
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/google/uuid"
+)
+
+// Manager adalah satu-satunya tempat token dibuat dan di-parse
+type Manager struct {
+	cfg           *config.Config
+	keySet        *KeySet
+	parserOptions []jwt.ParserOption
+}
+
+func NewManager(cfg *config.Config, keySet *KeySet) *Manager {
+	// Aturan validasi claim: exp wajib, iat tidak boleh di masa depan,
+	// iss dan aud harus sesuai konfigurasi deployment ini
+	parserOptions := []jwt.ParserOption{
+		jwt.WithValidMethods(keySet.ValidMethods()),
+		jwt.WithExpirationRequired(),
+		jwt.WithIssuedAt(),
+		jwt.WithLeeway(cfg.JWTLeeway),
+	}
+	if cfg.JWTIssuer != "" {
+		parserOptions = append(parserOptions, jwt.WithIssuer(cfg.JWTIssuer))
+	}
+	if cfg.JWTAudience != "" {
+		parserOptions = append(parserOptions, jwt.WithAudience(cfg.JWTAudience))
+	}
+
+	return &Manager{
+		cfg:           cfg,
+		keySet:        keySet,
+		parserOptions: parserOptions,
+	}
+}
+
+// Issue membuat access token untuk user dalam sesi tertentu
+// Role dan organisasi diambil dari keanggotaan user di organisasi aktif session
+func (m *Manager) Issue(user *models.User, member *models.OrganizationMember, sessionID string, issuedAt time.Time) (string, *Claims, error) {
+	claims := &Claims{
+		RegisteredClaims: jwt.RegisteredClaims{
+			ID:        uuid.NewString(),
+			Subject:   strconv.FormatUint(uint64(user.ID), 10),
+			Issuer:    m.cfg.JWTIssuer,
+			IssuedAt:  jwt.NewNumericDate(issuedAt),
+			NotBefore: jwt.NewNumericDate(issuedAt),
+			ExpiresAt: jwt.NewNumericDate(issuedAt.Add(m.cfg.JWTExpire)),
+		},
+		UserID:         user.ID,
+		Username:       user.Username,
+		Role:           member.Role,
+		SessionID:      sessionID,
+		TokenVersion:   user.TokenVersion,
+		OrganizationID: member.OrganizationID,
+	}
+	if m.cfg.JWTAudience != "" {
+		claims.Audience = jwt.ClaimStrings{m.cfg.JWTAudience}
+	}
+
+	tokenString, err := m.keySet.Sign(claims)
+	if err != nil {
+		return "", nil, err
+	}
+	return tokenString, claims, nil
+}
+
+// Parse memverifikasi signature dan semua claim, lalu mengembalikan Claims bertipe
+func (m *Manager) Parse(tokenString string) (*Claims, error) {
+	claims := &Claims{}
+	token, err := jwt.ParseWithClaims(tokenString, claims, m.keySet.Keyfunc, m.parserOptions...)
+	if err != nil {
+		return nil, err
+	}
+
+	if !token.Valid {
+		return nil, errors.New("invalid token")
+	}
+
+	return claims, nil
+}
@@ -0,0 +1,447 @@
+package jwtauth
+
+import (
+	"crypto/ed25519"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/pem"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/LutfiyaAinurrahmanP/boilerplate_fiber_restful_api/internal/config"
+	"github.com/LutfiyaAinurrahmanP/boilerplate_fiber_restful_api/internal/models"
+	"github.com/golang-jwt/jwt/v5"
+)
+
+func testConfig() *config.Config {
+	return &config.Config{
+		JWTSecret:   "test-secret",
+		JWTExpire:   15 * time.Minute,
+		JWTIssuer:   "test-issuer",
+		JWTAudience: "test-audience",
+	}
+}
+
+func testUser() (*models.User, *models.OrganizationMember) {
+	user := &models.User{Username: "john", TokenVersion: 3}
+	user.ID = 42
+	return user, &models.OrganizationMember{OrganizationID: 7, UserID: 42, Role: models.RoleAdmin}
+}
+
+// testClaims membuat claims valid untuk token yang ditandatangani manual di test
+func testClaims(user *models.User, member *models.OrganizationMember) *Claims {
+	now := time.Now()
+	return &Claims{
+		RegisteredClaims: jwt.RegisteredClaims{
+			ID:        "jti",
+			Subject:   "42",
+			Issuer:    "test-issuer",
+			Audience:  jwt.ClaimStrings{"test-audience"},
+			IssuedAt:  jwt.NewNumericDate(now),
+			ExpiresAt: jwt.NewNumericDate(now.Add(time.Minute)),
+		},
+		UserID:         user.ID,
+		Username:       user.Username,
+		Role:           member.Role,
+		SessionID:      "session-1",
+		OrganizationID: member.OrganizationID,
+	}
+}
+
+// writeKeyFiles menulis private key (PKCS#8) dan public key (PKIX) ke file PEM sementara
+func writeKeyFiles(t *testing.T, privateKey interface{}, publicKey interface{}) (string, string) {
+	t.Helper()
+	dir := t.TempDir()
+
+	privateDER, err := x509.MarshalPKCS8PrivateKey(privateKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+	publicDER, err := x509.MarshalPKIXPublicKey(publicKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	privatePath := filepath.Join(dir, "private.pem")
+	publicPath := filepath.Join(dir, "public.pem")
+	if err := os.WriteFile(privatePath, pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: privateDER}), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(publicPath, pem.EncodeToMemory(&pem.Block{Type: "PUBLIC KEY", Bytes: publicDER}), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	return privatePath, publicPath
+}
+
+func newRSAKeyFiles(t *testing.T) (string, string) {
+	t.Helper()
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return writeKeyFiles(t, key, &key.PublicKey)
+}
+
+func newEdKeyFiles(t *testing.T) (string, string) {
+	t.Helper()
+	publicKey, privateKey, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return writeKeyFiles(t, privateKey, publicKey)
+}
+
+func newManager(t *testing.T, cfg *config.Config) *Manager {
+	t.Helper()
+	keySet, err := LoadKeySet(cfg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return NewManager(cfg, keySet)
+}
+
+func TestIssueAndParse(t *testing.T) {
+	rsaPrivate, _ := newRSAKeyFiles(t)
+	edPrivate, _ := newEdKeyFiles(t)
+
+	tests := []struct {
+		name      string
+		algorithm string
+		keyFile   string
+		wantKid   bool
+	}{
+		{"HS256", "", "", false},
+		{"RS256", "RS256", rsaPrivate, true},
+		{"EdDSA", "EdDSA", edPrivate, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := testConfig()
+			cfg.JWTAlgorithm = tt.algorithm
+			cfg.JWTSigningKeyFile = tt.keyFile
+			manager := newManager(t, cfg)
+
+			user, member := testUser()
+			tokenString, issued, err := manager.Issue(user, member, "session-1", time.Now())
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			claims, err := manager.Parse(tokenString)
+			if err != nil {
+				t.Fatalf("Parse() error: %v", err)
+			}
+			if claims.UserID != 42 || claims.Subject != "42" || claims.Username != "john" {
+				t.Errorf("unexpected user claims: %+v", claims)
+			}
+			if claims.Role != models.RoleAdmin || claims.OrganizationID != 7 {
+				t.Errorf("unexpected organization claims: role=%s org=%d", claims.Role, claims.OrganizationID)
+			}
+			if claims.SessionID != "session-1" || claims.TokenVersion != 3 || claims.ID != issued.ID {
+				t.Errorf("unexpected session claims: %+v", claims)
+			}
+
+			token, _, err := jwt.NewParser().ParseUnverified(tokenString, &Claims{})
+			if err != nil {
+				t.Fatal(err)
+			}
+			if _, hasKid := token.Header["kid"]; hasKid != tt.wantKid {
+				t.Errorf("kid header present = %v, want %v", hasKid, tt.wantKid)
+			}
+			if token.Header["typ"] != "JWT" {
+				t.Errorf("typ header = %v, want JWT", token.Header["typ"])
+			}
+		})
+	}
+}
+
+func TestParseRejectsInvalidTokens(t *testing.T) {
+	cfg := testConfig()
+	manager := newManager(t, cfg)
+	user, member := testUser()
+
+	issue := func(mutate func(cfg *config.Config)) string {
+		other := testConfig()
+		if mutate != nil {
+			mutate(other)
+		}
+		tokenString, _, err := newManager(t, other).Issue(user, member, "session-1", time.Now())
+		if err != nil {
+			t.Fatal(err)
+		}
+		return tokenString
+	}
+
+	valid := issue(nil)
+	expired, _, err := manager.Issue(user, member, "session-1", time.Now().Add(-time.Hour))
+	if err != nil {
+		t.Fatal(err)
+	}
+	future, _, err := manager.Issue(user, member, "session-1", time.Now().Add(time.Hour))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Token tanpa signature (alg none) harus ditolak walaupun claim-nya valid
+	unsigned, err := jwt.NewWithClaims(jwt.SigningMethodNone, testClaims(user, member)).SignedString(jwt.UnsafeAllowNoneSignatureType)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	rsaPrivate, _ := newRSAKeyFiles(t)
+
+	tests := []struct {
+		name  string
+		token string
+	}{
+		{"tampered payload", valid[:len(valid)-4] + "AAAA"},
+		{"wrong secret", issue(func(c *config.Config) { c.JWTSecret = "other-secret" })},
+		{"wrong issuer", issue(func(c *config.Config) { c.JWTIssuer = "other-issuer" })},
+		{"wrong audience", issue(func(c *config.Config) { c.JWTAudience = "other-audience" })},
+		{"wrong algorithm", issue(func(c *config.Config) {
+			c.JWTAlgorithm = "RS256"
+			c.JWTSigningKeyFile = rsaPrivate
+		})},
+		{"alg none", unsigned},
+		{"expired", expired},
+		{"issued in the future", future},
+		{"not a jwt", "not-a-jwt"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := manager.Parse(tt.token); err == nil {
+				t.Error("expected Parse() to fail")
+			}
+		})
+	}
+
+	if _, err := manager.Parse(valid); err != nil {
+		t.Errorf("valid token rejected: %v", err)
+	}
+}
+
+func TestParseKeyRotation(t *testing.T) {
+	oldPrivate, oldPublic := newRSAKeyFiles(t)
+	newPrivate, _ := newRSAKeyFiles(t)
+	otherPrivate, _ := newRSAKeyFiles(t)
+	user, member := testUser()
+
+	issueWith := func(keyFile string) string {
+		cfg := testConfig()
+		cfg.JWTAlgorithm = "RS256"
+		cfg.JWTSigningKeyFile = keyFile
+		tokenString, _, err := newManager(t, cfg).Issue(user, member, "session-1", time.Now())
+		if err != nil {
+			t.Fatal(err)
+		}
+		return tokenString
+	}
+
+	// Key baru dipakai signing, key lama masih diterima untuk verifikasi
+	cfg := testConfig()
+	cfg.JWTAlgorithm = "RS256"
+	cfg.JWTSigningKeyFile = newPrivate
+	cfg.JWTVerifyKeyFiles = " " + oldPublic + " ,"
+	manager := newManager(t, cfg)
+
+	tests := []struct {
+		name    string
+		token   string
+		wantErr bool
+	}{
+		{"signed with new key", issueWith(newPrivate), false},
+		{"signed with old key", issueWith(oldPrivate), false},
+		{"signed with unknown key", issueWith(otherPrivate), true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := manager.Parse(tt.token)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("Parse() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+
+	// Algorithm confusion: HS256 dengan public key sebagai secret harus ditolak
+	publicPEM, err := os.ReadFile(oldPublic)
+	if err != nil {
+		t.Fatal(err)
+	}
+	claims := testClaims(user, member)
+	confused, err := jwt.NewWithClaims(jwt.SigningMethodHS256, claims).SignedString(publicPEM)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := manager.Parse(confused); err == nil {
+		t.Error("HS256 token signed with the RSA public key was accepted")
+	}
+
+	if keys := manager.keySet.JWKS().Keys; len(keys) != 2 {
+		t.Errorf("JWKS has %d keys, want 2", len(keys))
+	}
+}
+
+func TestOAuthAndFirstPartyTokensAreNotInterchangeable(t *testing.T) {
+	manager := newManager(t, testConfig())
+	user, member := testUser()
+
+	firstParty, _, err := manager.Issue(user, member, "session-1", time.Now())
+	if err != nil {
+		t.Fatal(err)
+	}
+	oauthToken, _, err := manager.IssueOAuthAccessToken(user, "client-1", "openid profile", time.Minute, time.Now())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := manager.Parse(oauthToken); err == nil {
+		t.Error("OAuth access token accepted as first-party token")
+	}
+	if _, err := manager.ParseOAuthAccessToken(firstParty); err == nil {
+		t.Error("first-party token accepted as OAuth access token")
+	}
+
+	claims, err := manager.ParseOAuthAccessToken(oauthToken)
+	if err != nil {
+		t.Fatalf("ParseOAuthAccessToken() error: %v", err)
+	}
+	if claims.ClientID != "client-1" || claims.Subject != "42" || claims.Username != "john" || claims.Scope != "openid profile" {
+		t.Errorf("unexpected OAuth claims: %+v", claims)
+	}
+
+	// client_credentials: subject adalah client_id
+	clientToken, _, err := manager.IssueOAuthAccessToken(nil, "client-1", "", time.Minute, time.Now())
+	if err != nil {
+		t.Fatal(err)
+	}
+	claims, err = manager.ParseOAuthAccessToken(clientToken)
+	if err != nil {
+		t.Fatalf("ParseOAuthAccessToken() error: %v", err)
+	}
+	if claims.Subject != "client-1" || claims.Username != "" {
+		t.Errorf("unexpected client_credentials claims: %+v", claims)
+	}
+}
+
+func TestClaimsValidate(t *testing.T) {
+	valid := func() *Claims {
+		return &Claims{
+			RegisteredClaims: jwt.RegisteredClaims{ID: "jti", Subject: "42"},
+			UserID:           42,
+			Username:         "john",
+			Role:             models.RoleUser,
+			SessionID:        "session-1",
+			OrganizationID:   1,
+		}
+	}
+
+	tests := []struct {
+		name    string
+		mutate  func(c *Claims)
+		wantErr string
+	}{
+		{"valid", func(c *Claims) {}, ""},
+		{"missing user id", func(c *Claims) { c.UserID = 0 }, "invalid subject claim"},
+		{"subject mismatch", func(c *Claims) { c.Subject = "43" }, "invalid subject claim"},
+		{"missing username", func(c *Claims) { c.Username = "" }, "missing username claim"},
+		{"missing role", func(c *Claims) { c.Role = "" }, "missing role claim"},
+		{"missing session", func(c *Claims) { c.SessionID = "" }, "missing session claim"},
+		{"missing organization", func(c *Claims) { c.OrganizationID = 0 }, "missing organization claim"},
+		{"missing jti", func(c *Claims) { c.ID = "" }, "missing jti claim"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			claims := valid()
+			tt.mutate(claims)
+			err := claims.Validate()
+			if tt.wantErr == "" {
+				if err != nil {
+					t.Errorf("Validate() error = %v", err)
+				}
+				return
+			}
+			if err == nil || err.Error() != tt.wantErr {
+				t.Errorf("Validate() error = %v, want %s", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestLoadKeySetErrors(t *testing.T) {
+	rsaPrivate, _ := newRSAKeyFiles(t)
+	edPrivate, _ := newEdKeyFiles(t)
+
+	tests := []struct {
+		name string
+		cfg  *config.Config
+	}{
+		{"HS256 without secret", &config.Config{}},
+		{"unsupported algorithm", &config.Config{JWTAlgorithm: "HS512", JWTSecret: "secret"}},
+		{"missing key file", &config.Config{JWTAlgorithm: "RS256", JWTSigningKeyFile: filepath.Join(t.TempDir(), "missing.pem")}},
+		{"RS256 with Ed25519 key", &config.Config{JWTAlgorithm: "RS256", JWTSigningKeyFile: edPrivate}},
+		{"EdDSA with RSA key", &config.Config{JWTAlgorithm: "EdDSA", JWTSigningKeyFile: rsaPrivate}},
+		{"missing verify key file", &config.Config{JWTAlgorithm: "RS256", JWTSigningKeyFile: rsaPrivate, JWTVerifyKeyFiles: filepath.Join(t.TempDir(), "missing.pem")}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := LoadKeySet(tt.cfg); err == nil {
+				t.Error("expected LoadKeySet() to fail")
+			}
+		})
+	}
+}
+
+func TestJWKS(t *testing.T) {
+	if keys := NewHMACKeySet("secret").JWKS().Keys; len(keys) != 0 {
+		t.Errorf("HMAC JWKS has %d keys, want 0", len(keys))
+	}
+
+	rsaPrivate, _ := newRSAKeyFiles(t)
+	edPrivate, _ := newEdKeyFiles(t)
+
+	tests := []struct {
+		name      string
+		algorithm string
+		keyFile   string
+		wantKty   string
+	}{
+		{"RS256", "RS256", rsaPrivate, "RSA"},
+		{"EdDSA", "EdDSA", edPrivate, "OKP"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			keySet, err := LoadKeySet(&config.Config{JWTAlgorithm: tt.algorithm, JWTSigningKeyFile: tt.keyFile})
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			keys := keySet.JWKS().Keys
+			if len(keys) != 1 {
+				t.Fatalf("JWKS has %d keys, want 1", len(keys))
+			}
+			key := keys[0]
+			if key.Kty != tt.wantKty || key.Alg != tt.algorithm || key.Use != "sig" || key.Kid != keySet.signingKid {
+				t.Errorf("unexpected JWK: %+v", key)
+			}
+			if strings.ContainsAny(key.Kid, "+/=") {
+				t.Errorf("kid %q is not base64url without padding", key.Kid)
+			}
+		})
+	}
+}
+
+func TestThumbprintUnsupportedKey(t *testing.T) {
+	if _, err := Thumbprint("not a key"); err == nil {
+		t.Error("expected error for unsupported key type")
+	}
+}
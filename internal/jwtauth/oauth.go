@@ -0,0 +1,121 @@
+package jwtauth
+
+import (
+	"errors"
+	"strconv"
+	"time"
+
+	"github.com/LutfiyaAinurrahmanP/boilerplate_fiber_restful_api/internal/models"
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/google/uuid"
+)
+
+// OAuthClaims adalah isi access token OAuth2 yang diterbitkan untuk aplikasi lain (RFC 9068)
+// Token ini tidak punya claim "sid" sehingga selalu ditolak JWTAuthMiddleware (bukan token login first-party)
+type OAuthClaims struct {
+	jwt.RegisteredClaims
+	ClientID string `json:"client_id"`
+	Scope    string `json:"scope,omitempty"`
+	Username string `json:"username,omitempty"` // Kosong untuk grant client_credentials
+}
+
+func (c *OAuthClaims) Validate() error {
+	if c.ClientID == "" {
+		return errors.New("missing client_id claim")
+	}
+	if c.Subject == "" {
+		return errors.New("missing subject claim")
+	}
+	if c.ID == "" {
+		return errors.New("missing jti claim")
+	}
+	return nil
+}
+
+// IDTokenClaims adalah isi ID token OpenID Connect untuk aplikasi yang meminta scope openid
+type IDTokenClaims struct {
+	jwt.RegisteredClaims
+	Nonce             string `json:"nonce,omitempty"`
+	PreferredUsername string `json:"preferred_username,omitempty"`
+	Email             string `json:"email,omitempty"`
+	EmailVerified     *bool  `json:"email_verified,omitempty"`
+}
+
+// IssueOAuthAccessToken membuat access token untuk client OAuth2
+// user nil untuk grant client_credentials (subject = client_id)
+func (m *Manager) IssueOAuthAccessToken(user *models.User, clientID, scope string, ttl time.Duration, issuedAt time.Time) (string, *OAuthClaims, error) {
+	claims := &OAuthClaims{
+		RegisteredClaims: jwt.RegisteredClaims{
+			ID:        uuid.NewString(),
+			Subject:   clientID,
+			Issuer:    m.cfg.JWTIssuer,
+			Audience:  jwt.ClaimStrings{clientID},
+			IssuedAt:  jwt.NewNumericDate(issuedAt),
+			NotBefore: jwt.NewNumericDate(issuedAt),
+			ExpiresAt: jwt.NewNumericDate(issuedAt.Add(ttl)),
+		},
+		ClientID: clientID,
+		Scope:    scope,
+	}
+	if user != nil {
+		claims.Subject = strconv.FormatUint(uint64(user.ID), 10)
+		claims.Username = user.Username
+	}
+
+	tokenString, err := m.keySet.SignWithType(claims, "at+jwt")
+	if err != nil {
+		return "", nil, err
+	}
+	return tokenString, claims, nil
+}
+
+// IssueIDToken membuat ID token OpenID Connect untuk user yang login lewat authorization code
+// Claim profile / email hanya diisi jika scope-nya diminta
+func (m *Manager) IssueIDToken(user *models.User, clientID, nonce string, scopes []string, ttl time.Duration, issuedAt time.Time) (string, error) {
+	claims := &IDTokenClaims{
+		RegisteredClaims: jwt.RegisteredClaims{
+			Subject:   strconv.FormatUint(uint64(user.ID), 10),
+			Issuer:    m.cfg.JWTIssuer,
+			Audience:  jwt.ClaimStrings{clientID},
+			IssuedAt:  jwt.NewNumericDate(issuedAt),
+			ExpiresAt: jwt.NewNumericDate(issuedAt.Add(ttl)),
+		},
+		Nonce: nonce,
+	}
+	for _, scope := range scopes {
+		switch scope {
+		case "profile":
+			claims.PreferredUsername = user.Username
+		case "email":
+			verified := user.EmailVerifiedAt != nil
+			claims.Email = user.Email
+			claims.EmailVerified = &verified
+		}
+	}
+
+	return m.keySet.Sign(claims)
+}
+
+// ParseOAuthAccessToken memverifikasi access token OAuth2 (signature, iss, exp, iat)
+// Audience tidak dicek karena berisi client_id pemilik token
+func (m *Manager) ParseOAuthAccessToken(tokenString string) (*OAuthClaims, error) {
+	options := []jwt.ParserOption{
+		jwt.WithValidMethods(m.keySet.ValidMethods()),
+		jwt.WithExpirationRequired(),
+		jwt.WithIssuedAt(),
+		jwt.WithLeeway(m.cfg.JWTLeeway),
+	}
+	if m.cfg.JWTIssuer != "" {
+		options = append(options, jwt.WithIssuer(m.cfg.JWTIssuer))
+	}
+
+	claims := &OAuthClaims{}
+	token, err := jwt.ParseWithClaims(tokenString, claims, m.keySet.Keyfunc, options...)
+	if err != nil {
+		return nil, err
+	}
+	if !token.Valid || token.Header["typ"] != "at+jwt" {
+		return nil, errors.New("invalid token")
+	}
+	return claims, nil
+}
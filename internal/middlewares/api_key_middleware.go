@@ -0,0 +1,134 @@
+package middlewares
+
+import (
+	"errors"
+	"strings"
+	"time"
+
+	"github.com/LutfiyaAinurrahmanP/boilerplate_fiber_restful_api/internal/models"
+	"github.com/LutfiyaAinurrahmanP/boilerplate_fiber_restful_api/internal/repositories"
+	"github.com/LutfiyaAinurrahmanP/boilerplate_fiber_restful_api/internal/utils"
+	"github.com/gofiber/fiber/v2"
+	"gorm.io/gorm"
+)
+
+// apiKeyTouchInterval membatasi penulisan last_used_at maksimal sekali per menit per key
+const apiKeyTouchInterval = time.Minute
+
+// APIKeyAuthMiddleware mengautentikasi request dengan API key
+// Format: "Authorization: ApiKey <key>" atau "X-API-Key: <key>"
+// Locals yang di-set sama dengan JWTAuthMiddleware (userID, username, role, organizationID) ditambah apiKey
+// API key selalu berlaku di organisasi asal pemiliknya dengan role users.role
+func APIKeyAuthMiddleware(apiKeyRepo repositories.APIKeyRepository, userRepo repositories.UserRepository) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		secret := extractAPIKey(c)
+		if secret == "" {
+			return utils.UnauthorizedResponse(c, "Missing API key")
+		}
+
+		key, err := apiKeyRepo.FindByHash(utils.HashToken(secret))
+		if err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return utils.UnauthorizedResponse(c, "Invalid API key")
+			}
+			return utils.InternalServerErrorResponse(c, "Failed to verify API key")
+		}
+
+		if !key.IsActive() {
+			return utils.UnauthorizedResponse(c, "API key has expired or been revoked")
+		}
+
+		// Role dibaca ulang dari user, perubahan role langsung berlaku untuk semua key
+		user, err := userRepo.FindById(key.UserID)
+		if err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return utils.UnauthorizedResponse(c, "User no longer exists")
+			}
+			return utils.InternalServerErrorResponse(c, "Failed to verify user")
+		}
+
+		if err := apiKeyRepo.TouchUsage(key.ID, c.IP(), apiKeyTouchInterval); err != nil {
+			return utils.InternalServerErrorResponse(c, "Failed to update API key")
+		}
+
+		c.Locals("userID", user.ID)
+		c.Locals("username", user.Username)
+		c.Locals("role", user.Role)
+		c.Locals("organizationID", user.OrganizationID)
+		c.Locals("apiKey", key)
+		// Status 2FA mengikuti session yang membuat key, key lama / tanpa 2FA ditolak jika role kemudian diwajibkan 2FA
+		c.Locals("mfaVerified", key.MFAVerified)
+
+		return c.Next()
+	}
+}
+
+// JWTOrAPIKeyMiddleware memakai APIKeyAuthMiddleware jika request membawa API key, selain itu JWTAuthMiddleware
+func JWTOrAPIKeyMiddleware(jwtAuth, apiKeyAuth fiber.Handler) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		if extractAPIKey(c) != "" {
+			return apiKeyAuth(c)
+		}
+		return jwtAuth(c)
+	}
+}
+
+// RequireAPIKeyScope memastikan API key punya scope tertentu, request dengan JWT selalu lolos
+func RequireAPIKeyScope(scope string) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		if key := GetAPIKeyFromContext(c); key != nil && !key.HasScope(scope) {
+			return utils.ForbiddenResponse(c, "Forbidden: API key is missing the "+scope+" scope")
+		}
+		return c.Next()
+	}
+}
+
+// RequireAPIKeyMethodScope mewajibkan scope read untuk GET / HEAD dan scope write untuk method lain
+func RequireAPIKeyMethodScope() fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		key := GetAPIKeyFromContext(c)
+		if key == nil {
+			return c.Next()
+		}
+
+		scope := models.APIKeyScopeWrite
+		if c.Method() == fiber.MethodGet || c.Method() == fiber.MethodHead {
+			scope = models.APIKeyScopeRead
+		}
+		if !key.HasScope(scope) {
+			return utils.ForbiddenResponse(c, "Forbidden: API key is missing the "+scope+" scope")
+		}
+		return c.Next()
+	}
+}
+
+// RequireSession menolak request yang diautentikasi dengan API key
+// Dipakai untuk endpoint yang butuh session login, misalnya ganti password
+func RequireSession() fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		if GetAPIKeyFromContext(c) != nil {
+			return utils.ForbiddenResponse(c, "Forbidden: This endpoint cannot be used with an API key")
+		}
+		return c.Next()
+	}
+}
+
+// GetAPIKeyFromContext mengambil API key dari context, nil jika request memakai JWT
+func GetAPIKeyFromContext(c *fiber.Ctx) *models.APIKey {
+	key, ok := c.Locals("apiKey").(*models.APIKey)
+	if !ok {
+		return nil
+	}
+	return key
+}
+
+func extractAPIKey(c *fiber.Ctx) string {
+	if key := strings.TrimSpace(c.Get("X-API-Key")); key != "" {
+		return key
+	}
+	parts := strings.SplitN(c.Get("Authorization"), " ", 2)
+	if len(parts) == 2 && strings.EqualFold(parts[0], "ApiKey") {
+		return strings.TrimSpace(parts[1])
+	}
+	return ""
+}
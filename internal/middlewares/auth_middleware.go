@@ -1,15 +1,17 @@
 package middlewares
 
 import (
+	"errors"
 	"strings"
 
+	"github.com/LutfiyaAinurrahmanP/boilerplate_fiber_restful_api/internal/jwtauth"
 	"github.com/LutfiyaAinurrahmanP/boilerplate_fiber_restful_api/internal/repositories"
 	"github.com/LutfiyaAinurrahmanP/boilerplate_fiber_restful_api/internal/utils"
 	"github.com/gofiber/fiber/v2"
-	"github.com/golang-jwt/jwt/v5"
+	"gorm.io/gorm"
 )
 
-func JWTAuthMiddleware(jwtSecret string, tokenRepo repositories.TokenRepository) fiber.Handler {
+func JWTAuthMiddleware(tokenManager *jwtauth.Manager, tokenRepo repositories.TokenRepository, sessionRepo repositories.SessionRepository, userRepo repositories.UserRepository) fiber.Handler {
 	return func(c *fiber.Ctx) error {
 		// 1. Get token dari Authorization header
 		// Format: "Bearer <token>"
@@ -27,7 +29,7 @@ func JWTAuthMiddleware(jwtSecret string, tokenRepo repositories.TokenRepository)
 		tokenString := parts[1]
 
 		// Check token blacklist (sudah logout)
-		isBlacklisted, err := tokenRepo.IsBlacklisted(tokenString)
+		isBlacklisted, err := tokenRepo.IsBlacklisted(utils.HashToken(tokenString))
 		if err != nil {
 			return utils.InternalServerErrorResponse(c, "Failed to verify token")
 		}
@@ -36,36 +38,51 @@ func JWTAuthMiddleware(jwtSecret string, tokenRepo repositories.TokenRepository)
 			return utils.UnauthorizedResponse(c, "Token has been revoked. Please login again.")
 		}
 
-		// 4. Parse dan validate token
-		token, err := jwt.Parse(tokenString, func(t *jwt.Token) (interface{}, error) {
-			if _, ok := t.Method.(*jwt.SigningMethodHMAC); !ok {
-				return nil, fiber.NewError(fiber.StatusUnauthorized, "Invalid signing method")
+		// 4. Parse dan validate token (signature, registered claims, dan custom claims)
+		claims, err := tokenManager.Parse(tokenString)
+		if err != nil {
+			return utils.UnauthorizedResponse(c, "Invalid or expired token")
+		}
+
+		// 5. Check session (sudah di-revoke lewat logout everywhere / admin, atau sudah expired)
+		session, err := sessionRepo.FindByID(claims.SessionID)
+		if err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return utils.UnauthorizedResponse(c, "Session not found. Please login again.")
 			}
-			return []byte(jwtSecret), nil
-		})
+			return utils.InternalServerErrorResponse(c, "Failed to verify session")
+		}
+
+		if !session.IsActive() {
+			return utils.UnauthorizedResponse(c, "Session has been revoked or expired. Please login again.")
+		}
 
-		// 5. Check parsing error
+		// 6. Check token version (role / password / status user berubah setelah token dibuat)
+		user, err := userRepo.FindById(claims.UserID)
 		if err != nil {
-			return utils.UnauthorizedResponse(c, "Invalid or expired token")
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return utils.UnauthorizedResponse(c, "User no longer exists. Please login again.")
+			}
+			return utils.InternalServerErrorResponse(c, "Failed to verify user")
 		}
 
-		// 6. Validate token
-		if !token.Valid {
-			return utils.UnauthorizedResponse(c, "Invalid token")
+		if user.TokenVersion != claims.TokenVersion {
+			return utils.UnauthorizedResponse(c, "Token is no longer valid. Please login again.")
 		}
 
-		// 7. Extract claims
-		claims, ok := token.Claims.(jwt.MapClaims)
-		if !ok {
-			return utils.UnauthorizedResponse(c, "Invalid tokenn claims")
+		if err := sessionRepo.Touch(session.ID); err != nil {
+			return utils.InternalServerErrorResponse(c, "Failed to update session")
 		}
 
-		// 8. Set user info ke context untuk digunakan di handler
-		c.Locals("userID", uint(claims["sub"].(float64)))
-		c.Locals("username", claims["username"].(string))
-		c.Locals("role", claims["role"].(string))
+		// 7. Set user info ke context untuk digunakan di handler
+		c.Locals("userID", claims.UserID)
+		c.Locals("username", claims.Username)
+		c.Locals("role", claims.Role)
+		c.Locals("organizationID", claims.OrganizationID)
+		c.Locals("claims", claims)
+		c.Locals("mfaVerified", session.MFAVerifiedAt != nil)
 
-		// 9. Continue ke handler berikutnya
+		// 8. Continue ke handler berikutnya
 		return c.Next()
 	}
 }
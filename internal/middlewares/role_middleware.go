@@ -1,6 +1,8 @@
 package middlewares
 
 import (
+	"github.com/LutfiyaAinurrahmanP/boilerplate_fiber_restful_api/internal/authz"
+	"github.com/LutfiyaAinurrahmanP/boilerplate_fiber_restful_api/internal/jwtauth"
 	"github.com/LutfiyaAinurrahmanP/boilerplate_fiber_restful_api/internal/models"
 	"github.com/LutfiyaAinurrahmanP/boilerplate_fiber_restful_api/internal/utils"
 	"github.com/gofiber/fiber/v2"
@@ -25,7 +27,8 @@ func RequireAdmin() fiber.Handler {
 }
 
 // RequireUser adalah middleware untuk memastikan user adalah regular user
-// Hanya user dengan role "user" yang bisa akses endpoint dengan middleware ini
+// Hanya user dengan role "user" yang bisa akses endpoint dengan middleware ini,
+// gunakan RequireMinimumRole jika role di atas user (misalnya admin) juga boleh masuk
 func RequireUser() fiber.Handler {
 	return func(c *fiber.Ctx) error {
 		// Get role dari context (sudah di-set oleh JWTAuthMiddleware)
@@ -41,6 +44,87 @@ func RequireUser() fiber.Handler {
 	}
 }
 
+// RequireAnyRole memastikan role user salah satu dari roles, misalnya RequireAnyRole("admin", "support")
+func RequireAnyRole(roles ...string) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		userRole := GetRoleFromContext(c)
+		for _, role := range roles {
+			if userRole == role {
+				return c.Next()
+			}
+		}
+		return utils.ForbiddenResponse(c, "Forbidden: Role not allowed")
+	}
+}
+
+// RoleHierarchy membandingkan level role (services.RoleService)
+type RoleHierarchy interface {
+	RoleAtLeast(role, minimum string) bool
+}
+
+// RequireMinimumRole memastikan level role user >= level role minimum,
+// sehingga role yang lebih tinggi mewarisi akses role di bawahnya (admin boleh masuk ke route RequireMinimumRole(store, "user"))
+func RequireMinimumRole(hierarchy RoleHierarchy, minimum string) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		if !hierarchy.RoleAtLeast(GetRoleFromContext(c), minimum) {
+			return utils.ForbiddenResponse(c, "Forbidden: Role "+minimum+" or higher required")
+		}
+		return c.Next()
+	}
+}
+
+// RequireOrganization memastikan request membawa organisasi aktif (claim "org" / organisasi asal pemilik API key)
+// Dipasang di route yang query-nya di-scope ke organisasi pemanggil
+func RequireOrganization() fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		if GetOrganizationIDFromContext(c) == 0 {
+			return utils.ForbiddenResponse(c, "Forbidden: Organization required")
+		}
+		return c.Next()
+	}
+}
+
+// PermissionStore adalah sumber permission per role (services.RoleService)
+type PermissionStore interface {
+	RolePermissions(role string) ([]string, error)
+}
+
+// LoadPermissions memuat permission milik role user ke context
+// Middleware ini harus dipasang setelah JWTAuthMiddleware / APIKeyAuthMiddleware dan sebelum RequirePermission
+func LoadPermissions(store PermissionStore) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		permissions, err := store.RolePermissions(GetRoleFromContext(c))
+		if err != nil {
+			return utils.InternalServerErrorResponse(c, "Failed to load permissions")
+		}
+
+		granted := make(map[string]bool, len(permissions))
+		for _, permission := range permissions {
+			granted[permission] = true
+		}
+		c.Locals("permissions", granted)
+
+		return c.Next()
+	}
+}
+
+// RequirePermission memastikan role user punya permission tertentu, misalnya RequirePermission("users.delete")
+// Jika LoadPermissions belum dipasang, request selalu ditolak
+func RequirePermission(permission string) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		if !HasPermission(c, permission) {
+			return utils.ForbiddenResponse(c, "Forbidden: Missing permission "+permission)
+		}
+		return c.Next()
+	}
+}
+
+// HasPermission mengecek permission yang sudah dimuat LoadPermissions
+func HasPermission(c *fiber.Ctx, permission string) bool {
+	granted, ok := c.Locals("permissions").(map[string]bool)
+	return ok && granted[permission]
+}
+
 // GetRoleFromContext mengambil role dari context
 // Helper function untuk mendapatkan role user yang sedang login
 func GetRoleFromContext(c *fiber.Ctx) string {
@@ -70,3 +154,32 @@ func GetUsernameFromContext(c *fiber.Ctx) string {
 	}
 	return username
 }
+
+// GetClaimsFromContext mengambil JWT claims dari context
+// Helper function untuk mendapatkan session ID, jti, dan waktu expired token
+func GetClaimsFromContext(c *fiber.Ctx) *jwtauth.Claims {
+	claims, ok := c.Locals("claims").(*jwtauth.Claims)
+	if !ok {
+		return nil
+	}
+	return claims
+}
+
+// GetOrganizationIDFromContext mengambil ID organisasi aktif dari context
+// Helper function untuk men-scope query ke organisasi user yang sedang login
+func GetOrganizationIDFromContext(c *fiber.Ctx) uint {
+	organizationID, ok := c.Locals("organizationID").(uint)
+	if !ok {
+		return 0
+	}
+	return organizationID
+}
+
+// GetSubjectFromContext mengambil pemanggil sebagai subject policy engine (authz)
+func GetSubjectFromContext(c *fiber.Ctx) authz.Subject {
+	return authz.Subject{
+		UserID:         GetUserIDFromContext(c),
+		Role:           GetRoleFromContext(c),
+		OrganizationID: GetOrganizationIDFromContext(c),
+	}
+}
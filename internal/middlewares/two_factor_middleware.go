@@ -0,0 +1,31 @@
+package middlewares
+
+import (
+	"github.com/LutfiyaAinurrahmanP/boilerplate_fiber_restful_api/internal/repositories"
+	"github.com/LutfiyaAinurrahmanP/boilerplate_fiber_restful_api/internal/utils"
+	"github.com/gofiber/fiber/v2"
+)
+
+// RequireTwoFactorPolicy menolak request jika role user wajib 2FA tetapi session belum lolos 2FA
+// Middleware ini harus dipasang setelah JWTAuthMiddleware
+// User yang belum enroll tetap bisa login dan enroll lewat /auth/2fa, lalu mengakses route ini
+func RequireTwoFactorPolicy(policyRepo repositories.TwoFactorPolicyRepository) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		required, err := policyRepo.IsRequired(GetRoleFromContext(c))
+		if err != nil {
+			return utils.InternalServerErrorResponse(c, "Failed to check two-factor policy")
+		}
+
+		if required && !IsMFAVerified(c) {
+			return utils.ForbiddenResponse(c, "Forbidden: Two-factor authentication required for your role")
+		}
+
+		return c.Next()
+	}
+}
+
+// IsMFAVerified menandakan session saat ini sudah melewati verifikasi 2FA
+func IsMFAVerified(c *fiber.Ctx) bool {
+	verified, ok := c.Locals("mfaVerified").(bool)
+	return ok && verified
+}
@@ -0,0 +1,51 @@
+package models
+
+import "time"
+
+// Scope API key
+// read: request GET / HEAD, write: request lain, admin: route /admin (hanya untuk pemilik dengan role admin)
+const (
+	APIKeyScopeRead  = "read"
+	APIKeyScopeWrite = "write"
+	APIKeyScopeAdmin = "admin"
+)
+
+// APIKeyPrefix menandai secret API key agar mudah dikenali (misalnya oleh secret scanner)
+const APIKeyPrefix = "ak_"
+
+// APIKey adalah personal access token untuk client mesin (CI, script)
+// Secret hanya ditampilkan sekali saat dibuat, yang disimpan hanya hash SHA-256 dan prefix untuk identifikasi
+type APIKey struct {
+	ID         uint       `gorm:"primaryKey" json:"id"`
+	UserID     uint       `gorm:"not null;index" json:"user_id"`
+	Name       string     `gorm:"size:100;not null" json:"name"`
+	Prefix     string     `gorm:"size:16;not null" json:"prefix"`
+	KeyHash    string     `gorm:"type:char(64);not null;uniqueIndex" json:"-"`
+	Scopes     []string   `gorm:"serializer:json;type:varchar(255);not null" json:"scopes"`
+	ExpiresAt  time.Time  `gorm:"not null;index" json:"expires_at"`
+	LastUsedAt *time.Time `json:"last_used_at,omitempty"`
+	LastUsedIP string     `gorm:"size:45" json:"last_used_ip,omitempty"`
+	RevokedAt  *time.Time `json:"revoked_at,omitempty"`
+	CreatedAt  time.Time  `gorm:"autoCreateTime" json:"created_at"`
+
+	// MFAVerified true jika key dibuat dari session yang sudah lolos 2FA,
+	// key tanpa tanda ini ditolak route yang diwajibkan 2FA oleh kebijakan role
+	MFAVerified bool `gorm:"not null;default:false" json:"mfa_verified"`
+}
+
+func (APIKey) TableName() string {
+	return "api_keys"
+}
+
+func (k *APIKey) IsActive() bool {
+	return k.RevokedAt == nil && time.Now().Before(k.ExpiresAt)
+}
+
+func (k *APIKey) HasScope(scope string) bool {
+	for _, s := range k.Scopes {
+		if s == scope {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,50 @@
+package models
+
+import "time"
+
+const (
+	InvitationStatusPending  = "pending"
+	InvitationStatusAccepted = "accepted"
+	InvitationStatusRevoked  = "revoked"
+	InvitationStatusExpired  = "expired"
+)
+
+// Invitation adalah undangan admin untuk onboarding (registrasi mode invite)
+// Token undangan hanya disimpan dalam bentuk hash, resend membuat token baru
+type Invitation struct {
+	ID             uint       `gorm:"primaryKey" json:"id"`
+	OrganizationID uint       `gorm:"not null;default:1;index" json:"organization_id"` // Organisasi asal akun yang dibuat
+	Email          string     `gorm:"size:100;not null;index" json:"email"`
+	Role           string     `gorm:"type:varchar(20);not null;default:'user'" json:"role"`
+	Note           string     `gorm:"size:255" json:"note"`
+	TokenHash      string     `gorm:"type:char(64);not null;uniqueIndex" json:"-"`
+	InvitedBy      uint       `gorm:"not null" json:"invited_by"`
+	ExpiresAt      time.Time  `gorm:"not null;index" json:"expires_at"`
+	LastSentAt     time.Time  `gorm:"not null" json:"last_sent_at"`
+	AcceptedAt     *time.Time `json:"accepted_at,omitempty"`
+	AcceptedUserID *uint      `json:"accepted_user_id,omitempty"`
+	RevokedAt      *time.Time `json:"revoked_at,omitempty"`
+	CreatedAt      time.Time  `gorm:"autoCreateTime" json:"created_at"`
+	UpdatedAt      time.Time  `gorm:"autoUpdateTime" json:"updated_at"`
+}
+
+func (Invitation) TableName() string {
+	return "invitations"
+}
+
+func (i *Invitation) Status() string {
+	switch {
+	case i.AcceptedAt != nil:
+		return InvitationStatusAccepted
+	case i.RevokedAt != nil:
+		return InvitationStatusRevoked
+	case !time.Now().Before(i.ExpiresAt):
+		return InvitationStatusExpired
+	default:
+		return InvitationStatusPending
+	}
+}
+
+func (i *Invitation) IsPending() bool {
+	return i.Status() == InvitationStatusPending
+}
@@ -0,0 +1,39 @@
+package models
+
+import "time"
+
+const (
+	LockoutEventLocked   = "locked"
+	LockoutEventUnlocked = "unlocked"
+)
+
+// LoginAttempt menghitung login gagal per key ("user:<username>" atau "ip:<address>")
+// Username yang tidak terdaftar juga dihitung agar perilakunya sama dengan username terdaftar
+type LoginAttempt struct {
+	Key           string     `gorm:"type:varchar(100);primaryKey" json:"key"`
+	Failures      int        `gorm:"not null;default:0" json:"failures"`
+	LastFailureAt time.Time  `gorm:"not null" json:"last_failure_at"`
+	LockedUntil   *time.Time `json:"locked_until,omitempty"`
+	UpdatedAt     time.Time  `gorm:"autoUpdateTime;index" json:"updated_at"`
+}
+
+func (LoginAttempt) TableName() string {
+	return "login_attempts"
+}
+
+// LockoutEvent mencatat riwayat lockout dan unlock untuk audit
+type LockoutEvent struct {
+	ID          uint       `gorm:"primaryKey" json:"id"`
+	Key         string     `gorm:"type:varchar(100);not null;index" json:"key"`
+	UserID      *uint      `gorm:"index" json:"user_id,omitempty"`
+	Event       string     `gorm:"type:varchar(20);not null" json:"event"`
+	Failures    int        `json:"failures"`
+	LockedUntil *time.Time `json:"locked_until,omitempty"`
+	ActorID     *uint      `json:"actor_id,omitempty"` // Admin yang melakukan unlock
+	IPAddress   string     `gorm:"size:45" json:"ip_address"`
+	CreatedAt   time.Time  `gorm:"autoCreateTime" json:"created_at"`
+}
+
+func (LockoutEvent) TableName() string {
+	return "lockout_events"
+}
@@ -0,0 +1,111 @@
+package models
+
+import "time"
+
+// Grant type OAuth2 yang didukung authorization server
+const (
+	OAuthGrantAuthorizationCode = "authorization_code"
+	OAuthGrantRefreshToken      = "refresh_token"
+	OAuthGrantClientCredentials = "client_credentials"
+)
+
+// OAuthClient adalah aplikasi internal yang mendelegasikan login ke service ini
+// Client confidential punya secret (hanya hash SHA-256 yang disimpan), client public (SPA / mobile) tidak punya secret dan wajib PKCE
+type OAuthClient struct {
+	ID           uint       `gorm:"primaryKey" json:"id"`
+	ClientID     string     `gorm:"size:64;not null;uniqueIndex" json:"client_id"`
+	SecretHash   string     `gorm:"type:char(64)" json:"-"`
+	Name         string     `gorm:"size:100;not null" json:"name"`
+	Confidential bool       `gorm:"not null" json:"confidential"`
+	RedirectURIs []string   `gorm:"serializer:json;type:text;not null" json:"redirect_uris"`
+	GrantTypes   []string   `gorm:"serializer:json;type:varchar(255);not null" json:"grant_types"`
+	Scopes       []string   `gorm:"serializer:json;type:text;not null" json:"scopes"`
+	CreatedBy    uint       `gorm:"not null" json:"created_by"`
+	DisabledAt   *time.Time `json:"disabled_at,omitempty"`
+	CreatedAt    time.Time  `gorm:"autoCreateTime" json:"created_at"`
+	UpdatedAt    time.Time  `gorm:"autoUpdateTime" json:"updated_at"`
+}
+
+func (OAuthClient) TableName() string {
+	return "oauth_clients"
+}
+
+func (c *OAuthClient) IsActive() bool {
+	return c.DisabledAt == nil
+}
+
+func (c *OAuthClient) AllowsGrant(grantType string) bool {
+	return containsString(c.GrantTypes, grantType)
+}
+
+func (c *OAuthClient) AllowsRedirectURI(redirectURI string) bool {
+	return containsString(c.RedirectURIs, redirectURI)
+}
+
+func (c *OAuthClient) AllowsScope(scope string) bool {
+	return containsString(c.Scopes, scope)
+}
+
+// OAuthAuthorizationCode adalah code sekali pakai hasil persetujuan user di /oauth/authorize
+type OAuthAuthorizationCode struct {
+	ID            uint       `gorm:"primaryKey" json:"id"`
+	CodeHash      string     `gorm:"type:char(64);not null;uniqueIndex" json:"-"`
+	ClientID      string     `gorm:"size:64;not null;index" json:"client_id"`
+	UserID        uint       `gorm:"not null;index" json:"user_id"`
+	RedirectURI   string     `gorm:"size:500;not null" json:"redirect_uri"`
+	Scope         string     `gorm:"size:500" json:"scope"`
+	CodeChallenge string     `gorm:"size:128;not null" json:"-"`
+	Nonce         string     `gorm:"size:255" json:"-"`
+	ExpiresAt     time.Time  `gorm:"not null;index" json:"expires_at"`
+	ConsumedAt    *time.Time `json:"consumed_at,omitempty"`
+	CreatedAt     time.Time  `gorm:"autoCreateTime" json:"created_at"`
+}
+
+func (OAuthAuthorizationCode) TableName() string {
+	return "oauth_authorization_codes"
+}
+
+func (c *OAuthAuthorizationCode) IsUsable() bool {
+	return c.ConsumedAt == nil && time.Now().Before(c.ExpiresAt)
+}
+
+// OAuthToken mencatat access token (jti) dan refresh token (hash) yang diterbitkan untuk client
+// FamilyID mengelompokkan hasil rotasi refresh token dari satu authorization code agar bisa di-revoke sekaligus
+// UserTokenVersion adalah users.token_version saat token diterbitkan, token tidak berlaku lagi jika versi user sudah naik
+// (ganti / reset password, perubahan role, delete, logout dari semua perangkat)
+type OAuthToken struct {
+	ID                  uint       `gorm:"primaryKey" json:"id"`
+	FamilyID            string     `gorm:"type:varchar(36);not null;index" json:"family_id"`
+	ClientID            string     `gorm:"size:64;not null;index" json:"client_id"`
+	UserID              *uint      `gorm:"index" json:"user_id,omitempty"` // nil untuk grant client_credentials
+	UserTokenVersion    uint       `gorm:"not null;default:0" json:"-"`
+	Scope               string     `gorm:"size:500" json:"scope"`
+	AccessJTI           string     `gorm:"type:varchar(36);not null;uniqueIndex" json:"-"`
+	AccessExpiresAt     time.Time  `gorm:"not null;index" json:"access_expires_at"`
+	RefreshHash         *string    `gorm:"type:char(64);uniqueIndex" json:"-"`
+	RefreshExpiresAt    *time.Time `gorm:"index" json:"refresh_expires_at,omitempty"`
+	AuthorizationCodeID *uint      `gorm:"index" json:"-"`
+	RevokedAt           *time.Time `json:"revoked_at,omitempty"`
+	CreatedAt           time.Time  `gorm:"autoCreateTime" json:"created_at"`
+}
+
+func (OAuthToken) TableName() string {
+	return "oauth_tokens"
+}
+
+func (t *OAuthToken) AccessActive() bool {
+	return t.RevokedAt == nil && time.Now().Before(t.AccessExpiresAt)
+}
+
+func (t *OAuthToken) RefreshActive() bool {
+	return t.RevokedAt == nil && t.RefreshExpiresAt != nil && time.Now().Before(*t.RefreshExpiresAt)
+}
+
+func containsString(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
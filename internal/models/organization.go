@@ -0,0 +1,62 @@
+package models
+
+import "time"
+
+// Organisasi default di-seed saat startup dengan ID tetap
+// User lama, register publik dan akun OIDC baru masuk ke organisasi ini,
+// dan hanya anggotanya yang bisa membuat / mengelola organisasi lain
+const (
+	DefaultOrganizationID   uint = 1
+	DefaultOrganizationSlug      = "default"
+)
+
+// Cakupan uniqueness username, email dan nomor telepon (USER_UNIQUENESS_SCOPE)
+const (
+	UniquenessGlobal       = "global"
+	UniquenessOrganization = "organization"
+)
+
+// Organization adalah tenant, setiap user punya satu organisasi asal (users.organization_id)
+type Organization struct {
+	ID        uint      `gorm:"primaryKey" json:"id"`
+	Name      string    `gorm:"size:100;not null" json:"name"`
+	Slug      string    `gorm:"size:50;not null;uniqueIndex" json:"slug"`
+	CreatedAt time.Time `gorm:"autoCreateTime" json:"created_at"`
+	UpdatedAt time.Time `gorm:"autoUpdateTime" json:"updated_at"`
+}
+
+func (Organization) TableName() string {
+	return "organizations"
+}
+
+// OrganizationMember adalah keanggotaan user di sebuah organisasi beserta role-nya di organisasi tersebut
+// User selalu menjadi anggota organisasi asalnya (dibuat otomatis saat user dibuat), keanggotaan lain ditambahkan admin
+type OrganizationMember struct {
+	ID             uint          `gorm:"primaryKey" json:"id"`
+	OrganizationID uint          `gorm:"not null;uniqueIndex:idx_organization_members_org_user" json:"organization_id"`
+	UserID         uint          `gorm:"not null;uniqueIndex:idx_organization_members_org_user;index" json:"user_id"`
+	Role           string        `gorm:"type:varchar(20);not null;default:'user'" json:"role"`
+	Organization   *Organization `json:"organization,omitempty"`
+	CreatedAt      time.Time     `gorm:"autoCreateTime" json:"created_at"`
+	UpdatedAt      time.Time     `gorm:"autoUpdateTime" json:"updated_at"`
+}
+
+func (OrganizationMember) TableName() string {
+	return "organization_members"
+}
+
+var perOrganizationUniqueness bool
+
+// SetUniquenessScope memilih cakupan uniqueness user, dipanggil sekali saat startup sebelum migrasi
+func SetUniquenessScope(scope string) {
+	perOrganizationUniqueness = scope == UniquenessOrganization
+}
+
+// UniqueScopeFor mengembalikan nilai users.unique_scope untuk user dari organisasi tersebut:
+// ID organisasi jika uniqueness per organisasi, 0 jika global (semua user berbagi satu cakupan)
+func UniqueScopeFor(organizationID uint) uint {
+	if perOrganizationUniqueness {
+		return organizationID
+	}
+	return 0
+}
@@ -0,0 +1,35 @@
+package models
+
+import "time"
+
+// RefreshToken menyimpan refresh token (opaque) dalam bentuk hash
+// Setiap login membuat family baru, setiap refresh membuat token baru dalam family yang sama
+type RefreshToken struct {
+	ID          uint       `gorm:"primaryKey" json:"id"`
+	TokenHash   string     `gorm:"type:char(64);not null;uniqueIndex" json:"-"`
+	FamilyID    string     `gorm:"type:varchar(36);not null;index" json:"family_id"`
+	UserID      uint       `gorm:"not null;index" json:"user_id"`
+	AccessHash  string     `gorm:"type:char(64);not null" json:"-"` // SHA-256 access token yang diterbitkan bersama refresh token ini
+	AccessExpAt time.Time  `gorm:"not null" json:"-"`
+	ExpiresAt   time.Time  `gorm:"not null;index" json:"expires_at"`
+	RotatedAt   *time.Time `json:"rotated_at,omitempty"`
+	RevokedAt   *time.Time `json:"revoked_at,omitempty"`
+	CreatedAt   time.Time  `gorm:"autoCreateTime" json:"created_at"`
+}
+
+func (RefreshToken) TableName() string {
+	return "refresh_tokens"
+}
+
+func (t *RefreshToken) IsExpired() bool {
+	return time.Now().After(t.ExpiresAt)
+}
+
+// IsRotated menandakan token sudah pernah dipakai untuk refresh
+func (t *RefreshToken) IsRotated() bool {
+	return t.RotatedAt != nil
+}
+
+func (t *RefreshToken) IsRevoked() bool {
+	return t.RevokedAt != nil
+}
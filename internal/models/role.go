@@ -0,0 +1,93 @@
+package models
+
+import "time"
+
+// Permission yang dicek di route, katalog ini di-seed ke tabel permissions saat startup
+const (
+	PermissionAdminAccess        = "admin.access"         // Masuk ke route /admin
+	PermissionUsersRead          = "users.read"           // List, detail, user terhapus, riwayat lockout
+	PermissionUsersCreate        = "users.create"         // Membuat user
+	PermissionUsersUpdate        = "users.update"         // Mengubah data & role user
+	PermissionUsersDeactivate    = "users.deactivate"     // Soft delete & restore
+	PermissionUsersDelete        = "users.delete"         // Hard delete (permanen)
+	PermissionUsersLogout        = "users.logout"         // Force logout semua session user
+	PermissionUsersUnlock        = "users.unlock"         // Membuka akun yang terkunci
+	PermissionInvitationsManage  = "invitations.manage"   // Membuat, mengirim ulang, revoke undangan
+	PermissionSecurityManage     = "security.manage"      // Kebijakan 2FA per role
+	PermissionOAuthClientsManage = "oauth_clients.manage" // Registrasi client OAuth2
+	PermissionRolesManage        = "roles.manage"         // CRUD role & permission-nya
+
+	// Multi-tenancy
+	PermissionOrganizationsManage = "organizations.manage" // Organisasi & keanggotaan user
+)
+
+// PermissionCatalog adalah semua permission yang dikenal aplikasi beserta deskripsinya
+var PermissionCatalog = []Permission{
+	{Name: PermissionAdminAccess, Description: "Access the admin area"},
+	{Name: PermissionUsersRead, Description: "List and view users, deleted users and lockout history"},
+	{Name: PermissionUsersCreate, Description: "Create users"},
+	{Name: PermissionUsersUpdate, Description: "Update users and their role"},
+	{Name: PermissionUsersDeactivate, Description: "Soft delete and restore users"},
+	{Name: PermissionUsersDelete, Description: "Permanently delete users"},
+	{Name: PermissionUsersLogout, Description: "Force logout users from all sessions"},
+	{Name: PermissionUsersUnlock, Description: "Unlock accounts locked by failed logins"},
+	{Name: PermissionInvitationsManage, Description: "Create, resend and revoke invitations"},
+	{Name: PermissionSecurityManage, Description: "Manage two-factor policies"},
+	{Name: PermissionOAuthClientsManage, Description: "Register and manage OAuth2 clients"},
+	{Name: PermissionRolesManage, Description: "Create, update and delete roles"},
+	{Name: PermissionOrganizationsManage, Description: "Manage organizations and their members"},
+}
+
+// Level role sistem, role dengan level lebih tinggi mewarisi akses role di bawahnya (RequireMinimumRole)
+const (
+	RoleLevelUser  = 10
+	RoleLevelAdmin = 100
+)
+
+// Role menentukan permission yang dimiliki user, users.role menyimpan Name
+// Role sistem (user & admin) di-seed saat startup, tidak bisa dihapus, dan admin selalu punya semua permission
+type Role struct {
+	ID          uint         `gorm:"primaryKey" json:"id"`
+	Name        string       `gorm:"size:20;not null;uniqueIndex" json:"name"`
+	Description string       `gorm:"size:255" json:"description"`
+	Level       int          `gorm:"not null;default:0" json:"level"`
+	IsSystem    bool         `gorm:"not null;default:false" json:"is_system"`
+	Permissions []Permission `gorm:"many2many:role_permissions" json:"permissions"`
+	CreatedAt   time.Time    `gorm:"autoCreateTime" json:"created_at"`
+	UpdatedAt   time.Time    `gorm:"autoUpdateTime" json:"updated_at"`
+}
+
+func (Role) TableName() string {
+	return "roles"
+}
+
+func (r *Role) PermissionNames() []string {
+	names := make([]string, 0, len(r.Permissions))
+	for _, permission := range r.Permissions {
+		names = append(names, permission.Name)
+	}
+	return names
+}
+
+type Permission struct {
+	ID          uint   `gorm:"primaryKey" json:"id"`
+	Name        string `gorm:"size:100;not null;uniqueIndex" json:"name"`
+	Description string `gorm:"size:255" json:"description"`
+}
+
+func (Permission) TableName() string {
+	return "permissions"
+}
+
+// RoleLookup adalah role store yang dibaca ValidateRole dan GetAvailableRoles
+type RoleLookup interface {
+	RoleExists(name string) bool
+	RoleNames() []string
+}
+
+var roleLookup RoleLookup
+
+// SetRoleLookup memasang role store, sebelum dipasang hanya role sistem yang dikenal (misalnya di CLI bootstrap)
+func SetRoleLookup(lookup RoleLookup) {
+	roleLookup = lookup
+}
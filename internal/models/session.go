@@ -0,0 +1,31 @@
+package models
+
+import "time"
+
+// Session mencatat setiap login (satu session = satu refresh token family)
+// Session ID ikut disimpan di JWT (claim "sid") sehingga token bisa di-revoke per sesi
+type Session struct {
+	ID         string     `gorm:"type:varchar(36);primaryKey" json:"id"`
+	UserID     uint       `gorm:"not null;index" json:"user_id"`
+	Device     string     `gorm:"size:100" json:"device"`
+	IPAddress  string     `gorm:"size:45" json:"ip_address"`
+	UserAgent  string     `gorm:"size:255" json:"user_agent"`
+	CreatedAt  time.Time  `gorm:"autoCreateTime" json:"created_at"`
+	LastSeenAt time.Time  `gorm:"not null" json:"last_seen_at"`
+	ExpiresAt  time.Time  `gorm:"not null;index" json:"expires_at"`
+	RevokedAt  *time.Time `gorm:"index" json:"revoked_at,omitempty"`
+
+	// Terisi jika login session ini sudah melewati verifikasi 2FA
+	MFAVerifiedAt *time.Time `json:"mfa_verified_at,omitempty"`
+
+	// Organisasi aktif session ini (claim "org"), bisa diganti lewat POST /auth/organizations/switch
+	OrganizationID uint `gorm:"not null;default:1" json:"organization_id"`
+}
+
+func (Session) TableName() string {
+	return "sessions"
+}
+
+func (s *Session) IsActive() bool {
+	return s.RevokedAt == nil && time.Now().Before(s.ExpiresAt)
+}
@@ -4,7 +4,7 @@ import "time"
 
 type TokenBlacklist struct {
 	ID        uint      `gorm:"primaryKey" json:"id"`
-	Token     string    `gorm:"type:varchar(512);not null;index" json:"token"`
+	TokenHash string    `gorm:"type:char(64);not null;index" json:"-"` // SHA-256 digest, token asli tidak disimpan
 	UserID    uint      `gorm:"not null;index" json:"user_id"`
 	ExpiresAt time.Time `gorm:"not null;index" json:"expires_at"`
 	CreatedAt time.Time `gorm:"autoCreateTime" json:"created_at"`
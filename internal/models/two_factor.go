@@ -0,0 +1,54 @@
+package models
+
+import "time"
+
+// RecoveryCode adalah kode cadangan sekali pakai jika authenticator app hilang
+type RecoveryCode struct {
+	ID        uint       `gorm:"primaryKey" json:"id"`
+	UserID    uint       `gorm:"not null;index" json:"user_id"`
+	CodeHash  string     `gorm:"type:char(64);not null" json:"-"`
+	UsedAt    *time.Time `json:"used_at,omitempty"`
+	CreatedAt time.Time  `gorm:"autoCreateTime" json:"created_at"`
+}
+
+func (RecoveryCode) TableName() string {
+	return "recovery_codes"
+}
+
+// MFAChallenge adalah token "mfa pending" yang diterbitkan Login untuk user dengan 2FA aktif
+// Info perangkat disimpan agar session dibuat dengan data login aslinya setelah 2FA lolos
+type MFAChallenge struct {
+	ID         uint       `gorm:"primaryKey" json:"id"`
+	TokenHash  string     `gorm:"type:char(64);not null;uniqueIndex" json:"-"`
+	UserID     uint       `gorm:"not null;index" json:"user_id"`
+	Device     string     `gorm:"size:100" json:"device"`
+	IPAddress  string     `gorm:"size:45" json:"ip_address"`
+	UserAgent  string     `gorm:"size:255" json:"user_agent"`
+	Attempts   int        `gorm:"not null;default:0" json:"attempts"`
+	ExpiresAt  time.Time  `gorm:"not null;index" json:"expires_at"`
+	ConsumedAt *time.Time `json:"consumed_at,omitempty"`
+	CreatedAt  time.Time  `gorm:"autoCreateTime" json:"created_at"`
+
+	// Organisasi yang dipilih saat login, dipakai session yang dibuat setelah 2FA lolos
+	OrganizationID uint `gorm:"not null;default:1" json:"organization_id"`
+}
+
+func (MFAChallenge) TableName() string {
+	return "mfa_challenges"
+}
+
+func (m *MFAChallenge) IsUsable(maxAttempts int) bool {
+	return m.ConsumedAt == nil && m.Attempts < maxAttempts && time.Now().Before(m.ExpiresAt)
+}
+
+// TwoFactorPolicy menentukan apakah role tertentu wajib memakai 2FA
+type TwoFactorPolicy struct {
+	Role      string    `gorm:"type:varchar(20);primaryKey" json:"role"`
+	Required  bool      `gorm:"not null;default:false" json:"required"`
+	UpdatedBy uint      `json:"updated_by"`
+	UpdatedAt time.Time `gorm:"autoUpdateTime" json:"updated_at"`
+}
+
+func (TwoFactorPolicy) TableName() string {
+	return "two_factor_policies"
+}
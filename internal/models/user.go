@@ -3,6 +3,7 @@ package models
 import (
 	"time"
 
+	"github.com/LutfiyaAinurrahmanP/boilerplate_fiber_restful_api/internal/utils"
 	"gorm.io/gorm"
 )
 
@@ -13,14 +14,32 @@ const (
 
 type User struct {
 	ID        uint           `gorm:"primaryKey" json:"id"`
-	Username  string         `gorm:"unique;not null;size:50" json:"username" validate:"required,min=3,max=50"`
-	Email     string         `gorm:"unique;not null;size:100" json:"email" validate:"required,email"`
-	Phone     string         `gorm:"unique;not null;size:15" json:"phone" validate:"required,min=10,max=15"`
-	Password  string         `gorm:"not null;size:255" json:"password" validate:"required,min=8"`
+	Username  string         `gorm:"not null;size:50;uniqueIndex:idx_users_scope_username,priority:2" json:"username" validate:"required,min=3,max=50"`
+	Email     string         `gorm:"not null;size:100;uniqueIndex:idx_users_scope_email,priority:2" json:"email" validate:"required,email"`
+	Phone     *string        `gorm:"size:16;uniqueIndex:idx_users_scope_phone,priority:2" json:"phone"`
+	Password  string         `gorm:"size:255" json:"password"`
 	Role      string         `gorm:"type:varchar(20);not null;default:'user';index" json:"role"`
 	CreatedAt time.Time      `gorm:"autoCreateTime" json:"created_at"`
 	UpdatedAt time.Time      `gorm:"autoUpdateTime" json:"updated_at"`
 	DeletedAt gorm.DeletedAt `gorm:"index" json:"-"`
+
+	// OrganizationID adalah organisasi asal user, Role adalah role user di organisasi asal
+	// UniqueScope menentukan cakupan unique index username / email / phone (lihat UniqueScopeFor)
+	OrganizationID uint `gorm:"not null;default:1;index" json:"organization_id"`
+	UniqueScope    uint `gorm:"not null;default:0;uniqueIndex:idx_users_scope_username,priority:1;uniqueIndex:idx_users_scope_email,priority:1;uniqueIndex:idx_users_scope_phone,priority:1" json:"-"`
+
+	// TokenVersion dinaikkan setiap ada perubahan role, password, delete, atau restore
+	// Token dengan claim "ver" yang lebih lama otomatis ditolak
+	TokenVersion uint `gorm:"not null;default:0" json:"-"`
+
+	// Waktu email / nomor telepon diverifikasi, nil jika belum
+	EmailVerifiedAt *time.Time `json:"email_verified_at"`
+	PhoneVerifiedAt *time.Time `json:"phone_verified_at"`
+
+	// TOTP 2FA, secret diisi saat enroll dan baru aktif setelah TwoFactorEnabledAt terisi
+	TwoFactorSecret    string     `gorm:"size:64" json:"-"`
+	TwoFactorEnabledAt *time.Time `json:"two_factor_enabled_at"`
+	TwoFactorLastStep  int64      `gorm:"not null;default:0" json:"-"` // Step TOTP terakhir yang dipakai (anti replay)
 }
 
 func (User) TableName() string {
@@ -42,6 +61,49 @@ func (u *User) BeforeCreate(tx *gorm.DB) error {
 	return nil
 }
 
+// BeforeSave menyimpan email dalam huruf kecil dan nomor telepon dalam format E.164
+// BeforeSave dijalankan sebelum BeforeCreate, jadi organisasi default diisi di sini
+func (u *User) BeforeSave(tx *gorm.DB) error {
+	if u.OrganizationID == 0 {
+		u.OrganizationID = DefaultOrganizationID
+	}
+	u.UniqueScope = UniqueScopeFor(u.OrganizationID)
+	u.Email = utils.NormalizeEmail(u.Email)
+	if u.Phone != nil && *u.Phone == "" {
+		u.Phone = nil // NULL tidak melanggar unique index, string kosong melanggar
+	}
+	if u.Phone != nil {
+		if phone, err := utils.NormalizePhone(*u.Phone); err == nil {
+			u.Phone = &phone
+		}
+	}
+	return nil
+}
+
+// AfterCreate mendaftarkan user sebagai anggota organisasi asalnya, dalam transaksi yang sama dengan insert user
+func (u *User) AfterCreate(tx *gorm.DB) error {
+	return tx.Create(&OrganizationMember{
+		OrganizationID: u.OrganizationID,
+		UserID:         u.ID,
+		Role:           u.Role,
+	}).Error
+}
+
+// PhoneNumber mengembalikan nomor telepon user, string kosong jika tidak ada
+// Phone bernilai nil (NULL) untuk akun tanpa nomor telepon, misalnya akun yang dibuat lewat login OIDC
+func (u *User) PhoneNumber() string {
+	if u.Phone == nil {
+		return ""
+	}
+	return *u.Phone
+}
+
+// HasPassword bernilai false untuk akun yang hanya bisa login lewat identity provider
+// Password kosong tidak pernah cocok di login biasa, user bisa membuatnya lewat change / reset password
+func (u *User) HasPassword() bool {
+	return u.Password != ""
+}
+
 func (u *User) IsAdmin() bool {
 	return u.Role == RoleAdmin
 }
@@ -50,10 +112,17 @@ func (u *User) IsUser() bool {
 	return u.Role == RoleUser
 }
 
+// ValidateRole mengecek role di role store (tabel roles), fallback ke role sistem jika store belum dipasang
 func ValidateRole(role string) bool {
+	if roleLookup != nil {
+		return roleLookup.RoleExists(role)
+	}
 	return role == RoleUser || role == RoleAdmin
 }
 
 func GetAvailableRoles() []string {
+	if roleLookup != nil {
+		return roleLookup.RoleNames()
+	}
 	return []string{RoleUser, RoleAdmin}
 }
@@ -0,0 +1,41 @@
+package models
+
+import "time"
+
+// UserIdentity menghubungkan akun lokal dengan akun di identity provider OIDC
+// Satu user bisa punya beberapa identitas, tetapi (provider, subject) hanya milik satu user
+type UserIdentity struct {
+	ID          uint       `gorm:"primaryKey" json:"id"`
+	UserID      uint       `gorm:"not null;index" json:"user_id"`
+	Provider    string     `gorm:"size:50;not null;uniqueIndex:idx_identity_provider_subject" json:"provider"`
+	Subject     string     `gorm:"size:255;not null;uniqueIndex:idx_identity_provider_subject" json:"subject"`
+	Email       string     `gorm:"size:100" json:"email"` // Email dari IdP saat terakhir login, hanya informasi
+	CreatedAt   time.Time  `gorm:"autoCreateTime" json:"created_at"`
+	LastLoginAt *time.Time `json:"last_login_at,omitempty"`
+}
+
+func (UserIdentity) TableName() string {
+	return "user_identities"
+}
+
+// OIDCLoginState menyimpan state, nonce, dan PKCE verifier selama user login di identity provider
+// UserID terisi jika flow dimulai user yang sudah login untuk menghubungkan identitas baru
+type OIDCLoginState struct {
+	ID           uint       `gorm:"primaryKey" json:"id"`
+	StateHash    string     `gorm:"type:char(64);not null;uniqueIndex" json:"-"`
+	Provider     string     `gorm:"size:50;not null" json:"provider"`
+	Nonce        string     `gorm:"size:64;not null" json:"-"`
+	CodeVerifier string     `gorm:"size:128;not null" json:"-"`
+	UserID       *uint      `json:"user_id,omitempty"`
+	ExpiresAt    time.Time  `gorm:"not null;index" json:"expires_at"`
+	ConsumedAt   *time.Time `json:"consumed_at,omitempty"`
+	CreatedAt    time.Time  `gorm:"autoCreateTime" json:"created_at"`
+}
+
+func (OIDCLoginState) TableName() string {
+	return "oidc_login_states"
+}
+
+func (s *OIDCLoginState) IsUsable() bool {
+	return s.ConsumedAt == nil && time.Now().Before(s.ExpiresAt)
+}
@@ -0,0 +1,30 @@
+package models
+
+import "time"
+
+const (
+	VerificationChannelEmail = "email"
+	VerificationChannelPhone = "phone"
+)
+
+// VerificationCode adalah kode OTP untuk verifikasi email atau nomor telepon
+// Target menyimpan alamat saat kode dibuat, kode tidak berlaku jika alamat user berubah
+type VerificationCode struct {
+	ID         uint       `gorm:"primaryKey" json:"id"`
+	UserID     uint       `gorm:"not null;index:idx_verification_user_channel" json:"user_id"`
+	Channel    string     `gorm:"type:varchar(10);not null;index:idx_verification_user_channel" json:"channel"`
+	Target     string     `gorm:"size:100;not null" json:"target"`
+	CodeHash   string     `gorm:"type:char(64);not null" json:"-"`
+	Attempts   int        `gorm:"not null;default:0" json:"attempts"`
+	ExpiresAt  time.Time  `gorm:"not null;index" json:"expires_at"`
+	ConsumedAt *time.Time `json:"consumed_at,omitempty"`
+	CreatedAt  time.Time  `gorm:"autoCreateTime" json:"created_at"`
+}
+
+func (VerificationCode) TableName() string {
+	return "verification_codes"
+}
+
+func (v *VerificationCode) IsUsable(maxAttempts int) bool {
+	return v.ConsumedAt == nil && v.Attempts < maxAttempts && time.Now().Before(v.ExpiresAt)
+}
@@ -0,0 +1,23 @@
+package oidc
+
+import "testing"
+
+func TestCodeChallengeS256(t *testing.T) {
+	tests := []struct {
+		name     string
+		verifier string
+		want     string
+	}{
+		// RFC 7636 Appendix B
+		{"rfc 7636 example", "dBjftJeZ4CVP-mB92K27uhbUJU1p1r_wW1gFWFOEjXk", "E9Melhoa2OwvFrEMTJguCHaoeK1t8URWbuGJSstw-cM"},
+		{"empty verifier", "", "47DEQpj8HBSa-_TImW-5JCeuQeRkm5NMpJWZG3hSuFU"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := CodeChallengeS256(tt.verifier); got != tt.want {
+				t.Errorf("CodeChallengeS256(%q) = %s, want %s", tt.verifier, got, tt.want)
+			}
+		})
+	}
+}
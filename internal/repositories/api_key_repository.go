@@ -0,0 +1,83 @@
+package repositories
+
+import (
+	"time"
+
+	"github.com/LutfiyaAinurrahmanP/boilerplate_fiber_restful_api/internal/models"
+	"gorm.io/gorm"
+)
+
+type APIKeyRepository interface {
+	Create(key *models.APIKey) error
+	FindByHash(keyHash string) (*models.APIKey, error)
+	FindByUser(userID uint) ([]models.APIKey, error)
+	CountActiveByUser(userID uint) (int64, error)
+	TouchUsage(id uint, ip string, minInterval time.Duration) error
+	Revoke(userID, id uint) (bool, error)
+	CleanupExpiredTokens(batchSize int) (int64, error)
+}
+
+type apiKeyRepository struct {
+	db *gorm.DB
+}
+
+func NewAPIKeyRepository(db *gorm.DB) APIKeyRepository {
+	return &apiKeyRepository{
+		db: db,
+	}
+}
+
+func (r *apiKeyRepository) Create(key *models.APIKey) error {
+	return r.db.Create(key).Error
+}
+
+func (r *apiKeyRepository) FindByHash(keyHash string) (*models.APIKey, error) {
+	var key models.APIKey
+	err := r.db.Where("key_hash = ?", keyHash).First(&key).Error
+	if err != nil {
+		return nil, err
+	}
+	return &key, nil
+}
+
+func (r *apiKeyRepository) FindByUser(userID uint) ([]models.APIKey, error) {
+	var keys []models.APIKey
+	err := r.db.Where("user_id = ?", userID).Order("created_at DESC").Find(&keys).Error
+	return keys, err
+}
+
+func (r *apiKeyRepository) CountActiveByUser(userID uint) (int64, error) {
+	var count int64
+	err := r.db.Model(&models.APIKey{}).
+		Where("user_id = ? AND revoked_at IS NULL AND expires_at > ?", userID, time.Now()).
+		Count(&count).Error
+	return count, err
+}
+
+// TouchUsage mencatat waktu & IP pemakaian terakhir
+// Hanya ditulis jika pemakaian terakhir lebih lama dari minInterval agar tidak ada UPDATE di setiap request
+func (r *apiKeyRepository) TouchUsage(id uint, ip string, minInterval time.Duration) error {
+	now := time.Now()
+	return r.db.Model(&models.APIKey{}).
+		Where("id = ? AND (last_used_at IS NULL OR last_used_at < ?)", id, now.Add(-minInterval)).
+		UpdateColumns(map[string]interface{}{"last_used_at": now, "last_used_ip": ip}).Error
+}
+
+// Revoke me-revoke key milik user tertentu, return false jika tidak ditemukan atau sudah di-revoke
+func (r *apiKeyRepository) Revoke(userID, id uint) (bool, error) {
+	result := r.db.Model(&models.APIKey{}).
+		Where("id = ? AND user_id = ? AND revoked_at IS NULL", id, userID).
+		Update("revoked_at", time.Now())
+	if result.Error != nil {
+		return false, result.Error
+	}
+	return result.RowsAffected == 1, nil
+}
+
+// CleanupExpiredTokens menghapus key yang sudah expired (key yang di-revoke tetap tampil sampai expired)
+func (r *apiKeyRepository) CleanupExpiredTokens(batchSize int) (int64, error) {
+	result := r.db.Where("expires_at <= ?", time.Now()).
+		Limit(batchSize).
+		Delete(&models.APIKey{})
+	return result.RowsAffected, result.Error
+}
@@ -0,0 +1,112 @@
+package repositories
+
+import (
+	"errors"
+	"time"
+
+	"github.com/LutfiyaAinurrahmanP/boilerplate_fiber_restful_api/internal/cache"
+	"github.com/LutfiyaAinurrahmanP/boilerplate_fiber_restful_api/internal/models"
+	"gorm.io/gorm"
+)
+
+const (
+	blacklistCachePrefix = "token_blacklist:"
+	activeCachePrefix    = "token_active:"
+)
+
+// cachedTokenRepository adalah decorator TokenRepository
+// Token yang sudah di-revoke disimpan di cache dengan TTL sampai ExpiresAt,
+// token yang belum di-revoke disimpan dengan TTL pendek (negativeTTL),
+// sedangkan repository asli (MySQL) tetap menjadi source of truth
+type cachedTokenRepository struct {
+	repo        TokenRepository
+	store       cache.Store
+	bloom       *cache.BloomFilter // nil jika fast path negatif tidak dipakai
+	negativeTTL time.Duration      // 0 = hasil negatif tidak di-cache
+}
+
+// NewCachedTokenRepository membungkus repo dengan cache
+// Jika bloom tidak nil, filter diisi dari semua token aktif di database saat startup.
+// Bloom filter hanya aman jika semua revoke melewati instance ini (single instance).
+// Hasil negatif di-cache selama negativeTTL; revoke dari instance lain baru terlihat setelah TTL habis
+func NewCachedTokenRepository(repo TokenRepository, store cache.Store, bloom *cache.BloomFilter, negativeTTL time.Duration) (TokenRepository, error) {
+	r := &cachedTokenRepository{
+		repo:        repo,
+		store:       store,
+		bloom:       bloom,
+		negativeTTL: negativeTTL,
+	}
+
+	if bloom != nil {
+		tokens, err := repo.FindAllActive()
+		if err != nil {
+			return nil, err
+		}
+		for _, t := range tokens {
+			r.remember(&t)
+		}
+	}
+
+	return r, nil
+}
+
+func (r *cachedTokenRepository) AddToBlacklist(token *models.TokenBlacklist) error {
+	if err := r.repo.AddToBlacklist(token); err != nil {
+		return err
+	}
+	r.remember(token)
+	return nil
+}
+
+func (r *cachedTokenRepository) IsBlacklisted(tokenHash string) (bool, error) {
+	// 1. Cache hit: token pasti sudah di-revoke
+	if _, found, err := r.store.Get(blacklistCachePrefix + tokenHash); err == nil && found {
+		return true, nil
+	}
+
+	// 2. Bloom filter: token pasti belum pernah di-revoke
+	if r.bloom != nil && !r.bloom.MightContain(tokenHash) {
+		return false, nil
+	}
+
+	// 3. Cache negatif: token belum di-revoke saat terakhir dicek
+	if _, found, err := r.store.Get(activeCachePrefix + tokenHash); err == nil && found {
+		return false, nil
+	}
+
+	// 4. Fallback ke database
+	blacklisted, err := r.repo.FindBlacklisted(tokenHash)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			_ = r.store.Set(activeCachePrefix+tokenHash, "1", r.negativeTTL)
+			return false, nil
+		}
+		return false, err
+	}
+
+	r.remember(blacklisted)
+	return true, nil
+}
+
+func (r *cachedTokenRepository) FindBlacklisted(tokenHash string) (*models.TokenBlacklist, error) {
+	return r.repo.FindBlacklisted(tokenHash)
+}
+
+func (r *cachedTokenRepository) FindAllActive() ([]models.TokenBlacklist, error) {
+	return r.repo.FindAllActive()
+}
+
+func (r *cachedTokenRepository) CleanupExpiredTokens(batchSize int) (int64, error) {
+	return r.repo.CleanupExpiredTokens(batchSize)
+}
+
+// remember menyimpan token ke cache (TTL = sisa umur token) dan bloom filter,
+// sekaligus menghapus cache negatifnya
+// Error cache diabaikan karena database tetap menjadi source of truth
+func (r *cachedTokenRepository) remember(token *models.TokenBlacklist) {
+	_ = r.store.Delete(activeCachePrefix + token.TokenHash)
+	_ = r.store.Set(blacklistCachePrefix+token.TokenHash, "1", time.Until(token.ExpiresAt))
+	if r.bloom != nil {
+		r.bloom.Add(token.TokenHash)
+	}
+}
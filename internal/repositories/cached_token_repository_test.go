@@ -0,0 +1,167 @@
+package repositories
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/LutfiyaAinurrahmanP/boilerplate_fiber_restful_api/internal/cache"
+	"github.com/LutfiyaAinurrahmanP/boilerplate_fiber_restful_api/internal/models"
+	"gorm.io/gorm"
+)
+
+// fakeTokenRepository menyimpan blacklist di map dan menghitung lookup ke "database"
+type fakeTokenRepository struct {
+	tokens  map[string]models.TokenBlacklist
+	lookups int
+	err     error
+}
+
+func newFakeTokenRepository(tokens ...models.TokenBlacklist) *fakeTokenRepository {
+	repo := &fakeTokenRepository{tokens: make(map[string]models.TokenBlacklist)}
+	for _, token := range tokens {
+		repo.tokens[token.TokenHash] = token
+	}
+	return repo
+}
+
+func (f *fakeTokenRepository) AddToBlacklist(token *models.TokenBlacklist) error {
+	if f.err != nil {
+		return f.err
+	}
+	f.tokens[token.TokenHash] = *token
+	return nil
+}
+
+func (f *fakeTokenRepository) IsBlacklisted(tokenHash string) (bool, error) {
+	_, ok := f.tokens[tokenHash]
+	return ok, nil
+}
+
+func (f *fakeTokenRepository) FindBlacklisted(tokenHash string) (*models.TokenBlacklist, error) {
+	f.lookups++
+	if f.err != nil {
+		return nil, f.err
+	}
+	token, ok := f.tokens[tokenHash]
+	if !ok {
+		return nil, gorm.ErrRecordNotFound
+	}
+	return &token, nil
+}
+
+func (f *fakeTokenRepository) FindAllActive() ([]models.TokenBlacklist, error) {
+	tokens := make([]models.TokenBlacklist, 0, len(f.tokens))
+	for _, token := range f.tokens {
+		tokens = append(tokens, token)
+	}
+	return tokens, f.err
+}
+
+func (f *fakeTokenRepository) CleanupExpiredTokens(batchSize int) (int64, error) {
+	return 0, nil
+}
+
+func revoked(hash string) models.TokenBlacklist {
+	return models.TokenBlacklist{TokenHash: hash, ExpiresAt: time.Now().Add(time.Hour)}
+}
+
+func TestCachedTokenRepositoryIsBlacklisted(t *testing.T) {
+	tests := []struct {
+		name        string
+		bloom       bool
+		negativeTTL time.Duration
+		hash        string
+		want        bool
+		wantLookups int // lookup database setelah dua kali IsBlacklisted
+	}{
+		{"revoked token is cached", false, 0, "revoked", true, 1},
+		{"active token without negative cache", false, 0, "active", false, 2},
+		{"active token with negative cache", false, time.Minute, "active", false, 1},
+		{"revoked token with negative cache", false, time.Minute, "revoked", true, 1},
+		{"bloom warmed from database", true, 0, "revoked", true, 0},
+		{"bloom skips database for active token", true, 0, "active", false, 0},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			base := newFakeTokenRepository(revoked("revoked"))
+			var bloom *cache.BloomFilter
+			if tt.bloom {
+				bloom = cache.NewBloomFilter(100, 0.01)
+			}
+			repo, err := NewCachedTokenRepository(base, cache.NewMemoryStore(), bloom, tt.negativeTTL)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			for i := 0; i < 2; i++ {
+				got, err := repo.IsBlacklisted(tt.hash)
+				if err != nil {
+					t.Fatal(err)
+				}
+				if got != tt.want {
+					t.Fatalf("IsBlacklisted(%s) = %v, want %v", tt.hash, got, tt.want)
+				}
+			}
+			if base.lookups != tt.wantLookups {
+				t.Errorf("database lookups = %d, want %d", base.lookups, tt.wantLookups)
+			}
+		})
+	}
+}
+
+func TestCachedTokenRepositoryRevokeInvalidatesNegativeCache(t *testing.T) {
+	base := newFakeTokenRepository()
+	repo, err := NewCachedTokenRepository(base, cache.NewMemoryStore(), nil, time.Minute)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if blacklisted, _ := repo.IsBlacklisted("token"); blacklisted {
+		t.Fatal("token blacklisted before revoke")
+	}
+
+	token := revoked("token")
+	if err := repo.AddToBlacklist(&token); err != nil {
+		t.Fatal(err)
+	}
+
+	blacklisted, err := repo.IsBlacklisted("token")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !blacklisted {
+		t.Error("revoked token still served from negative cache")
+	}
+}
+
+func TestCachedTokenRepositoryErrors(t *testing.T) {
+	dbErr := errors.New("db down")
+	base := newFakeTokenRepository()
+	repo, err := NewCachedTokenRepository(base, cache.NewMemoryStore(), nil, time.Minute)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	base.err = dbErr
+	if _, err := repo.IsBlacklisted("token"); !errors.Is(err, dbErr) {
+		t.Errorf("IsBlacklisted() error = %v, want %v", err, dbErr)
+	}
+
+	// Error database tidak boleh di-cache sebagai "belum di-revoke"
+	base.err = nil
+	base.tokens["token"] = revoked("token")
+	if blacklisted, _ := repo.IsBlacklisted("token"); !blacklisted {
+		t.Error("failed lookup was cached as not revoked")
+	}
+
+	token := revoked("other")
+	base.err = dbErr
+	if err := repo.AddToBlacklist(&token); !errors.Is(err, dbErr) {
+		t.Errorf("AddToBlacklist() error = %v, want %v", err, dbErr)
+	}
+	if _, err := NewCachedTokenRepository(base, cache.NewMemoryStore(), cache.NewBloomFilter(10, 0.01), 0); !errors.Is(err, dbErr) {
+		t.Errorf("NewCachedTokenRepository() error = %v, want %v", err, dbErr)
+	}
+}
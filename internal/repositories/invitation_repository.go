@@ -0,0 +1,136 @@
+package repositories
+
+import (
+	"errors"
+	"time"
+
+	"github.com/LutfiyaAinurrahmanP/boilerplate_fiber_restful_api/internal/models"
+	"gorm.io/gorm"
+)
+
+type InvitationRepository interface {
+	Create(invitation *models.Invitation) error
+	Update(invitation *models.Invitation) error
+	FindByID(id uint) (*models.Invitation, error)
+	FindByHash(tokenHash string) (*models.Invitation, error)
+	FindAll(organizationID uint, status string) ([]models.Invitation, error)
+	ExistsPendingByEmail(organizationID uint, email string) (bool, error)
+	// Accept membuat user dan menandai undangan terpakai dalam satu transaksi
+	// Return false (user tidak dibuat) jika undangan sudah diterima / di-revoke bersamaan
+	Accept(id uint, user *models.User) (bool, error)
+	Revoke(id uint) (bool, error)
+}
+
+type invitationRepository struct {
+	db *gorm.DB
+}
+
+func NewInvitationRepository(db *gorm.DB) InvitationRepository {
+	return &invitationRepository{
+		db: db,
+	}
+}
+
+func (r *invitationRepository) Create(invitation *models.Invitation) error {
+	return r.db.Create(invitation).Error
+}
+
+func (r *invitationRepository) Update(invitation *models.Invitation) error {
+	return r.db.Save(invitation).Error
+}
+
+func (r *invitationRepository) FindByID(id uint) (*models.Invitation, error) {
+	var invitation models.Invitation
+	err := r.db.First(&invitation, id).Error
+	if err != nil {
+		return nil, err
+	}
+	return &invitation, nil
+}
+
+func (r *invitationRepository) FindByHash(tokenHash string) (*models.Invitation, error) {
+	var invitation models.Invitation
+	err := r.db.Where("token_hash = ?", tokenHash).First(&invitation).Error
+	if err != nil {
+		return nil, err
+	}
+	return &invitation, nil
+}
+
+// FindAll memfilter undangan organisasi berdasarkan status, "all" atau kosong mengembalikan semua
+func (r *invitationRepository) FindAll(organizationID uint, status string) ([]models.Invitation, error) {
+	var invitations []models.Invitation
+	now := time.Now()
+
+	db := r.db.Model(&models.Invitation{}).Where("organization_id = ?", organizationID)
+	switch status {
+	case models.InvitationStatusPending:
+		db = db.Where("accepted_at IS NULL AND revoked_at IS NULL AND expires_at > ?", now)
+	case models.InvitationStatusAccepted:
+		db = db.Where("accepted_at IS NOT NULL")
+	case models.InvitationStatusRevoked:
+		db = db.Where("accepted_at IS NULL AND revoked_at IS NOT NULL")
+	case models.InvitationStatusExpired:
+		db = db.Where("accepted_at IS NULL AND revoked_at IS NULL AND expires_at <= ?", now)
+	}
+
+	err := db.Order("id desc").Find(&invitations).Error
+	if err != nil {
+		return nil, err
+	}
+	return invitations, nil
+}
+
+func (r *invitationRepository) ExistsPendingByEmail(organizationID uint, email string) (bool, error) {
+	var count int64
+	err := r.db.Model(&models.Invitation{}).
+		Where("organization_id = ?", organizationID).
+		Where("email = ? AND accepted_at IS NULL AND revoked_at IS NULL AND expires_at > ?", email, time.Now()).
+		Count(&count).Error
+	return count > 0, err
+}
+
+// errInvitationUnavailable membatalkan transaksi Accept jika undangan sudah tidak pending
+var errInvitationUnavailable = errors.New("invitation is no longer pending")
+
+func (r *invitationRepository) Accept(id uint, user *models.User) (bool, error) {
+	err := r.db.Transaction(func(tx *gorm.DB) error {
+		// Keanggotaan organisasi asal dibuat oleh hook models.User.AfterCreate di transaksi yang sama
+		if err := tx.Create(user).Error; err != nil {
+			return err
+		}
+
+		// Conditional update, undangan hanya bisa dipakai sekali dan tidak setelah di-revoke
+		result := tx.Model(&models.Invitation{}).
+			Where("id = ? AND accepted_at IS NULL AND revoked_at IS NULL", id).
+			Updates(map[string]interface{}{
+				"accepted_at":      time.Now(),
+				"accepted_user_id": user.ID,
+			})
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected != 1 {
+			return errInvitationUnavailable
+		}
+		return nil
+	})
+	if errors.Is(err, errInvitationUnavailable) {
+		return false, nil
+	}
+	if err != nil {
+		return false, err
+	}
+	return true, nil
+}
+
+// Revoke return false jika undangan sudah diterima / sudah di-revoke
+func (r *invitationRepository) Revoke(id uint) (bool, error) {
+	result := r.db.Model(&models.Invitation{}).
+		Where("id = ? AND accepted_at IS NULL AND revoked_at IS NULL", id).
+		Update("revoked_at", time.Now())
+	if result.Error != nil {
+		return false, result.Error
+	}
+	return result.RowsAffected == 1, nil
+}
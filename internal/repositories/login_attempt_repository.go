@@ -0,0 +1,113 @@
+package repositories
+
+import (
+	"errors"
+	"time"
+
+	"github.com/LutfiyaAinurrahmanP/boilerplate_fiber_restful_api/internal/models"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type LoginAttemptRepository interface {
+	FindByKeys(keys []string) ([]models.LoginAttempt, error)
+	RecordFailure(key string) (*models.LoginAttempt, error)
+	Lock(key string, until time.Time) error
+	Reset(key string) (bool, error)
+	CreateEvent(event *models.LockoutEvent) error
+	FindEventsByUser(userID uint) ([]models.LockoutEvent, error)
+	CleanupExpiredTokens(batchSize int) (int64, error)
+}
+
+type loginAttemptRepository struct {
+	db     *gorm.DB
+	window time.Duration
+}
+
+// window adalah rentang waktu login gagal dihitung berturut-turut
+func NewLoginAttemptRepository(db *gorm.DB, window time.Duration) LoginAttemptRepository {
+	return &loginAttemptRepository{
+		db:     db,
+		window: window,
+	}
+}
+
+func (r *loginAttemptRepository) FindByKeys(keys []string) ([]models.LoginAttempt, error) {
+	var attempts []models.LoginAttempt
+	err := r.db.Where("`key` IN ?", keys).Find(&attempts).Error
+	if err != nil {
+		return nil, err
+	}
+	return attempts, nil
+}
+
+// RecordFailure menambah counter secara atomic (SELECT ... FOR UPDATE)
+// Counter dimulai ulang jika login gagal terakhir sudah lebih lama dari window
+func (r *loginAttemptRepository) RecordFailure(key string) (*models.LoginAttempt, error) {
+	var attempt models.LoginAttempt
+	err := r.db.Transaction(func(tx *gorm.DB) error {
+		now := time.Now()
+
+		err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+			Where("`key` = ?", key).
+			First(&attempt).Error
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			attempt = models.LoginAttempt{Key: key, Failures: 1, LastFailureAt: now}
+			return tx.Create(&attempt).Error
+		}
+		if err != nil {
+			return err
+		}
+
+		if now.Sub(attempt.LastFailureAt) > r.window {
+			attempt.Failures = 0
+		}
+		attempt.Failures++
+		attempt.LastFailureAt = now
+		return tx.Save(&attempt).Error
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &attempt, nil
+}
+
+func (r *loginAttemptRepository) Lock(key string, until time.Time) error {
+	return r.db.Model(&models.LoginAttempt{}).
+		Where("`key` = ?", key).
+		Update("locked_until", until).Error
+}
+
+// Reset menghapus counter, return false jika key tidak punya login gagal tercatat
+func (r *loginAttemptRepository) Reset(key string) (bool, error) {
+	result := r.db.Where("`key` = ?", key).Delete(&models.LoginAttempt{})
+	if result.Error != nil {
+		return false, result.Error
+	}
+	return result.RowsAffected > 0, nil
+}
+
+func (r *loginAttemptRepository) CreateEvent(event *models.LockoutEvent) error {
+	return r.db.Create(event).Error
+}
+
+func (r *loginAttemptRepository) FindEventsByUser(userID uint) ([]models.LockoutEvent, error) {
+	var events []models.LockoutEvent
+	err := r.db.Where("user_id = ?", userID).
+		Order("id desc").
+		Limit(100).
+		Find(&events).Error
+	if err != nil {
+		return nil, err
+	}
+	return events, nil
+}
+
+// CleanupExpiredTokens menghapus counter yang sudah di luar window dan tidak sedang terkunci
+func (r *loginAttemptRepository) CleanupExpiredTokens(batchSize int) (int64, error) {
+	now := time.Now()
+	result := r.db.Where("last_failure_at <= ? AND (locked_until IS NULL OR locked_until <= ?)", now.Add(-r.window), now).
+		Limit(batchSize).
+		Delete(&models.LoginAttempt{})
+	return result.RowsAffected, result.Error
+}
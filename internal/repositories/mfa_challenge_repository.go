@@ -0,0 +1,63 @@
+package repositories
+
+import (
+	"time"
+
+	"github.com/LutfiyaAinurrahmanP/boilerplate_fiber_restful_api/internal/models"
+	"gorm.io/gorm"
+)
+
+type MFAChallengeRepository interface {
+	Create(challenge *models.MFAChallenge) error
+	FindByHash(tokenHash string) (*models.MFAChallenge, error)
+	IncrementAttempts(id uint) error
+	MarkConsumed(id uint) (bool, error)
+	CleanupExpiredTokens(batchSize int) (int64, error)
+}
+
+type mfaChallengeRepository struct {
+	db *gorm.DB
+}
+
+func NewMFAChallengeRepository(db *gorm.DB) MFAChallengeRepository {
+	return &mfaChallengeRepository{
+		db: db,
+	}
+}
+
+func (r *mfaChallengeRepository) Create(challenge *models.MFAChallenge) error {
+	return r.db.Create(challenge).Error
+}
+
+func (r *mfaChallengeRepository) FindByHash(tokenHash string) (*models.MFAChallenge, error) {
+	var challenge models.MFAChallenge
+	err := r.db.Where("token_hash = ?", tokenHash).First(&challenge).Error
+	if err != nil {
+		return nil, err
+	}
+	return &challenge, nil
+}
+
+func (r *mfaChallengeRepository) IncrementAttempts(id uint) error {
+	return r.db.Model(&models.MFAChallenge{}).
+		Where("id = ?", id).
+		UpdateColumn("attempts", gorm.Expr("attempts + 1")).Error
+}
+
+// MarkConsumed menandai challenge terpakai secara atomic
+func (r *mfaChallengeRepository) MarkConsumed(id uint) (bool, error) {
+	result := r.db.Model(&models.MFAChallenge{}).
+		Where("id = ? AND consumed_at IS NULL", id).
+		Update("consumed_at", time.Now())
+	if result.Error != nil {
+		return false, result.Error
+	}
+	return result.RowsAffected == 1, nil
+}
+
+func (r *mfaChallengeRepository) CleanupExpiredTokens(batchSize int) (int64, error) {
+	result := r.db.Where("expires_at <= ?", time.Now()).
+		Limit(batchSize).
+		Delete(&models.MFAChallenge{})
+	return result.RowsAffected, result.Error
+}
@@ -0,0 +1,70 @@
+package repositories
+
+import (
+	"time"
+
+	"github.com/LutfiyaAinurrahmanP/boilerplate_fiber_restful_api/internal/models"
+	"gorm.io/gorm"
+)
+
+type OAuthClientRepository interface {
+	Create(client *models.OAuthClient) error
+	Update(client *models.OAuthClient) error
+	FindAll() ([]models.OAuthClient, error)
+	FindByID(id uint) (*models.OAuthClient, error)
+	FindByClientID(clientID string) (*models.OAuthClient, error)
+	Disable(id uint) (bool, error)
+}
+
+type oauthClientRepository struct {
+	db *gorm.DB
+}
+
+func NewOAuthClientRepository(db *gorm.DB) OAuthClientRepository {
+	return &oauthClientRepository{
+		db: db,
+	}
+}
+
+func (r *oauthClientRepository) Create(client *models.OAuthClient) error {
+	return r.db.Create(client).Error
+}
+
+func (r *oauthClientRepository) Update(client *models.OAuthClient) error {
+	return r.db.Save(client).Error
+}
+
+func (r *oauthClientRepository) FindAll() ([]models.OAuthClient, error) {
+	var clients []models.OAuthClient
+	err := r.db.Order("created_at DESC").Find(&clients).Error
+	return clients, err
+}
+
+func (r *oauthClientRepository) FindByID(id uint) (*models.OAuthClient, error) {
+	var client models.OAuthClient
+	err := r.db.First(&client, id).Error
+	if err != nil {
+		return nil, err
+	}
+	return &client, nil
+}
+
+func (r *oauthClientRepository) FindByClientID(clientID string) (*models.OAuthClient, error) {
+	var client models.OAuthClient
+	err := r.db.Where("client_id = ?", clientID).First(&client).Error
+	if err != nil {
+		return nil, err
+	}
+	return &client, nil
+}
+
+// Disable menonaktifkan client, return false jika tidak ditemukan atau sudah nonaktif
+func (r *oauthClientRepository) Disable(id uint) (bool, error) {
+	result := r.db.Model(&models.OAuthClient{}).
+		Where("id = ? AND disabled_at IS NULL", id).
+		Update("disabled_at", time.Now())
+	if result.Error != nil {
+		return false, result.Error
+	}
+	return result.RowsAffected == 1, nil
+}
@@ -0,0 +1,56 @@
+package repositories
+
+import (
+	"time"
+
+	"github.com/LutfiyaAinurrahmanP/boilerplate_fiber_restful_api/internal/models"
+	"gorm.io/gorm"
+)
+
+type OAuthCodeRepository interface {
+	Create(code *models.OAuthAuthorizationCode) error
+	FindByHash(codeHash string) (*models.OAuthAuthorizationCode, error)
+	MarkConsumed(id uint) (bool, error)
+	CleanupExpiredTokens(batchSize int) (int64, error)
+}
+
+type oauthCodeRepository struct {
+	db *gorm.DB
+}
+
+func NewOAuthCodeRepository(db *gorm.DB) OAuthCodeRepository {
+	return &oauthCodeRepository{
+		db: db,
+	}
+}
+
+func (r *oauthCodeRepository) Create(code *models.OAuthAuthorizationCode) error {
+	return r.db.Create(code).Error
+}
+
+func (r *oauthCodeRepository) FindByHash(codeHash string) (*models.OAuthAuthorizationCode, error) {
+	var code models.OAuthAuthorizationCode
+	err := r.db.Where("code_hash = ?", codeHash).First(&code).Error
+	if err != nil {
+		return nil, err
+	}
+	return &code, nil
+}
+
+// MarkConsumed menandai code terpakai secara atomic, return false jika code sudah pernah ditukar
+func (r *oauthCodeRepository) MarkConsumed(id uint) (bool, error) {
+	result := r.db.Model(&models.OAuthAuthorizationCode{}).
+		Where("id = ? AND consumed_at IS NULL", id).
+		Update("consumed_at", time.Now())
+	if result.Error != nil {
+		return false, result.Error
+	}
+	return result.RowsAffected == 1, nil
+}
+
+func (r *oauthCodeRepository) CleanupExpiredTokens(batchSize int) (int64, error) {
+	result := r.db.Where("expires_at <= ?", time.Now()).
+		Limit(batchSize).
+		Delete(&models.OAuthAuthorizationCode{})
+	return result.RowsAffected, result.Error
+}
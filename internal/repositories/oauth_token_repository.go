@@ -0,0 +1,97 @@
+package repositories
+
+import (
+	"time"
+
+	"github.com/LutfiyaAinurrahmanP/boilerplate_fiber_restful_api/internal/models"
+	"gorm.io/gorm"
+)
+
+type OAuthTokenRepository interface {
+	Create(token *models.OAuthToken) error
+	FindByJTI(jti string) (*models.OAuthToken, error)
+	FindByRefreshHash(refreshHash string) (*models.OAuthToken, error)
+	Revoke(id uint) (bool, error)
+	RevokeFamily(familyID string) error
+	RevokeByAuthorizationCode(codeID uint) error
+	RevokeByClient(clientID string) error
+	CleanupExpiredTokens(batchSize int) (int64, error)
+}
+
+type oauthTokenRepository struct {
+	db *gorm.DB
+}
+
+func NewOAuthTokenRepository(db *gorm.DB) OAuthTokenRepository {
+	return &oauthTokenRepository{
+		db: db,
+	}
+}
+
+func (r *oauthTokenRepository) Create(token *models.OAuthToken) error {
+	return r.db.Create(token).Error
+}
+
+func (r *oauthTokenRepository) FindByJTI(jti string) (*models.OAuthToken, error) {
+	var token models.OAuthToken
+	err := r.db.Where("access_jti = ?", jti).First(&token).Error
+	if err != nil {
+		return nil, err
+	}
+	return &token, nil
+}
+
+func (r *oauthTokenRepository) FindByRefreshHash(refreshHash string) (*models.OAuthToken, error) {
+	var token models.OAuthToken
+	err := r.db.Where("refresh_hash = ?", refreshHash).First(&token).Error
+	if err != nil {
+		return nil, err
+	}
+	return &token, nil
+}
+
+// Revoke me-revoke satu token secara atomic, return false jika sudah di-revoke (dipakai untuk deteksi reuse refresh token)
+func (r *oauthTokenRepository) Revoke(id uint) (bool, error) {
+	result := r.db.Model(&models.OAuthToken{}).
+		Where("id = ? AND revoked_at IS NULL", id).
+		Update("revoked_at", time.Now())
+	if result.Error != nil {
+		return false, result.Error
+	}
+	return result.RowsAffected == 1, nil
+}
+
+func (r *oauthTokenRepository) RevokeFamily(familyID string) error {
+	return r.db.Model(&models.OAuthToken{}).
+		Where("family_id = ? AND revoked_at IS NULL", familyID).
+		Update("revoked_at", time.Now()).Error
+}
+
+// RevokeByAuthorizationCode me-revoke semua token hasil code yang dipakai ulang (RFC 6749 section 4.1.2)
+func (r *oauthTokenRepository) RevokeByAuthorizationCode(codeID uint) error {
+	var familyIDs []string
+	err := r.db.Model(&models.OAuthToken{}).
+		Where("authorization_code_id = ?", codeID).
+		Distinct().Pluck("family_id", &familyIDs).Error
+	if err != nil || len(familyIDs) == 0 {
+		return err
+	}
+	return r.db.Model(&models.OAuthToken{}).
+		Where("family_id IN ? AND revoked_at IS NULL", familyIDs).
+		Update("revoked_at", time.Now()).Error
+}
+
+func (r *oauthTokenRepository) RevokeByClient(clientID string) error {
+	return r.db.Model(&models.OAuthToken{}).
+		Where("client_id = ? AND revoked_at IS NULL", clientID).
+		Update("revoked_at", time.Now()).Error
+}
+
+// CleanupExpiredTokens menghapus token yang access token-nya expired dan refresh token-nya (jika ada) juga expired
+func (r *oauthTokenRepository) CleanupExpiredTokens(batchSize int) (int64, error) {
+	now := time.Now()
+	result := r.db.Where("access_expires_at <= ? AND (refresh_expires_at IS NULL OR refresh_expires_at <= ?)", now, now).
+		Limit(batchSize).
+		Delete(&models.OAuthToken{})
+	return result.RowsAffected, result.Error
+}
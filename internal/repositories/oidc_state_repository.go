@@ -0,0 +1,56 @@
+package repositories
+
+import (
+	"time"
+
+	"github.com/LutfiyaAinurrahmanP/boilerplate_fiber_restful_api/internal/models"
+	"gorm.io/gorm"
+)
+
+type OIDCStateRepository interface {
+	Create(state *models.OIDCLoginState) error
+	FindByHash(stateHash string) (*models.OIDCLoginState, error)
+	MarkConsumed(id uint) (bool, error)
+	CleanupExpiredTokens(batchSize int) (int64, error)
+}
+
+type oidcStateRepository struct {
+	db *gorm.DB
+}
+
+func NewOIDCStateRepository(db *gorm.DB) OIDCStateRepository {
+	return &oidcStateRepository{
+		db: db,
+	}
+}
+
+func (r *oidcStateRepository) Create(state *models.OIDCLoginState) error {
+	return r.db.Create(state).Error
+}
+
+func (r *oidcStateRepository) FindByHash(stateHash string) (*models.OIDCLoginState, error) {
+	var state models.OIDCLoginState
+	err := r.db.Where("state_hash = ?", stateHash).First(&state).Error
+	if err != nil {
+		return nil, err
+	}
+	return &state, nil
+}
+
+// MarkConsumed menandai state terpakai secara atomic (callback yang sama tidak bisa diputar ulang)
+func (r *oidcStateRepository) MarkConsumed(id uint) (bool, error) {
+	result := r.db.Model(&models.OIDCLoginState{}).
+		Where("id = ? AND consumed_at IS NULL", id).
+		Update("consumed_at", time.Now())
+	if result.Error != nil {
+		return false, result.Error
+	}
+	return result.RowsAffected == 1, nil
+}
+
+func (r *oidcStateRepository) CleanupExpiredTokens(batchSize int) (int64, error) {
+	result := r.db.Where("expires_at <= ?", time.Now()).
+		Limit(batchSize).
+		Delete(&models.OIDCLoginState{})
+	return result.RowsAffected, result.Error
+}
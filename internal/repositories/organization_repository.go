@@ -0,0 +1,179 @@
+package repositories
+
+import (
+	"errors"
+
+	"github.com/LutfiyaAinurrahmanP/boilerplate_fiber_restful_api/internal/models"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// ErrLastAdmin dikembalikan jika perubahan akan menghilangkan admin aktif terakhir sebuah organisasi
+var ErrLastAdmin = errors.New("organization must keep at least one active admin")
+
+type OrganizationRepository interface {
+	Create(organization *models.Organization) error
+	Update(organization *models.Organization) error
+	FindAll() ([]models.Organization, error)
+	FindByID(id uint) (*models.Organization, error)
+	FindBySlug(slug string) (*models.Organization, error)
+
+	// Keanggotaan
+	AddMember(member *models.OrganizationMember) error
+	// RemoveMember mengembalikan ErrLastAdmin jika user adalah admin aktif terakhir organisasi
+	RemoveMember(organizationID, userID uint) error
+	FindMember(organizationID, userID uint) (*models.OrganizationMember, error)
+	FindMembers(organizationID uint) ([]models.OrganizationMember, error)
+	FindMembershipsByUser(userID uint) ([]models.OrganizationMember, error)
+	CountActiveMembers(organizationID uint, role string) (int64, error)
+}
+
+type organizationRepository struct {
+	db *gorm.DB
+}
+
+func NewOrganizationRepository(db *gorm.DB) OrganizationRepository {
+	return &organizationRepository{
+		db: db,
+	}
+}
+
+func (r *organizationRepository) Create(organization *models.Organization) error {
+	return r.db.Create(organization).Error
+}
+
+func (r *organizationRepository) Update(organization *models.Organization) error {
+	return r.db.Save(organization).Error
+}
+
+func (r *organizationRepository) FindAll() ([]models.Organization, error) {
+	var organizations []models.Organization
+	err := r.db.Order("id ASC").Find(&organizations).Error
+	return organizations, err
+}
+
+func (r *organizationRepository) FindByID(id uint) (*models.Organization, error) {
+	var organization models.Organization
+	err := r.db.First(&organization, id).Error
+	if err != nil {
+		return nil, err
+	}
+	return &organization, nil
+}
+
+func (r *organizationRepository) FindBySlug(slug string) (*models.Organization, error) {
+	var organization models.Organization
+	err := r.db.Where("slug = ?", slug).First(&organization).Error
+	if err != nil {
+		return nil, err
+	}
+	return &organization, nil
+}
+
+func (r *organizationRepository) AddMember(member *models.OrganizationMember) error {
+	return r.db.Omit("Organization").Create(member).Error
+}
+
+func (r *organizationRepository) RemoveMember(organizationID, userID uint) error {
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		if err := ensureAdminRemains(tx, userID, organizationID); err != nil {
+			return err
+		}
+		if err := tx.Where("organization_id = ? AND user_id = ?", organizationID, userID).
+			Delete(&models.OrganizationMember{}).Error; err != nil {
+			return err
+		}
+		// Token yang masih aktif di organisasi ini langsung ditolak
+		return incrementTokenVersion(tx, userID)
+	})
+}
+
+func (r *organizationRepository) FindMember(organizationID, userID uint) (*models.OrganizationMember, error) {
+	var member models.OrganizationMember
+	err := r.db.Where("organization_id = ? AND user_id = ?", organizationID, userID).First(&member).Error
+	if err != nil {
+		return nil, err
+	}
+	return &member, nil
+}
+
+func (r *organizationRepository) FindMembers(organizationID uint) ([]models.OrganizationMember, error) {
+	var members []models.OrganizationMember
+	err := r.db.Where("organization_id = ?", organizationID).Order("id ASC").Find(&members).Error
+	return members, err
+}
+
+// FindMembershipsByUser mengembalikan semua organisasi tempat user menjadi anggota beserta data organisasinya
+func (r *organizationRepository) FindMembershipsByUser(userID uint) ([]models.OrganizationMember, error) {
+	var members []models.OrganizationMember
+	err := r.db.Preload("Organization").Where("user_id = ?", userID).Order("organization_id ASC").Find(&members).Error
+	return members, err
+}
+
+// CountActiveMembers menghitung anggota organisasi dengan role tertentu yang user-nya belum di-soft delete
+func (r *organizationRepository) CountActiveMembers(organizationID uint, role string) (int64, error) {
+	var count int64
+	err := r.db.Model(&models.OrganizationMember{}).
+		Joins("JOIN users ON users.id = organization_members.user_id AND users.deleted_at IS NULL").
+		Where("organization_members.organization_id = ? AND organization_members.role = ?", organizationID, role).
+		Count(&count).Error
+	return count, err
+}
+
+// ensureAdminRemains dipanggil di dalam transaksi sebelum user kehilangan akses admin
+// (role diturunkan, keanggotaan dicabut, soft / hard delete) di organizationID, 0 berarti semua organisasinya
+//
+// Baris organisasi tempat user menjadi admin dikunci lebih dulu (SELECT ... FOR UPDATE, urut id) sebagai mutex per organisasi,
+// sehingga request bersamaan yang menurunkan / menghapus admin lain di organisasi yang sama menunggu transaksi ini selesai
+// lalu membaca hasilnya. ErrLastAdmin jika ada organisasi yang tidak punya admin aktif selain user tersebut
+func ensureAdminRemains(tx *gorm.DB, userID, organizationID uint) error {
+	adminOrganizations := tx.Model(&models.OrganizationMember{}).
+		Select("organization_id").
+		Where("user_id = ? AND role = ?", userID, models.RoleAdmin)
+	if organizationID != 0 {
+		adminOrganizations = adminOrganizations.Where("organization_id = ?", organizationID)
+	}
+
+	var organizations []models.Organization
+	err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+		Where("id IN (?)", adminOrganizations).
+		Order("id ASC").
+		Find(&organizations).Error
+	if err != nil {
+		return err
+	}
+	if len(organizations) == 0 {
+		return nil
+	}
+
+	ids := make([]uint, len(organizations))
+	for i, organization := range organizations {
+		ids[i] = organization.ID
+	}
+
+	// Dibaca ulang setelah mendapat lock agar mengikuti perubahan yang baru di-commit request lain
+	var admins []models.OrganizationMember
+	err = tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+		Joins("JOIN users ON users.id = organization_members.user_id AND users.deleted_at IS NULL").
+		Where("organization_members.organization_id IN ? AND organization_members.role = ?", ids, models.RoleAdmin).
+		Find(&admins).Error
+	if err != nil {
+		return err
+	}
+
+	isAdmin := make(map[uint]bool)
+	others := make(map[uint]int)
+	for _, admin := range admins {
+		if admin.UserID == userID {
+			isAdmin[admin.OrganizationID] = true
+			continue
+		}
+		others[admin.OrganizationID]++
+	}
+	for _, id := range ids {
+		if isAdmin[id] && others[id] == 0 {
+			return ErrLastAdmin
+		}
+	}
+	return nil
+}
@@ -0,0 +1,82 @@
+package repositories
+
+import (
+	"errors"
+	"time"
+
+	"github.com/LutfiyaAinurrahmanP/boilerplate_fiber_restful_api/internal/models"
+	"gorm.io/gorm"
+)
+
+type PasswordResetRepository interface {
+	Create(token *models.PasswordResetToken) error
+	FindByHash(tokenHash string) (*models.PasswordResetToken, error)
+	// ResetPassword menandai token terpakai dan menyimpan password baru user dalam satu transaksi
+	// Return false (password tidak diubah) jika token sudah dipakai oleh request lain
+	ResetPassword(id, userID uint, hashedPassword string) (bool, error)
+	InvalidateForUser(userID uint) error
+	CleanupExpiredTokens(batchSize int) (int64, error)
+}
+
+type passwordResetRepository struct {
+	db *gorm.DB
+}
+
+func NewPasswordResetRepository(db *gorm.DB) PasswordResetRepository {
+	return &passwordResetRepository{
+		db: db,
+	}
+}
+
+func (r *passwordResetRepository) Create(token *models.PasswordResetToken) error {
+	return r.db.Create(token).Error
+}
+
+func (r *passwordResetRepository) FindByHash(tokenHash string) (*models.PasswordResetToken, error) {
+	var token models.PasswordResetToken
+	err := r.db.Where("token_hash = ?", tokenHash).First(&token).Error
+	if err != nil {
+		return nil, err
+	}
+	return &token, nil
+}
+
+// errResetTokenUsed membatalkan transaksi ResetPassword jika token sudah dipakai
+var errResetTokenUsed = errors.New("reset token already used")
+
+func (r *passwordResetRepository) ResetPassword(id, userID uint, hashedPassword string) (bool, error) {
+	err := r.db.Transaction(func(tx *gorm.DB) error {
+		// Conditional update, token hanya bisa dipakai sekali walaupun ada request bersamaan
+		result := tx.Model(&models.PasswordResetToken{}).
+			Where("id = ? AND used_at IS NULL", id).
+			Update("used_at", time.Now())
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected != 1 {
+			return errResetTokenUsed
+		}
+		return updatePassword(tx, userID, hashedPassword)
+	})
+	if errors.Is(err, errResetTokenUsed) {
+		return false, nil
+	}
+	if err != nil {
+		return false, err
+	}
+	return true, nil
+}
+
+// InvalidateForUser membuat semua token reset milik user yang belum dipakai menjadi tidak berlaku
+func (r *passwordResetRepository) InvalidateForUser(userID uint) error {
+	return r.db.Model(&models.PasswordResetToken{}).
+		Where("user_id = ? AND used_at IS NULL", userID).
+		Update("used_at", time.Now()).Error
+}
+
+func (r *passwordResetRepository) CleanupExpiredTokens(batchSize int) (int64, error) {
+	result := r.db.Where("expires_at <= ?", time.Now()).
+		Limit(batchSize).
+		Delete(&models.PasswordResetToken{})
+	return result.RowsAffected, result.Error
+}
@@ -0,0 +1,96 @@
+package repositories
+
+import (
+	"github.com/LutfiyaAinurrahmanP/boilerplate_fiber_restful_api/internal/models"
+	"gorm.io/gorm"
+)
+
+type RoleRepository interface {
+	Create(role *models.Role) error
+	Update(role *models.Role) error
+	Delete(role *models.Role) error
+	FindAll() ([]models.Role, error)
+	FindByID(id uint) (*models.Role, error)
+	FindByName(name string) (*models.Role, error)
+	CountUsers(name string) (int64, error)
+	FindAllPermissions() ([]models.Permission, error)
+	FindPermissionsByNames(names []string) ([]models.Permission, error)
+}
+
+type roleRepository struct {
+	db *gorm.DB
+}
+
+func NewRoleRepository(db *gorm.DB) RoleRepository {
+	return &roleRepository{
+		db: db,
+	}
+}
+
+// Create menyimpan role beserta baris role_permissions (data permission tidak ikut di-upsert)
+func (r *roleRepository) Create(role *models.Role) error {
+	return r.db.Omit("Permissions.*").Create(role).Error
+}
+
+// Update menyimpan role dan mengganti seluruh permission-nya dalam satu transaksi
+func (r *roleRepository) Update(role *models.Role) error {
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Omit("Permissions").Save(role).Error; err != nil {
+			return err
+		}
+		return tx.Model(role).Omit("Permissions.*").Association("Permissions").Replace(role.Permissions)
+	})
+}
+
+// Delete menghapus role beserta baris role_permissions-nya
+func (r *roleRepository) Delete(role *models.Role) error {
+	return r.db.Select("Permissions").Delete(role).Error
+}
+
+func (r *roleRepository) FindAll() ([]models.Role, error) {
+	var roles []models.Role
+	err := r.db.Preload("Permissions").Order("id asc").Find(&roles).Error
+	return roles, err
+}
+
+func (r *roleRepository) FindByID(id uint) (*models.Role, error) {
+	var role models.Role
+	err := r.db.Preload("Permissions").First(&role, id).Error
+	if err != nil {
+		return nil, err
+	}
+	return &role, nil
+}
+
+func (r *roleRepository) FindByName(name string) (*models.Role, error) {
+	var role models.Role
+	err := r.db.Preload("Permissions").Where("name = ?", name).First(&role).Error
+	if err != nil {
+		return nil, err
+	}
+	return &role, nil
+}
+
+// CountUsers menghitung user dengan role tertentu, termasuk yang di-soft delete (bisa di-restore)
+// dan user yang memakai role tersebut di organisasi selain organisasi asalnya
+func (r *roleRepository) CountUsers(name string) (int64, error) {
+	var count int64
+	members := r.db.Model(&models.OrganizationMember{}).Select("user_id").Where("role = ?", name)
+	err := r.db.Unscoped().Model(&models.User{}).Where("role = ? OR id IN (?)", name, members).Count(&count).Error
+	return count, err
+}
+
+func (r *roleRepository) FindAllPermissions() ([]models.Permission, error) {
+	var permissions []models.Permission
+	err := r.db.Order("name asc").Find(&permissions).Error
+	return permissions, err
+}
+
+func (r *roleRepository) FindPermissionsByNames(names []string) ([]models.Permission, error) {
+	var permissions []models.Permission
+	if len(names) == 0 {
+		return permissions, nil
+	}
+	err := r.db.Where("name IN ?", names).Find(&permissions).Error
+	return permissions, err
+}
@@ -0,0 +1,93 @@
+package repositories
+
+import (
+	"time"
+
+	"github.com/LutfiyaAinurrahmanP/boilerplate_fiber_restful_api/internal/models"
+	"gorm.io/gorm"
+)
+
+// lastSeenInterval membatasi update last_seen_at agar tidak ada write di setiap request
+const lastSeenInterval = time.Minute
+
+type SessionRepository interface {
+	Create(session *models.Session) error
+	FindByID(id string) (*models.Session, error)
+	FindActiveByUser(userID uint) ([]models.Session, error)
+	Touch(id string) error
+	Extend(id string, expiresAt time.Time) error
+	Revoke(id string) error
+	MarkMFAVerified(id string) error
+	SetOrganization(id string, organizationID uint) error
+}
+
+type sessionRepository struct {
+	db *gorm.DB
+}
+
+func NewSessionRepository(db *gorm.DB) SessionRepository {
+	return &sessionRepository{
+		db: db,
+	}
+}
+
+func (r *sessionRepository) Create(session *models.Session) error {
+	return r.db.Create(session).Error
+}
+
+func (r *sessionRepository) FindByID(id string) (*models.Session, error) {
+	var session models.Session
+	err := r.db.Where("id = ?", id).First(&session).Error
+	if err != nil {
+		return nil, err
+	}
+	return &session, nil
+}
+
+func (r *sessionRepository) FindActiveByUser(userID uint) ([]models.Session, error) {
+	var sessions []models.Session
+	err := r.db.Where("user_id = ? AND revoked_at IS NULL AND expires_at > ?", userID, time.Now()).
+		Order("last_seen_at desc").
+		Find(&sessions).Error
+	if err != nil {
+		return nil, err
+	}
+	return sessions, nil
+}
+
+// Touch memperbarui last_seen_at, paling banyak sekali per lastSeenInterval
+func (r *sessionRepository) Touch(id string) error {
+	now := time.Now()
+	return r.db.Model(&models.Session{}).
+		Where("id = ? AND last_seen_at < ?", id, now.Add(-lastSeenInterval)).
+		Update("last_seen_at", now).Error
+}
+
+// Extend dipanggil saat refresh token di-rotate (sliding expiration)
+func (r *sessionRepository) Extend(id string, expiresAt time.Time) error {
+	return r.db.Model(&models.Session{}).
+		Where("id = ?", id).
+		Updates(map[string]interface{}{
+			"last_seen_at": time.Now(),
+			"expires_at":   expiresAt,
+		}).Error
+}
+
+func (r *sessionRepository) Revoke(id string) error {
+	return r.db.Model(&models.Session{}).
+		Where("id = ? AND revoked_at IS NULL", id).
+		Update("revoked_at", time.Now()).Error
+}
+
+func (r *sessionRepository) MarkMFAVerified(id string) error {
+	return r.db.Model(&models.Session{}).
+		Where("id = ?", id).
+		Update("mfa_verified_at", time.Now()).Error
+}
+
+// SetOrganization mengganti organisasi aktif session, dipakai saat user berpindah organisasi
+func (r *sessionRepository) SetOrganization(id string, organizationID uint) error {
+	return r.db.Model(&models.Session{}).
+		Where("id = ?", id).
+		Update("organization_id", organizationID).Error
+}
@@ -9,8 +9,10 @@ import (
 
 type TokenRepository interface {
 	AddToBlacklist(token *models.TokenBlacklist) error
-	IsBlacklisted(token string) (bool, error)
-	CleanupExpiredTokens() error
+	IsBlacklisted(tokenHash string) (bool, error)
+	FindBlacklisted(tokenHash string) (*models.TokenBlacklist, error)
+	FindAllActive() ([]models.TokenBlacklist, error)
+	CleanupExpiredTokens(batchSize int) (int64, error)
 }
 
 type tokenRepository struct {
@@ -27,10 +29,11 @@ func (r *tokenRepository) AddToBlacklist(token *models.TokenBlacklist) error {
 	return r.db.Create(token).Error
 }
 
-func (r *tokenRepository) IsBlacklisted(token string) (bool, error) {
+// IsBlacklisted menerima SHA-256 digest token (utils.HashToken), bukan token asli
+func (r *tokenRepository) IsBlacklisted(tokenHash string) (bool, error) {
 	var count int64
 	err := r.db.Model(&models.TokenBlacklist{}).
-		Where("token = ? AND expires_at > ?", token, time.Now()).
+		Where("token_hash = ? AND expires_at > ?", tokenHash, time.Now()).
 		Count(&count).Error
 
 	if err != nil {
@@ -40,7 +43,31 @@ func (r *tokenRepository) IsBlacklisted(token string) (bool, error) {
 	return count > 0, nil
 }
 
-func (r *tokenRepository) CleanupExpiredTokens() error {
-	return r.db.Where("expires_at <= ?", time.Now()).
-		Delete(&models.TokenBlacklist{}).Error
+// FindBlacklisted mengembalikan entry blacklist yang masih aktif (gorm.ErrRecordNotFound jika tidak ada)
+func (r *tokenRepository) FindBlacklisted(tokenHash string) (*models.TokenBlacklist, error) {
+	var blacklisted models.TokenBlacklist
+	err := r.db.Where("token_hash = ? AND expires_at > ?", tokenHash, time.Now()).
+		First(&blacklisted).Error
+	if err != nil {
+		return nil, err
+	}
+	return &blacklisted, nil
+}
+
+func (r *tokenRepository) FindAllActive() ([]models.TokenBlacklist, error) {
+	var tokens []models.TokenBlacklist
+	err := r.db.Where("expires_at > ?", time.Now()).Find(&tokens).Error
+	if err != nil {
+		return nil, err
+	}
+	return tokens, nil
+}
+
+// CleanupExpiredTokens menghapus token expired maksimal batchSize baris per panggilan
+// Batch kecil menjaga lock table tetap singkat, return jumlah baris yang dihapus
+func (r *tokenRepository) CleanupExpiredTokens(batchSize int) (int64, error) {
+	result := r.db.Where("expires_at <= ?", time.Now()).
+		Limit(batchSize).
+		Delete(&models.TokenBlacklist{})
+	return result.RowsAffected, result.Error
 }
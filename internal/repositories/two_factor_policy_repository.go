@@ -0,0 +1,49 @@
+package repositories
+
+import (
+	"github.com/LutfiyaAinurrahmanP/boilerplate_fiber_restful_api/internal/models"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type TwoFactorPolicyRepository interface {
+	FindAll() ([]models.TwoFactorPolicy, error)
+	IsRequired(role string) (bool, error)
+	Save(policy *models.TwoFactorPolicy) error
+}
+
+type twoFactorPolicyRepository struct {
+	db *gorm.DB
+}
+
+func NewTwoFactorPolicyRepository(db *gorm.DB) TwoFactorPolicyRepository {
+	return &twoFactorPolicyRepository{
+		db: db,
+	}
+}
+
+func (r *twoFactorPolicyRepository) FindAll() ([]models.TwoFactorPolicy, error) {
+	var policies []models.TwoFactorPolicy
+	err := r.db.Order("role asc").Find(&policies).Error
+	if err != nil {
+		return nil, err
+	}
+	return policies, nil
+}
+
+// IsRequired return false jika role belum punya policy
+func (r *twoFactorPolicyRepository) IsRequired(role string) (bool, error) {
+	var count int64
+	err := r.db.Model(&models.TwoFactorPolicy{}).
+		Where("role = ? AND required = ?", role, true).
+		Count(&count).Error
+	return count > 0, err
+}
+
+// Save membuat atau memperbarui policy sebuah role (upsert)
+func (r *twoFactorPolicyRepository) Save(policy *models.TwoFactorPolicy) error {
+	return r.db.Clauses(clause.OnConflict{
+		Columns:   []clause.Column{{Name: "role"}},
+		DoUpdates: clause.AssignmentColumns([]string{"required", "updated_by", "updated_at"}),
+	}).Create(policy).Error
+}
@@ -0,0 +1,68 @@
+package repositories
+
+import (
+	"time"
+
+	"github.com/LutfiyaAinurrahmanP/boilerplate_fiber_restful_api/internal/models"
+	"gorm.io/gorm"
+)
+
+type UserIdentityRepository interface {
+	Create(identity *models.UserIdentity) error
+	FindByProviderSubject(provider, subject string) (*models.UserIdentity, error)
+	FindByUser(userID uint) ([]models.UserIdentity, error)
+	CountByUser(userID uint) (int64, error)
+	TouchLogin(id uint, email string) error
+	Delete(userID, id uint) (bool, error)
+}
+
+type userIdentityRepository struct {
+	db *gorm.DB
+}
+
+func NewUserIdentityRepository(db *gorm.DB) UserIdentityRepository {
+	return &userIdentityRepository{
+		db: db,
+	}
+}
+
+func (r *userIdentityRepository) Create(identity *models.UserIdentity) error {
+	return r.db.Create(identity).Error
+}
+
+func (r *userIdentityRepository) FindByProviderSubject(provider, subject string) (*models.UserIdentity, error) {
+	var identity models.UserIdentity
+	err := r.db.Where("provider = ? AND subject = ?", provider, subject).First(&identity).Error
+	if err != nil {
+		return nil, err
+	}
+	return &identity, nil
+}
+
+func (r *userIdentityRepository) FindByUser(userID uint) ([]models.UserIdentity, error) {
+	var identities []models.UserIdentity
+	err := r.db.Where("user_id = ?", userID).Order("created_at ASC").Find(&identities).Error
+	return identities, err
+}
+
+func (r *userIdentityRepository) CountByUser(userID uint) (int64, error) {
+	var count int64
+	err := r.db.Model(&models.UserIdentity{}).Where("user_id = ?", userID).Count(&count).Error
+	return count, err
+}
+
+// TouchLogin mencatat waktu login terakhir dan email terbaru dari IdP
+func (r *userIdentityRepository) TouchLogin(id uint, email string) error {
+	return r.db.Model(&models.UserIdentity{}).
+		Where("id = ?", id).
+		UpdateColumns(map[string]interface{}{"last_login_at": time.Now(), "email": email}).Error
+}
+
+// Delete menghapus identitas milik user tertentu, return false jika tidak ditemukan
+func (r *userIdentityRepository) Delete(userID, id uint) (bool, error) {
+	result := r.db.Where("id = ? AND user_id = ?", id, userID).Delete(&models.UserIdentity{})
+	if result.Error != nil {
+		return false, result.Error
+	}
+	return result.RowsAffected == 1, nil
+}
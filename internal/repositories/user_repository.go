@@ -5,31 +5,47 @@ import (
 	"strings"
 
 	"github.com/LutfiyaAinurrahmanP/boilerplate_fiber_restful_api/internal/models"
+	"github.com/LutfiyaAinurrahmanP/boilerplate_fiber_restful_api/internal/utils"
 	"github.com/LutfiyaAinurrahmanP/boilerplate_fiber_restful_api/internal/validators"
 	"gorm.io/gorm"
 )
 
 type UserRepository interface {
 	Create(user *models.User) error
+	// Update, Delete dan HardDelete mengembalikan ErrLastAdmin jika perubahan menghilangkan admin aktif terakhir organisasi
 	Update(user *models.User) error
 	Delete(id uint) error
 	HardDelete(id uint) error
 	Restore(id uint) error
+	IncrementTokenVersion(id uint) error
+	// UpdatePassword menyimpan hash password baru sekaligus menaikkan token_version dalam satu UPDATE
+	UpdatePassword(id uint, hashedPassword string) error
+	AdvanceTwoFactorStep(id uint, step int64) (bool, error)
 
 	FindByUsername(username string) (*models.User, error)
 	FindByEmail(email string) (*models.User, error)
 	FindByPhone(phone string) (*models.User, error)
 	FindById(id uint) (*models.User, error)
+	FindByIdWithDeleted(id uint) (*models.User, error)
 	FindAll(query *validators.ListUserQuery) ([]models.User, int64, error)
 	FindAllDelete(query *validators.ListUserQuery) ([]models.User, int64, error)
 
 	ExistsByUsername(username string) (bool, error)
 	ExistsByEmail(email string) (bool, error)
 	ExistsByPhone(phone string) (bool, error)
+
+	// ForOrganization mengembalikan repository yang di-scope ke satu organisasi:
+	// FindAll, FindAllDelete dan FindById hanya melihat anggota organisasi (Role berisi role di organisasi tersebut),
+	// FindBy* dan Exists* untuk username / email / phone mengikuti cakupan uniqueness organisasi,
+	// Delete, HardDelete dan Restore hanya berlaku untuk user yang organisasi asalnya adalah organisasi tersebut
+	ForOrganization(organizationID uint) UserRepository
 }
 
 type userRepository struct {
 	db *gorm.DB
+
+	// organizationID 0 berarti tidak di-scope (semua user)
+	organizationID uint
 }
 
 func NewUserRepository(db *gorm.DB) UserRepository {
@@ -38,29 +54,158 @@ func NewUserRepository(db *gorm.DB) UserRepository {
 	}
 }
 
+func (r *userRepository) ForOrganization(organizationID uint) UserRepository {
+	return &userRepository{
+		db:             r.db,
+		organizationID: organizationID,
+	}
+}
+
+// Create pada repository yang di-scope menjadikan organisasi tersebut organisasi asal user baru
+// Keanggotaan organisasi asal dibuat oleh hook models.User.AfterCreate
 func (r *userRepository) Create(user *models.User) error {
+	if r.organizationID != 0 && user.OrganizationID == 0 {
+		user.OrganizationID = r.organizationID
+	}
 	return r.db.Create(user).Error
 }
 
+// Update menyimpan Role sebagai role di organisasi scope (organization_members), tanpa scope di organisasi asal user
+// users.role selalu sama dengan role di organisasi asal, dan hanya ikut berubah jika scope adalah organisasi asal
+// token_version dan two_factor_last_step tidak ikut ditulis agar nilai lama yang terbaca sebelumnya tidak menimpa
+// kenaikan dari IncrementTokenVersion / AdvanceTwoFactorStep yang berjalan bersamaan.
+// Jika role di organisasi scope berubah, token_version dinaikkan di transaksi yang sama (token lama membawa role lama)
 func (r *userRepository) Update(user *models.User) error {
-	return r.db.Save(user).Error
+	organizationID := r.organizationID
+	if organizationID == 0 {
+		organizationID = user.OrganizationID
+	}
+
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		if user.Role != models.RoleAdmin {
+			if err := ensureAdminRemains(tx, user.ID, organizationID); err != nil {
+				return err
+			}
+		}
+
+		omit := []string{"token_version", "two_factor_last_step"}
+		if user.OrganizationID != organizationID {
+			omit = append(omit, "role")
+		}
+		save := tx.Omit(omit...)
+		if err := save.Save(user).Error; err != nil {
+			return err
+		}
+
+		result := tx.Model(&models.OrganizationMember{}).
+			Where("organization_id = ? AND user_id = ? AND role <> ?", organizationID, user.ID, user.Role).
+			Update("role", user.Role)
+		if result.Error != nil || result.RowsAffected == 0 {
+			return result.Error
+		}
+		return incrementTokenVersion(tx, user.ID)
+	})
 }
 
+// Delete (soft delete) mengeluarkan user dari hitungan admin aktif di semua organisasinya
+// dan menaikkan token_version di transaksi yang sama
+// Return gorm.ErrRecordNotFound jika tidak ada user dengan id tersebut di organisasi asal scope
 func (r *userRepository) Delete(id uint) error {
-	return r.db.Delete(&models.User{}, id).Error
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		if err := ensureAdminRemains(tx, id, 0); err != nil {
+			return err
+		}
+
+		result := r.homeScope(tx).Delete(&models.User{}, id)
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected == 0 {
+			return gorm.ErrRecordNotFound
+		}
+		return incrementTokenVersion(tx, id)
+	})
 }
 
+// HardDelete menghapus user beserta semua keanggotaan organisasinya
+// Return gorm.ErrRecordNotFound jika tidak ada user dengan id tersebut di organisasi asal scope
 func (r *userRepository) HardDelete(id uint) error {
-	return r.db.Unscoped().Delete(&models.User{}, id).Error
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		if err := ensureAdminRemains(tx, id, 0); err != nil {
+			return err
+		}
+
+		result := r.homeScope(tx.Unscoped()).Delete(&models.User{}, id)
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected == 0 {
+			return gorm.ErrRecordNotFound
+		}
+		return tx.Where("user_id = ?", id).Delete(&models.OrganizationMember{}).Error
+	})
 }
 
+// Restore mengembalikan user yang di-soft delete sekaligus menaikkan token_version dalam satu UPDATE
+// Return gorm.ErrRecordNotFound jika tidak ada user terhapus dengan id tersebut di organisasi asal scope
 func (r *userRepository) Restore(id uint) error {
-	return r.db.Model(&models.User{}).Unscoped().Where("id = ?", id).Update("deleted_at", nil).Error
+	result := r.homeScope(r.db.Model(&models.User{}).Unscoped()).
+		Where("id = ? AND deleted_at IS NOT NULL", id).
+		Updates(map[string]interface{}{
+			"deleted_at":    nil,
+			"token_version": gorm.Expr("token_version + 1"),
+		})
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return gorm.ErrRecordNotFound
+	}
+	return nil
+}
+
+// IncrementTokenVersion meng-invalidasi semua token user secara atomic
+// Unscoped agar tetap bisa dipakai untuk user yang sudah di-soft delete
+func (r *userRepository) IncrementTokenVersion(id uint) error {
+	return incrementTokenVersion(r.db, id)
+}
+
+// incrementTokenVersion dipakai juga di dalam transaksi repository lain (misalnya Update dan Delete)
+func incrementTokenVersion(db *gorm.DB, id uint) error {
+	return db.Model(&models.User{}).Unscoped().
+		Where("id = ?", id).
+		UpdateColumn("token_version", gorm.Expr("token_version + 1")).Error
+}
+
+func (r *userRepository) UpdatePassword(id uint, hashedPassword string) error {
+	return updatePassword(r.db, id, hashedPassword)
+}
+
+// updatePassword dipakai juga di dalam transaksi repository lain (misalnya PasswordResetRepository.ResetPassword)
+func updatePassword(db *gorm.DB, id uint, hashedPassword string) error {
+	return db.Model(&models.User{}).
+		Where("id = ?", id).
+		Updates(map[string]interface{}{
+			"password":      hashedPassword,
+			"token_version": gorm.Expr("token_version + 1"),
+		}).Error
+}
+
+// AdvanceTwoFactorStep menyimpan step TOTP terakhir yang dipakai secara atomic
+// Return false jika step yang sama / lebih baru sudah pernah dipakai (kode di-replay)
+func (r *userRepository) AdvanceTwoFactorStep(id uint, step int64) (bool, error) {
+	result := r.db.Model(&models.User{}).
+		Where("id = ? AND two_factor_last_step < ?", id, step).
+		UpdateColumn("two_factor_last_step", step)
+	if result.Error != nil {
+		return false, result.Error
+	}
+	return result.RowsAffected == 1, nil
 }
 
 func (r *userRepository) FindByUsername(username string) (*models.User, error) {
 	var user models.User
-	err := r.db.Where("username = ?", username).First(&user).Error
+	err := r.uniqueScope(r.db).Where("username = ?", username).First(&user).Error
 	if err != nil {
 		return nil, err
 	}
@@ -69,7 +214,7 @@ func (r *userRepository) FindByUsername(username string) (*models.User, error) {
 
 func (r *userRepository) FindByEmail(email string) (*models.User, error) {
 	var user models.User
-	err := r.db.Where("email = ?", email).First(&user).Error
+	err := r.uniqueScope(r.db).Where("email = ?", utils.NormalizeEmail(email)).First(&user).Error
 	if err != nil {
 		return nil, err
 	}
@@ -78,7 +223,7 @@ func (r *userRepository) FindByEmail(email string) (*models.User, error) {
 
 func (r *userRepository) FindByPhone(phone string) (*models.User, error) {
 	var user models.User
-	err := r.db.Where("phone = ?", phone).First(&user).Error
+	err := r.uniqueScope(r.db).Where("phone = ?", phoneLookup(phone)).First(&user).Error
 	if err != nil {
 		return nil, err
 	}
@@ -87,18 +232,38 @@ func (r *userRepository) FindByPhone(phone string) (*models.User, error) {
 
 func (r *userRepository) FindById(id uint) (*models.User, error) {
 	var user models.User
-	err := r.db.First(&user, id).Error
+	err := r.memberScope(r.db, "").First(&user, id).Error
 	if err != nil {
 		return nil, err
 	}
-	return &user, nil
+
+	users := []models.User{user}
+	if err := r.applyMemberRoles(users); err != nil {
+		return nil, err
+	}
+	return &users[0], nil
+}
+
+// FindByIdWithDeleted sama dengan FindById tetapi juga menemukan user yang sudah di-soft delete
+func (r *userRepository) FindByIdWithDeleted(id uint) (*models.User, error) {
+	var user models.User
+	err := r.memberScope(r.db.Unscoped(), "").First(&user, id).Error
+	if err != nil {
+		return nil, err
+	}
+
+	users := []models.User{user}
+	if err := r.applyMemberRoles(users); err != nil {
+		return nil, err
+	}
+	return &users[0], nil
 }
 
 func (r *userRepository) FindAll(query *validators.ListUserQuery) ([]models.User, int64, error) {
 	var users []models.User
 	var total int64
 
-	db := r.db.Model(&models.User{})
+	db := r.memberScope(r.db.Model(&models.User{}), query.Role)
 
 	if query.Search != "" {
 		searchPattern := "%" + strings.ToLower(query.Search) + "%"
@@ -108,10 +273,6 @@ func (r *userRepository) FindAll(query *validators.ListUserQuery) ([]models.User
 		)
 	}
 
-	if query.Role != "" {
-		db = db.Where("role = ?", query.Role)
-	}
-
 	if err := db.Count(&total).Error; err != nil {
 		return nil, 0, fmt.Errorf("failed to count users: %w", err)
 	}
@@ -124,6 +285,9 @@ func (r *userRepository) FindAll(query *validators.ListUserQuery) ([]models.User
 	if err := db.Find(&users).Error; err != nil {
 		return nil, 0, fmt.Errorf("failed to fetch user: %w", err)
 	}
+	if err := r.applyMemberRoles(users); err != nil {
+		return nil, 0, fmt.Errorf("failed to fetch member roles: %w", err)
+	}
 	return users, total, nil
 }
 
@@ -131,7 +295,7 @@ func (r *userRepository) FindAllDelete(query *validators.ListUserQuery) ([]model
 	var users []models.User
 	var total int64
 
-	db := r.db.Unscoped().Model(&models.User{}).Where("deleted_at IS NOT NULL")
+	db := r.memberScope(r.db.Unscoped().Model(&models.User{}), query.Role).Where("deleted_at IS NOT NULL")
 
 	if query.Search != "" {
 		searchPattern := "%" + strings.ToLower(query.Search) + "%"
@@ -141,10 +305,6 @@ func (r *userRepository) FindAllDelete(query *validators.ListUserQuery) ([]model
 		)
 	}
 
-	if query.Role != "" {
-		db = db.Where("role = ?", query.Role)
-	}
-
 	if err := db.Count(&total).Error; err != nil {
 		return nil, 0, fmt.Errorf("failed to count deleted users: %w", err)
 	}
@@ -157,24 +317,97 @@ func (r *userRepository) FindAllDelete(query *validators.ListUserQuery) ([]model
 	if err := db.Find(&users).Error; err != nil {
 		return nil, 0, fmt.Errorf("failed to fetch deleted users: %w", err)
 	}
+	if err := r.applyMemberRoles(users); err != nil {
+		return nil, 0, fmt.Errorf("failed to fetch member roles: %w", err)
+	}
 
 	return users, total, nil
 }
 
 func (r *userRepository) ExistsByUsername(username string) (bool, error) {
 	var count int64
-	err := r.db.Model(&models.User{}).Where("username = ?", username).Count(&count).Error
+	err := r.uniqueScope(r.db.Model(&models.User{})).Where("username = ?", username).Count(&count).Error
 	return count > 0, err
 }
 
 func (r *userRepository) ExistsByEmail(email string) (bool, error) {
 	var count int64
-	err := r.db.Model(&models.User{}).Where("email = ?", email).Count(&count).Error
+	err := r.uniqueScope(r.db.Model(&models.User{})).Where("email = ?", utils.NormalizeEmail(email)).Count(&count).Error
 	return count > 0, err
 }
 
 func (r *userRepository) ExistsByPhone(phone string) (bool, error) {
 	var count int64
-	err := r.db.Model(&models.User{}).Where("phone = ?", phone).Count(&count).Error
+	err := r.uniqueScope(r.db.Model(&models.User{})).Where("phone = ?", phoneLookup(phone)).Count(&count).Error
 	return count > 0, err
 }
+
+// memberScope membatasi query ke anggota organisasi, role (opsional) adalah role di organisasi tersebut
+// Tanpa scope, role difilter dari users.role
+func (r *userRepository) memberScope(db *gorm.DB, role string) *gorm.DB {
+	if r.organizationID == 0 {
+		if role != "" {
+			db = db.Where("role = ?", role)
+		}
+		return db
+	}
+
+	members := r.db.Model(&models.OrganizationMember{}).Select("user_id").Where("organization_id = ?", r.organizationID)
+	if role != "" {
+		members = members.Where("role = ?", role)
+	}
+	return db.Where("users.id IN (?)", members)
+}
+
+// uniqueScope membatasi pencarian username / email / phone ke cakupan uniqueness organisasi
+func (r *userRepository) uniqueScope(db *gorm.DB) *gorm.DB {
+	if r.organizationID == 0 {
+		return db
+	}
+	return db.Where("unique_scope = ?", models.UniqueScopeFor(r.organizationID))
+}
+
+// homeScope membatasi perubahan status akun ke user yang organisasi asalnya adalah organisasi scope
+func (r *userRepository) homeScope(db *gorm.DB) *gorm.DB {
+	if r.organizationID == 0 {
+		return db
+	}
+	return db.Where("organization_id = ?", r.organizationID)
+}
+
+// applyMemberRoles mengganti Role setiap user dengan role-nya di organisasi scope
+func (r *userRepository) applyMemberRoles(users []models.User) error {
+	if r.organizationID == 0 || len(users) == 0 {
+		return nil
+	}
+
+	ids := make([]uint, len(users))
+	for i, user := range users {
+		ids[i] = user.ID
+	}
+
+	var members []models.OrganizationMember
+	err := r.db.Where("organization_id = ? AND user_id IN ?", r.organizationID, ids).Find(&members).Error
+	if err != nil {
+		return err
+	}
+
+	roles := make(map[uint]string, len(members))
+	for _, member := range members {
+		roles[member.UserID] = member.Role
+	}
+	for i := range users {
+		if role, ok := roles[users[i].ID]; ok {
+			users[i].Role = role
+		}
+	}
+	return nil
+}
+
+// phoneLookup menormalisasi nomor ke E.164 sebelum dicari, input yang tidak valid dipakai apa adanya
+func phoneLookup(phone string) string {
+	if normalized, err := utils.NormalizePhone(phone); err == nil {
+		return normalized
+	}
+	return phone
+}
@@ -0,0 +1,82 @@
+package repositories
+
+import (
+	"time"
+
+	"github.com/LutfiyaAinurrahmanP/boilerplate_fiber_restful_api/internal/models"
+	"gorm.io/gorm"
+)
+
+type VerificationCodeRepository interface {
+	Create(code *models.VerificationCode) error
+	FindLatestActive(userID uint, channel string) (*models.VerificationCode, error)
+	CountSince(userID uint, channel string, since time.Time) (int64, error)
+	IncrementAttempts(id uint) error
+	MarkConsumed(id uint) (bool, error)
+	InvalidateForUser(userID uint, channel string) error
+	CleanupExpiredTokens(batchSize int) (int64, error)
+}
+
+type verificationCodeRepository struct {
+	db *gorm.DB
+}
+
+func NewVerificationCodeRepository(db *gorm.DB) VerificationCodeRepository {
+	return &verificationCodeRepository{
+		db: db,
+	}
+}
+
+func (r *verificationCodeRepository) Create(code *models.VerificationCode) error {
+	return r.db.Create(code).Error
+}
+
+func (r *verificationCodeRepository) FindLatestActive(userID uint, channel string) (*models.VerificationCode, error) {
+	var code models.VerificationCode
+	err := r.db.Where("user_id = ? AND channel = ? AND consumed_at IS NULL AND expires_at > ?", userID, channel, time.Now()).
+		Order("id desc").
+		First(&code).Error
+	if err != nil {
+		return nil, err
+	}
+	return &code, nil
+}
+
+// CountSince menghitung jumlah kode yang dibuat sejak waktu tertentu (untuk rate limit)
+func (r *verificationCodeRepository) CountSince(userID uint, channel string, since time.Time) (int64, error) {
+	var count int64
+	err := r.db.Model(&models.VerificationCode{}).
+		Where("user_id = ? AND channel = ? AND created_at >= ?", userID, channel, since).
+		Count(&count).Error
+	return count, err
+}
+
+func (r *verificationCodeRepository) IncrementAttempts(id uint) error {
+	return r.db.Model(&models.VerificationCode{}).
+		Where("id = ?", id).
+		UpdateColumn("attempts", gorm.Expr("attempts + 1")).Error
+}
+
+// MarkConsumed menandai kode terpakai secara atomic
+func (r *verificationCodeRepository) MarkConsumed(id uint) (bool, error) {
+	result := r.db.Model(&models.VerificationCode{}).
+		Where("id = ? AND consumed_at IS NULL", id).
+		Update("consumed_at", time.Now())
+	if result.Error != nil {
+		return false, result.Error
+	}
+	return result.RowsAffected == 1, nil
+}
+
+func (r *verificationCodeRepository) InvalidateForUser(userID uint, channel string) error {
+	return r.db.Model(&models.VerificationCode{}).
+		Where("user_id = ? AND channel = ? AND consumed_at IS NULL", userID, channel).
+		Update("consumed_at", time.Now()).Error
+}
+
+func (r *verificationCodeRepository) CleanupExpiredTokens(batchSize int) (int64, error) {
+	result := r.db.Where("expires_at <= ?", time.Now()).
+		Limit(batchSize).
+		Delete(&models.VerificationCode{})
+	return result.RowsAffected, result.Error
+}
@@ -0,0 +1,117 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/LutfiyaAinurrahmanP/boilerplate_fiber_restful_api/internal/config"
+	"github.com/LutfiyaAinurrahmanP/boilerplate_fiber_restful_api/internal/models"
+	"github.com/LutfiyaAinurrahmanP/boilerplate_fiber_restful_api/internal/repositories"
+	"github.com/LutfiyaAinurrahmanP/boilerplate_fiber_restful_api/internal/utils"
+	"github.com/LutfiyaAinurrahmanP/boilerplate_fiber_restful_api/internal/validators"
+	"gorm.io/gorm"
+)
+
+// CreatedAPIKey adalah API key yang baru dibuat beserta secret-nya (hanya dikembalikan sekali)
+type CreatedAPIKey struct {
+	APIKey *models.APIKey `json:"api_key"`
+	Secret string         `json:"secret"`
+}
+
+type APIKeyService interface {
+	// mfaVerified adalah status 2FA session yang membuat key, disimpan di key (lihat models.APIKey.MFAVerified)
+	CreateAPIKey(userID uint, mfaVerified bool, req *validators.CreateAPIKeyRequest) (*CreatedAPIKey, error)
+	ListAPIKeys(userID uint) ([]models.APIKey, error)
+	RevokeAPIKey(userID, id uint) error
+}
+
+type apiKeyService struct {
+	apiKeyRepo repositories.APIKeyRepository
+	userRepo   repositories.UserRepository
+	roles      RoleService
+	cfg        *config.Config
+}
+
+func NewAPIKeyService(apiKeyRepo repositories.APIKeyRepository, userRepo repositories.UserRepository, roles RoleService, cfg *config.Config) APIKeyService {
+	return &apiKeyService{
+		apiKeyRepo: apiKeyRepo,
+		userRepo:   userRepo,
+		roles:      roles,
+		cfg:        cfg,
+	}
+}
+
+func (s *apiKeyService) CreateAPIKey(userID uint, mfaVerified bool, req *validators.CreateAPIKeyRequest) (*CreatedAPIKey, error) {
+	user, err := s.userRepo.FindById(userID)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, errors.New("user not found")
+		}
+		return nil, fmt.Errorf("failed to find user: %w", err)
+	}
+
+	scopes := uniqueStrings(req.Scopes)
+	for _, scope := range scopes {
+		if scope == models.APIKeyScopeAdmin && !s.roles.HasPermission(user.Role, models.PermissionAdminAccess) {
+			return nil, errors.New("admin scope requires admin access")
+		}
+	}
+
+	ttl := s.cfg.APIKeyDefaultTTL
+	if req.ExpiresInDays > 0 {
+		ttl = time.Duration(req.ExpiresInDays) * 24 * time.Hour
+	}
+	if ttl > s.cfg.APIKeyMaxTTL {
+		return nil, fmt.Errorf("expires_in_days must not exceed %d days", int(s.cfg.APIKeyMaxTTL.Hours()/24))
+	}
+
+	active, err := s.apiKeyRepo.CountActiveByUser(userID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to count api keys: %w", err)
+	}
+	if active >= int64(s.cfg.APIKeyMaxPerUser) {
+		return nil, errors.New("api key limit reached, revoke an unused key first")
+	}
+
+	random, err := utils.GenerateRandomToken(32)
+	if err != nil {
+		return nil, fmt.Errorf("failed to generate api key: %w", err)
+	}
+	secret := models.APIKeyPrefix + random
+
+	key := &models.APIKey{
+		UserID:    userID,
+		Name:      req.Name,
+		Prefix:    secret[:len(models.APIKeyPrefix)+8],
+		KeyHash:   utils.HashToken(secret),
+		Scopes:    scopes,
+		ExpiresAt: time.Now().Add(ttl),
+
+		MFAVerified: mfaVerified,
+	}
+	if err := s.apiKeyRepo.Create(key); err != nil {
+		return nil, fmt.Errorf("failed to store api key: %w", err)
+	}
+
+	return &CreatedAPIKey{APIKey: key, Secret: secret}, nil
+}
+
+func (s *apiKeyService) ListAPIKeys(userID uint) ([]models.APIKey, error) {
+	keys, err := s.apiKeyRepo.FindByUser(userID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch api keys: %w", err)
+	}
+	return keys, nil
+}
+
+func (s *apiKeyService) RevokeAPIKey(userID, id uint) error {
+	revoked, err := s.apiKeyRepo.Revoke(userID, id)
+	if err != nil {
+		return fmt.Errorf("failed to revoke api key: %w", err)
+	}
+	if !revoked {
+		return errors.New("api key not found")
+	}
+	return nil
+}
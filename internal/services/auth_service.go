@@ -6,46 +6,117 @@ import (
 	"strings"
 	"time"
 
+	"github.com/LutfiyaAinurrahmanP/boilerplate_fiber_restful_api/internal/config"
+	"github.com/LutfiyaAinurrahmanP/boilerplate_fiber_restful_api/internal/jwtauth"
 	"github.com/LutfiyaAinurrahmanP/boilerplate_fiber_restful_api/internal/models"
 	"github.com/LutfiyaAinurrahmanP/boilerplate_fiber_restful_api/internal/repositories"
 	"github.com/LutfiyaAinurrahmanP/boilerplate_fiber_restful_api/internal/utils"
 	"github.com/LutfiyaAinurrahmanP/boilerplate_fiber_restful_api/internal/validators"
-	"github.com/golang-jwt/jwt/v5"
+	"github.com/google/uuid"
 	"gorm.io/gorm"
 )
 
+// TokenPair adalah pasangan access token (JWT) dan refresh token (opaque)
+type TokenPair struct {
+	AccessToken  string `json:"token"`
+	RefreshToken string `json:"refresh_token"`
+	TokenType    string `json:"token_type"`
+	ExpiresIn    int64  `json:"expires_in"`
+}
+
+// ClientInfo adalah informasi perangkat yang dicatat di session saat login
+type ClientInfo struct {
+	Device    string
+	IPAddress string
+	UserAgent string
+}
+
+// LoginResult adalah hasil login
+// Jika user mengaktifkan 2FA, Tokens kosong dan MFAToken harus ditukar di VerifyTwoFactor
+type LoginResult struct {
+	Tokens       *TokenPair
+	User         *models.User
+	MFAToken     string
+	MFAExpiresIn int64
+}
+
 type AuthService interface {
 	Register(req *validators.RegisterRequest) (*models.User, error)
-	Login(req *validators.LoginRequest) (string, *models.User, error)
+	Login(req *validators.LoginRequest, client ClientInfo) (*LoginResult, error)
+	LoginWithIdentity(user *models.User, client ClientInfo) (*LoginResult, error)
+	VerifyTwoFactor(req *validators.VerifyTwoFactorRequest) (*TokenPair, *models.User, error)
+	Refresh(refreshToken string) (*TokenPair, error)
 	Logout(token string, userID uint) error
 	ValidateToken(token string) error
+	SwitchOrganization(userID uint, sessionID string, organizationID uint) (*TokenPair, error)
+
+	// Session
+	ListSessions(userID uint) ([]models.Session, error)
+	RevokeSession(userID uint, sessionID string) error
+	RevokeAllSessions(userID uint) error
+	SessionRevoker
+}
+
+// SessionRevoker dipakai service lain (misalnya UserService) untuk me-revoke session user
+type SessionRevoker interface {
+	RevokeOtherSessions(userID uint, keepSessionID string) error
+	ReissueTokens(userID uint, sessionID string) (*TokenPair, error)
 }
 
 type authService struct {
-	userRepo  repositories.UserRepository
-	tokenRepo repositories.TokenRepository
-	jwtSecret string
+	userRepo         repositories.UserRepository
+	tokenRepo        repositories.TokenRepository
+	refreshTokenRepo repositories.RefreshTokenRepository
+	sessionRepo      repositories.SessionRepository
+	cfg              *config.Config
+	tokenManager     *jwtauth.Manager
+	verifier         VerificationService
+	mfaChallengeRepo repositories.MFAChallengeRepository
+	twoFactor        TwoFactorService
+	loginGuard       LoginGuard
+
+	registrationPolicy RegistrationPolicy
+	organizationRepo   repositories.OrganizationRepository
 }
 
-func NewAuthService(userRepo repositories.UserRepository, tokenRepo repositories.TokenRepository, jwtSecret string) AuthService {
+func NewAuthService(userRepo repositories.UserRepository, tokenRepo repositories.TokenRepository, refreshTokenRepo repositories.RefreshTokenRepository, sessionRepo repositories.SessionRepository, cfg *config.Config, tokenManager *jwtauth.Manager, verifier VerificationService, mfaChallengeRepo repositories.MFAChallengeRepository, twoFactor TwoFactorService, loginGuard LoginGuard, registrationPolicy RegistrationPolicy, organizationRepo repositories.OrganizationRepository) AuthService {
 	return &authService{
-		userRepo:  userRepo,
-		tokenRepo: tokenRepo,
-		jwtSecret: jwtSecret,
+		userRepo:         userRepo,
+		tokenRepo:        tokenRepo,
+		refreshTokenRepo: refreshTokenRepo,
+		sessionRepo:      sessionRepo,
+		cfg:              cfg,
+		tokenManager:     tokenManager,
+		verifier:         verifier,
+		mfaChallengeRepo: mfaChallengeRepo,
+		twoFactor:        twoFactor,
+		loginGuard:       loginGuard,
+
+		registrationPolicy: registrationPolicy,
+		organizationRepo:   organizationRepo,
 	}
 }
 
 func (s *authService) Register(req *validators.RegisterRequest) (*models.User, error) {
-	req.SetDefaultRole()
-	existingUser, err := s.userRepo.FindByUsername(req.Username)
+	req.Normalize()
+
+	// Cek mode registrasi (open / invite / domain / disabled)
+	if err := s.registrationPolicy.AllowPublicSignup(req.Email); err != nil {
+		return nil, err
+	}
+
+	// Register publik selalu masuk ke organisasi default
+	members := s.userRepo.ForOrganization(models.DefaultOrganizationID)
+
+	existingUser, err := members.FindByUsername(req.Username)
 	if err == nil && existingUser != nil {
 		return nil, errors.New("username already exists")
 	}
-	existingUser, err = s.userRepo.FindByEmail(req.Email)
+	existingUser, err = members.FindByEmail(req.Email)
 	if err == nil && existingUser != nil {
 		return nil, errors.New("email already exists")
 	}
-	existingUser, err = s.userRepo.FindByPhone(req.Phone)
+	existingUser, err = members.FindByPhone(req.Phone)
 	if err == nil && existingUser != nil {
 		return nil, errors.New("phone already exists")
 	}
@@ -58,65 +129,265 @@ func (s *authService) Register(req *validators.RegisterRequest) (*models.User, e
 	user := &models.User{
 		Username: req.Username,
 		Email:    req.Email,
-		Phone:    req.Phone,
+		Phone:    &req.Phone,
 		Password: hashedPassword,
-		Role:     req.Role,
+		Role:     models.RoleUser, // Register publik selalu membuat user biasa
 	}
 
-	if err := s.userRepo.Create(user); err != nil {
+	if err := members.Create(user); err != nil {
 		return nil, fmt.Errorf("failed to create user: %w", err)
 	}
 
+	// Kirim kode verifikasi email & telepon
+	s.verifier.SendInitialCodes(user)
+
 	return user, nil
 }
 
-func (s *authService) Login(req *validators.LoginRequest) (string, *models.User, error) {
-	user, err := s.userRepo.FindByUsername(req.Username)
+// Login mencari user di cakupan uniqueness organisasi req.Organization (kosong = organisasi default)
+// Token diterbitkan untuk organisasi tersebut, atau organisasi asal user jika req.Organization kosong
+func (s *authService) Login(req *validators.LoginRequest, client ClientInfo) (*LoginResult, error) {
+	organizationID, err := ResolveOrganization(s.organizationRepo, req.Organization)
 	if err != nil {
-		if errors.Is(err, gorm.ErrRecordNotFound) {
-			return "", nil, errors.New("invalid username or password")
+		return nil, err
+	}
+
+	user, throttleKey, err := s.resolveIdentifier(s.userRepo.ForOrganization(organizationID), req.LoginIdentifier())
+	if err != nil {
+		return nil, err
+	}
+
+	// Backoff / lockout dicek sebelum password, untuk akun terdaftar maupun tidak
+	if err := s.loginGuard.Check(organizationID, throttleKey, client.IPAddress); err != nil {
+		return nil, err
+	}
+
+	if user == nil {
+		checkDummyPassword(req.Password)
+		if err := s.loginGuard.RecordFailure(organizationID, throttleKey, client.IPAddress, nil); err != nil {
+			return nil, err
 		}
-		return "", nil, fmt.Errorf("failed to find user: %w", err)
+		return nil, errors.New("invalid username or password")
 	}
 
-	if err := utils.CheckPassword(user.Password, req.Password); err != nil {
-		return "", nil, errors.New("invalid username or password")
+	// Akun tanpa password hanya bisa login lewat OIDC, bcrypt dummy menyamakan waktu respons
+	if !user.HasPassword() {
+		checkDummyPassword(req.Password)
+	}
+	if !user.HasPassword() || utils.CheckPassword(user.Password, req.Password) != nil {
+		if err := s.loginGuard.RecordFailure(organizationID, throttleKey, client.IPAddress, &user.ID); err != nil {
+			return nil, err
+		}
+		return nil, errors.New("invalid username or password")
 	}
 
-	token, err := s.generateJWTToken(user)
+	// Untuk user dengan 2FA, counter baru di-reset setelah kode 2FA valid (VerifyTwoFactor)
+	// agar password yang benar tidak bisa dipakai untuk membuka challenge baru tanpa batas
+	if user.TwoFactorEnabledAt == nil {
+		if err := s.loginGuard.RecordSuccess(organizationID, throttleKey); err != nil {
+			return nil, err
+		}
+	}
+
+	if req.Organization == "" {
+		organizationID = user.OrganizationID
+	}
+	return s.completeLogin(user, organizationID, client)
+}
+
+// LoginWithIdentity menyelesaikan login user yang identitasnya sudah dibuktikan pihak lain (misalnya OIDC)
+// Pemeriksaan setelah password tetap berlaku: verifikasi email dan 2FA
+func (s *authService) LoginWithIdentity(user *models.User, client ClientInfo) (*LoginResult, error) {
+	return s.completeLogin(user, user.OrganizationID, client)
+}
+
+// completeLogin dijalankan setelah identitas user terbukti: cek keanggotaan organisasi, verifikasi email, 2FA, lalu buat session
+func (s *authService) completeLogin(user *models.User, organizationID uint, client ClientInfo) (*LoginResult, error) {
+	// Keanggotaan dan status verifikasi dicek setelah password agar tidak bocor ke pihak yang tidak tahu password
+	if _, err := s.findMembership(organizationID, user.ID); err != nil {
+		return nil, err
+	}
+	if s.cfg.RequireVerifiedEmail && user.EmailVerifiedAt == nil {
+		return nil, errors.New("email not verified")
+	}
+
+	// User dengan 2FA aktif mendapat token "mfa pending", session baru dibuat setelah kode 2FA valid
+	if user.TwoFactorEnabledAt != nil {
+		mfaToken, err := s.createMFAChallenge(user, organizationID, client)
+		if err != nil {
+			return nil, err
+		}
+		return &LoginResult{
+			User:         user,
+			MFAToken:     mfaToken,
+			MFAExpiresIn: int64(s.cfg.MFAChallengeTTL.Seconds()),
+		}, nil
+	}
+
+	pair, err := s.startSession(user, organizationID, client, false)
 	if err != nil {
-		return "", nil, fmt.Errorf("failed to generate token: %w", err)
+		return nil, err
 	}
-	return token, user, nil
+	return &LoginResult{Tokens: pair, User: user}, nil
 }
 
-func (s *authService) Logout(token string, userID uint) error {
-	claims, err := s.parseToken(token)
+// VerifyTwoFactor menukar token "mfa pending" + kode TOTP / recovery code dengan pasangan token
+func (s *authService) VerifyTwoFactor(req *validators.VerifyTwoFactorRequest) (*TokenPair, *models.User, error) {
+	challenge, err := s.mfaChallengeRepo.FindByHash(utils.HashToken(req.MFAToken))
 	if err != nil {
-		return fmt.Errorf("invalid token: %w", err)
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, nil, errors.New("invalid or expired mfa token")
+		}
+		return nil, nil, fmt.Errorf("failed to find mfa token: %w", err)
+	}
+
+	if !challenge.IsUsable(s.cfg.MFAMaxAttempts) {
+		return nil, nil, errors.New("invalid or expired mfa token")
+	}
+
+	user, err := s.userRepo.FindById(challenge.UserID)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, nil, errors.New("invalid or expired mfa token")
+		}
+		return nil, nil, fmt.Errorf("failed to find user: %w", err)
+	}
+
+	if user.TwoFactorEnabledAt == nil {
+		return nil, nil, errors.New("invalid or expired mfa token")
+	}
+
+	// Kode salah juga dihitung di counter username milik LoginGuard (lintas challenge),
+	// sehingga backoff / lockout berlaku walaupun penyerang terus membuat challenge baru
+	if err := s.loginGuard.Check(user.OrganizationID, user.Username, challenge.IPAddress); err != nil {
+		return nil, nil, err
 	}
 
-	exp, ok := claims["exp"].(float64)
+	ok, err := s.twoFactor.VerifyCode(user, req.Code)
+	if err != nil {
+		return nil, nil, err
+	}
 	if !ok {
-		return errors.New("invalid token expiration")
+		if err := s.mfaChallengeRepo.IncrementAttempts(challenge.ID); err != nil {
+			return nil, nil, fmt.Errorf("failed to record mfa attempt: %w", err)
+		}
+		if err := s.loginGuard.RecordFailure(user.OrganizationID, user.Username, challenge.IPAddress, &user.ID); err != nil {
+			return nil, nil, err
+		}
+		return nil, nil, errors.New("invalid two-factor code")
 	}
-	expiresAt := time.Unix(int64(exp), 0)
 
-	blacklistedToken := &models.TokenBlacklist{
-		Token:     token,
-		UserID:    userID,
-		ExpiresAt: expiresAt,
+	// Conditional update, token "mfa pending" hanya bisa ditukar sekali
+	consumed, err := s.mfaChallengeRepo.MarkConsumed(challenge.ID)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to use mfa token: %w", err)
+	}
+	if !consumed {
+		return nil, nil, errors.New("invalid or expired mfa token")
 	}
 
-	if err := s.tokenRepo.AddToBlacklist(blacklistedToken); err != nil {
-		return fmt.Errorf("failed to blacklist token: %w", err)
+	if err := s.loginGuard.RecordSuccess(user.OrganizationID, user.Username); err != nil {
+		return nil, nil, err
+	}
+
+	pair, err := s.startSession(user, challenge.OrganizationID, ClientInfo{
+		Device:    challenge.Device,
+		IPAddress: challenge.IPAddress,
+		UserAgent: challenge.UserAgent,
+	}, true)
+	if err != nil {
+		return nil, nil, err
+	}
+	return pair, user, nil
+}
+
+// Refresh menukar refresh token dengan pasangan token baru (rotation)
+// Jika refresh token yang sudah di-rotate dipakai lagi, seluruh family di-revoke
+func (s *authService) Refresh(refreshToken string) (*TokenPair, error) {
+	stored, err := s.refreshTokenRepo.FindByHash(utils.HashToken(refreshToken))
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, errors.New("invalid refresh token")
+		}
+		return nil, fmt.Errorf("failed to find refresh token: %w", err)
+	}
+
+	if stored.IsRevoked() {
+		return nil, errors.New("invalid refresh token")
+	}
+
+	// Token yang sudah pernah di-rotate dipakai lagi: kemungkinan besar dicuri
+	if stored.IsRotated() {
+		if err := s.revokeSession(stored.FamilyID); err != nil {
+			return nil, err
+		}
+		return nil, errors.New("refresh token reuse detected")
+	}
+
+	if stored.IsExpired() {
+		return nil, errors.New("refresh token expired")
+	}
+
+	// Conditional update, hanya satu request yang bisa me-rotate token ini
+	rotated, err := s.refreshTokenRepo.MarkRotated(stored.ID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to rotate refresh token: %w", err)
+	}
+	if !rotated {
+		if err := s.revokeSession(stored.FamilyID); err != nil {
+			return nil, err
+		}
+		return nil, errors.New("refresh token reuse detected")
+	}
+
+	user, err := s.userRepo.FindById(stored.UserID)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, errors.New("invalid refresh token")
+		}
+		return nil, fmt.Errorf("failed to find user: %w", err)
+	}
+
+	// Organisasi aktif disimpan di session (refresh token family)
+	session, err := s.sessionRepo.FindByID(stored.FamilyID)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, errors.New("invalid refresh token")
+		}
+		return nil, fmt.Errorf("failed to find session: %w", err)
+	}
+
+	pair, err := s.issueTokenPair(user, session.OrganizationID, stored.FamilyID)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := s.sessionRepo.Extend(stored.FamilyID, time.Now().Add(s.cfg.JWTRefreshExpire)); err != nil {
+		return nil, fmt.Errorf("failed to extend session: %w", err)
+	}
+	return pair, nil
+}
+
+func (s *authService) Logout(token string, userID uint) error {
+	claims, err := s.tokenManager.Parse(token)
+	if err != nil {
+		return fmt.Errorf("invalid token: %w", err)
+	}
+
+	if err := s.blacklistToken(utils.HashToken(token), userID, claims.ExpiresAt.Time); err != nil {
+		return err
+	}
+
+	// Revoke session beserta refresh token family-nya
+	if err := s.revokeSession(claims.SessionID); err != nil {
+		return err
 	}
 
 	return nil
 }
 
 func (s *authService) ValidateToken(token string) error {
-	isBlacklisted, err := s.tokenRepo.IsBlacklisted(token)
+	isBlacklisted, err := s.tokenRepo.IsBlacklisted(utils.HashToken(token))
 	if err != nil {
 		return fmt.Errorf("failed to check token blacklist: %w", err)
 	}
@@ -125,7 +396,7 @@ func (s *authService) ValidateToken(token string) error {
 		return errors.New("token has been revoked")
 	}
 
-	_, err = s.parseToken(token)
+	_, err = s.tokenManager.Parse(token)
 	if err != nil {
 		return fmt.Errorf("invalid token: %w", err)
 	}
@@ -133,44 +404,311 @@ func (s *authService) ValidateToken(token string) error {
 	return nil
 }
 
-func (s *authService) generateJWTToken(user *models.User) (string, error) {
-	claims := jwt.MapClaims{
-		"sub":      user.ID,
-		"username": user.Username,
-		"role":     user.Role,
-		"exp":      time.Now().Add(24 * time.Hour).Unix(),
-		"iat":      time.Now().Unix(),
+func (s *authService) ListSessions(userID uint) ([]models.Session, error) {
+	sessions, err := s.sessionRepo.FindActiveByUser(userID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch sessions: %w", err)
+	}
+	return sessions, nil
+}
+
+func (s *authService) RevokeSession(userID uint, sessionID string) error {
+	session, err := s.sessionRepo.FindByID(sessionID)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return errors.New("session not found")
+		}
+		return fmt.Errorf("failed to find session: %w", err)
+	}
+
+	// User hanya boleh me-revoke session miliknya sendiri
+	if session.UserID != userID {
+		return errors.New("session not found")
+	}
+
+	return s.revokeSession(session.ID)
+}
+
+// RevokeAllSessions me-logout user dari semua perangkat
+// TokenVersion ikut dinaikkan agar token OAuth2 yang diterbitkan untuk aplikasi lain juga tidak berlaku
+func (s *authService) RevokeAllSessions(userID uint) error {
+	sessions, err := s.sessionRepo.FindActiveByUser(userID)
+	if err != nil {
+		return fmt.Errorf("failed to fetch sessions: %w", err)
+	}
+
+	for _, session := range sessions {
+		if err := s.revokeSession(session.ID); err != nil {
+			return err
+		}
+	}
+
+	if err := s.userRepo.IncrementTokenVersion(userID); err != nil {
+		return fmt.Errorf("failed to revoke user tokens: %w", err)
+	}
+	return nil
+}
+
+// RevokeOtherSessions me-revoke semua session user kecuali keepSessionID
+func (s *authService) RevokeOtherSessions(userID uint, keepSessionID string) error {
+	sessions, err := s.sessionRepo.FindActiveByUser(userID)
+	if err != nil {
+		return fmt.Errorf("failed to fetch sessions: %w", err)
 	}
-	token := jwt.NewWithClaims(jwt.SigningMethodHS256, claims)
-	tokenString, err := token.SignedString([]byte(s.jwtSecret))
+
+	for _, session := range sessions {
+		if session.ID == keepSessionID {
+			continue
+		}
+		if err := s.revokeSession(session.ID); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// ReissueTokens mengganti semua token di session yang masih aktif dengan token baru
+// Dipakai setelah TokenVersion user naik (misalnya ganti password) agar session saat ini tetap login
+func (s *authService) ReissueTokens(userID uint, sessionID string) (*TokenPair, error) {
+	session, err := s.sessionRepo.FindByID(sessionID)
 	if err != nil {
-		return "", err
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, errors.New("session not found")
+		}
+		return nil, fmt.Errorf("failed to find session: %w", err)
 	}
-	return tokenString, nil
+	if session.UserID != userID || !session.IsActive() {
+		return nil, errors.New("session not found")
+	}
+
+	user, err := s.userRepo.FindById(userID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to find user: %w", err)
+	}
+
+	// Token lama di session ini di-revoke, session sendiri tetap aktif
+	if err := s.revokeFamily(sessionID); err != nil {
+		return nil, err
+	}
+
+	return s.issueTokenPair(user, session.OrganizationID, sessionID)
 }
 
-func (s *authService) parseToken(tokenString string) (jwt.MapClaims, error) {
-	token, err := jwt.Parse(tokenString, func(token *jwt.Token) (interface{}, error) {
-		if _, ok := token.Method.(*jwt.SigningMethodHMAC); !ok {
-			return nil, fmt.Errorf("unexpected signing method: %v", token.Header["alg"])
+// SwitchOrganization memindahkan session saat ini ke organisasi lain tempat user menjadi anggota
+// Token lama di session ini di-revoke, token baru membawa organisasi dan role di organisasi tersebut
+func (s *authService) SwitchOrganization(userID uint, sessionID string, organizationID uint) (*TokenPair, error) {
+	session, err := s.sessionRepo.FindByID(sessionID)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, errors.New("session not found")
 		}
-		return []byte(s.jwtSecret), nil
-	})
+		return nil, fmt.Errorf("failed to find session: %w", err)
+	}
+	if session.UserID != userID || !session.IsActive() {
+		return nil, errors.New("session not found")
+	}
 
+	user, err := s.userRepo.FindById(userID)
 	if err != nil {
+		return nil, fmt.Errorf("failed to find user: %w", err)
+	}
+
+	if _, err := s.findMembership(organizationID, userID); err != nil {
+		return nil, err
+	}
+
+	if err := s.sessionRepo.SetOrganization(sessionID, organizationID); err != nil {
+		return nil, fmt.Errorf("failed to switch organization: %w", err)
+	}
+	if err := s.revokeFamily(sessionID); err != nil {
 		return nil, err
 	}
 
-	if !token.Valid {
-		return nil, errors.New("invalid token")
+	return s.issueTokenPair(user, organizationID, sessionID)
+}
+
+// resolveIdentifier mencari user berdasarkan email (mengandung "@"), nomor telepon, atau username
+// Identifier yang bisa dibaca sebagai nomor telepon tetapi tidak terdaftar dicoba lagi sebagai username
+// Return user nil jika tidak ditemukan, beserta key untuk LoginGuard:
+// username untuk akun terdaftar (semua identifier berbagi satu counter), identifier ternormalisasi jika tidak
+func (s *authService) resolveIdentifier(users repositories.UserRepository, identifier string) (*models.User, string, error) {
+	var (
+		user *models.User
+		err  error
+		key  = identifier
+	)
+
+	if strings.Contains(identifier, "@") {
+		key = utils.NormalizeEmail(identifier)
+		user, err = users.FindByEmail(key)
+	} else if phone, phoneErr := utils.NormalizePhone(identifier); phoneErr == nil {
+		key = phone
+		user, err = users.FindByPhone(phone)
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			key = identifier
+			user, err = users.FindByUsername(identifier)
+		}
+	} else {
+		user, err = users.FindByUsername(identifier)
 	}
 
-	claims, ok := token.Claims.(jwt.MapClaims)
-	if !ok {
-		return nil, errors.New("invalid token claims")
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, key, nil
+		}
+		return nil, "", fmt.Errorf("failed to find user: %w", err)
 	}
+	return user, user.Username, nil
+}
+
+// startSession membuat session baru (ID session juga menjadi refresh token family) lalu menerbitkan token
+func (s *authService) startSession(user *models.User, organizationID uint, client ClientInfo, mfaVerified bool) (*TokenPair, error) {
+	now := time.Now()
+	session := &models.Session{
+		ID:         uuid.NewString(),
+		UserID:     user.ID,
+		Device:     client.Device,
+		IPAddress:  client.IPAddress,
+		UserAgent:  client.UserAgent,
+		LastSeenAt: now,
+		ExpiresAt:  now.Add(s.cfg.JWTRefreshExpire),
 
-	return claims, nil
+		OrganizationID: organizationID,
+	}
+	if mfaVerified {
+		session.MFAVerifiedAt = &now
+	}
+	if err := s.sessionRepo.Create(session); err != nil {
+		return nil, fmt.Errorf("failed to create session: %w", err)
+	}
+
+	return s.issueTokenPair(user, organizationID, session.ID)
+}
+
+func (s *authService) createMFAChallenge(user *models.User, organizationID uint, client ClientInfo) (string, error) {
+	token, err := utils.GenerateRandomToken(32)
+	if err != nil {
+		return "", fmt.Errorf("failed to generate mfa token: %w", err)
+	}
+
+	challenge := &models.MFAChallenge{
+		TokenHash: utils.HashToken(token),
+		UserID:    user.ID,
+		Device:    client.Device,
+		IPAddress: client.IPAddress,
+		UserAgent: client.UserAgent,
+		ExpiresAt: time.Now().Add(s.cfg.MFAChallengeTTL),
+
+		OrganizationID: organizationID,
+	}
+	if err := s.mfaChallengeRepo.Create(challenge); err != nil {
+		return "", fmt.Errorf("failed to store mfa token: %w", err)
+	}
+	return token, nil
+}
+
+// issueTokenPair membuat access token baru dan refresh token baru dalam family yang sama
+// Role di token adalah role user di organisasi aktif session
+func (s *authService) issueTokenPair(user *models.User, organizationID uint, familyID string) (*TokenPair, error) {
+	member, err := s.findMembership(organizationID, user.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+
+	accessToken, claims, err := s.tokenManager.Issue(user, member, familyID, now)
+	if err != nil {
+		return nil, fmt.Errorf("failed to generate token: %w", err)
+	}
+
+	refreshToken, err := utils.GenerateRandomToken(32)
+	if err != nil {
+		return nil, fmt.Errorf("failed to generate refresh token: %w", err)
+	}
+
+	stored := &models.RefreshToken{
+		TokenHash:   utils.HashToken(refreshToken),
+		FamilyID:    familyID,
+		UserID:      user.ID,
+		AccessHash:  utils.HashToken(accessToken),
+		AccessExpAt: claims.ExpiresAt.Time,
+		ExpiresAt:   now.Add(s.cfg.JWTRefreshExpire),
+	}
+	if err := s.refreshTokenRepo.Create(stored); err != nil {
+		return nil, fmt.Errorf("failed to store refresh token: %w", err)
+	}
+
+	return &TokenPair{
+		AccessToken:  accessToken,
+		RefreshToken: refreshToken,
+		TokenType:    "Bearer",
+		ExpiresIn:    int64(s.cfg.JWTExpire.Seconds()),
+	}, nil
+}
+
+// findMembership mengembalikan keanggotaan user di organisasi, error jika user bukan anggota
+func (s *authService) findMembership(organizationID, userID uint) (*models.OrganizationMember, error) {
+	member, err := s.organizationRepo.FindMember(organizationID, userID)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, errors.New("not a member of this organization")
+		}
+		return nil, fmt.Errorf("failed to find organization membership: %w", err)
+	}
+	return member, nil
+}
+
+// revokeSession menandai session revoked lalu me-revoke refresh token family-nya
+func (s *authService) revokeSession(sessionID string) error {
+	if err := s.sessionRepo.Revoke(sessionID); err != nil {
+		return fmt.Errorf("failed to revoke session: %w", err)
+	}
+	return s.revokeFamily(sessionID)
+}
+
+// revokeFamily me-revoke semua refresh token dalam family
+// dan mem-blacklist semua access token family tersebut yang belum expired
+func (s *authService) revokeFamily(familyID string) error {
+	if err := s.refreshTokenRepo.RevokeFamily(familyID); err != nil {
+		return fmt.Errorf("failed to revoke refresh token family: %w", err)
+	}
+
+	tokens, err := s.refreshTokenRepo.FindByFamily(familyID)
+	if err != nil {
+		return fmt.Errorf("failed to find refresh token family: %w", err)
+	}
+
+	for _, t := range tokens {
+		if t.AccessHash == "" || time.Now().After(t.AccessExpAt) {
+			continue
+		}
+		isBlacklisted, err := s.tokenRepo.IsBlacklisted(t.AccessHash)
+		if err != nil {
+			return fmt.Errorf("failed to check token blacklist: %w", err)
+		}
+		if isBlacklisted {
+			continue
+		}
+		if err := s.blacklistToken(t.AccessHash, t.UserID, t.AccessExpAt); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// blacklistToken menyimpan SHA-256 digest token, token asli tidak pernah masuk database
+func (s *authService) blacklistToken(tokenHash string, userID uint, expiresAt time.Time) error {
+	blacklistedToken := &models.TokenBlacklist{
+		TokenHash: tokenHash,
+		UserID:    userID,
+		ExpiresAt: expiresAt,
+	}
+
+	if err := s.tokenRepo.AddToBlacklist(blacklistedToken); err != nil {
+		return fmt.Errorf("failed to blacklist token: %w", err)
+	}
+	return nil
 }
 
 func ExtractTokenFromHeader(authHeader string) (string, error) {
@@ -0,0 +1,246 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/LutfiyaAinurrahmanP/boilerplate_fiber_restful_api/internal/config"
+	"github.com/LutfiyaAinurrahmanP/boilerplate_fiber_restful_api/internal/mailer"
+	"github.com/LutfiyaAinurrahmanP/boilerplate_fiber_restful_api/internal/models"
+	"github.com/LutfiyaAinurrahmanP/boilerplate_fiber_restful_api/internal/repositories"
+	"github.com/LutfiyaAinurrahmanP/boilerplate_fiber_restful_api/internal/utils"
+	"github.com/LutfiyaAinurrahmanP/boilerplate_fiber_restful_api/internal/validators"
+	"gorm.io/gorm"
+)
+
+// InvitationService mengelola undangan per organisasi, akun yang dibuat dari undangan masuk ke organisasi pengundang
+type InvitationService interface {
+	CreateInvitation(adminID, organizationID uint, actorRole string, req *validators.CreateInvitationRequest) (*models.Invitation, error)
+	ListInvitations(organizationID uint, query *validators.ListInvitationQuery) ([]models.Invitation, error)
+	ResendInvitation(organizationID, id uint) (*models.Invitation, error)
+	RevokeInvitation(organizationID, id uint) error
+	AcceptInvitation(req *validators.AcceptInvitationRequest) (*models.User, error)
+}
+
+type invitationService struct {
+	invitationRepo repositories.InvitationRepository
+	userRepo       repositories.UserRepository
+	userService    UserService
+	mailer         mailer.Mailer
+	cfg            *config.Config
+	roles          RoleHierarchy
+}
+
+func NewInvitationService(invitationRepo repositories.InvitationRepository, userRepo repositories.UserRepository, userService UserService, mailer mailer.Mailer, cfg *config.Config, roles RoleHierarchy) InvitationService {
+	return &invitationService{
+		invitationRepo: invitationRepo,
+		userRepo:       userRepo,
+		userService:    userService,
+		mailer:         mailer,
+		cfg:            cfg,
+		roles:          roles,
+	}
+}
+
+// CreateInvitation hanya bisa mengundang dengan role yang tidak di atas role pengundang (actorRole)
+func (s *invitationService) CreateInvitation(adminID, organizationID uint, actorRole string, req *validators.CreateInvitationRequest) (*models.Invitation, error) {
+	req.Normalize()
+
+	if !models.ValidateRole(req.Role) {
+		return nil, errors.New("invalid role")
+	}
+	if err := ensureCanAssignRole(s.roles, actorRole, req.Role); err != nil {
+		return nil, err
+	}
+
+	exists, err := s.userRepo.ForOrganization(organizationID).ExistsByEmail(req.Email)
+	if err != nil {
+		return nil, fmt.Errorf("failed to check email: %w", err)
+	}
+	if exists {
+		return nil, errors.New("email already exists")
+	}
+
+	pending, err := s.invitationRepo.ExistsPendingByEmail(organizationID, req.Email)
+	if err != nil {
+		return nil, fmt.Errorf("failed to check invitations: %w", err)
+	}
+	if pending {
+		return nil, errors.New("pending invitation already exists for this email")
+	}
+
+	ttl := s.cfg.InvitationTTL
+	if req.ExpiresInHours > 0 {
+		ttl = time.Duration(req.ExpiresInHours) * time.Hour
+	}
+
+	token, err := utils.GenerateRandomToken(32)
+	if err != nil {
+		return nil, fmt.Errorf("failed to generate invitation token: %w", err)
+	}
+
+	now := time.Now()
+	invitation := &models.Invitation{
+		Email:      req.Email,
+		Role:       req.Role,
+		Note:       req.Note,
+		TokenHash:  utils.HashToken(token),
+		InvitedBy:  adminID,
+		ExpiresAt:  now.Add(ttl),
+		LastSentAt: now,
+
+		OrganizationID: organizationID,
+	}
+	if err := s.invitationRepo.Create(invitation); err != nil {
+		return nil, fmt.Errorf("failed to create invitation: %w", err)
+	}
+
+	go s.sendInvitationEmail(invitation, token)
+
+	return invitation, nil
+}
+
+func (s *invitationService) ListInvitations(organizationID uint, query *validators.ListInvitationQuery) ([]models.Invitation, error) {
+	status := query.Status
+	if status == "" {
+		status = models.InvitationStatusPending
+	}
+
+	invitations, err := s.invitationRepo.FindAll(organizationID, status)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch invitations: %w", err)
+	}
+	return invitations, nil
+}
+
+// ResendInvitation membuat token baru (link lama tidak berlaku) dan memperpanjang masa berlaku
+func (s *invitationService) ResendInvitation(organizationID, id uint) (*models.Invitation, error) {
+	invitation, err := s.findInvitation(organizationID, id)
+	if err != nil {
+		return nil, err
+	}
+
+	status := invitation.Status()
+	if status == models.InvitationStatusAccepted || status == models.InvitationStatusRevoked {
+		return nil, fmt.Errorf("invitation already %s", status)
+	}
+
+	token, err := utils.GenerateRandomToken(32)
+	if err != nil {
+		return nil, fmt.Errorf("failed to generate invitation token: %w", err)
+	}
+
+	now := time.Now()
+	invitation.TokenHash = utils.HashToken(token)
+	invitation.LastSentAt = now
+	if invitation.ExpiresAt.Before(now.Add(s.cfg.InvitationTTL)) {
+		invitation.ExpiresAt = now.Add(s.cfg.InvitationTTL)
+	}
+	if err := s.invitationRepo.Update(invitation); err != nil {
+		return nil, fmt.Errorf("failed to update invitation: %w", err)
+	}
+
+	go s.sendInvitationEmail(invitation, token)
+
+	return invitation, nil
+}
+
+func (s *invitationService) RevokeInvitation(organizationID, id uint) error {
+	invitation, err := s.findInvitation(organizationID, id)
+	if err != nil {
+		return err
+	}
+
+	revoked, err := s.invitationRepo.Revoke(invitation.ID)
+	if err != nil {
+		return fmt.Errorf("failed to revoke invitation: %w", err)
+	}
+	if !revoked {
+		return fmt.Errorf("invitation already %s", invitation.Status())
+	}
+	return nil
+}
+
+// AcceptInvitation membuat akun dengan email dan role dari undangan
+// Email langsung dianggap terverifikasi karena link undangan diterima lewat email tersebut
+func (s *invitationService) AcceptInvitation(req *validators.AcceptInvitationRequest) (*models.User, error) {
+	req.Normalize()
+
+	invitation, err := s.invitationRepo.FindByHash(utils.HashToken(req.Token))
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, errors.New("invalid or expired invitation")
+		}
+		return nil, fmt.Errorf("failed to find invitation: %w", err)
+	}
+
+	if !invitation.IsPending() {
+		return nil, errors.New("invalid or expired invitation")
+	}
+
+	// Uniqueness username / email / phone dicek oleh PrepareUser di cakupan organisasi undangan
+	// Role undangan sudah dicek terhadap role pengundang saat undangan dibuat
+	user, err := s.userService.ForOrganization(invitation.OrganizationID).PrepareUser(invitation.Role, &validators.CreateUserRequest{
+		Username:        req.Username,
+		Email:           invitation.Email,
+		Phone:           req.Phone,
+		Password:        req.Password,
+		ConfirmPassword: req.ConfirmPassword,
+		Role:            invitation.Role,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	user.EmailVerifiedAt = &now
+
+	// User dibuat dan undangan ditandai terpakai dalam satu transaksi,
+	// undangan yang di-revoke bersamaan request ini membatalkan pembuatan akun
+	accepted, err := s.invitationRepo.Accept(invitation.ID, user)
+	if err != nil {
+		return nil, fmt.Errorf("failed to accept invitation: %w", err)
+	}
+	if !accepted {
+		return nil, errors.New("invalid or expired invitation")
+	}
+
+	return user, nil
+}
+
+// findInvitation mengembalikan "invitation not found" untuk undangan milik organisasi lain
+func (s *invitationService) findInvitation(organizationID, id uint) (*models.Invitation, error) {
+	invitation, err := s.invitationRepo.FindByID(id)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, errors.New("invitation not found")
+		}
+		return nil, fmt.Errorf("failed to find invitation: %w", err)
+	}
+	if invitation.OrganizationID != organizationID {
+		return nil, errors.New("invitation not found")
+	}
+	return invitation, nil
+}
+
+func (s *invitationService) sendInvitationEmail(invitation *models.Invitation, token string) {
+	body := fmt.Sprintf(
+		"Hi,\n\nYou have been invited to join %s as %s. Open the link below to create your account:\n\n%s\n\nThis invitation expires on %s.\n",
+		s.cfg.AppName, invitation.Role, withTokenQuery(s.cfg.InvitationURL, token), invitation.ExpiresAt.Format(time.RFC1123),
+	)
+	if invitation.Note != "" {
+		body += "\nNote from the administrator:\n" + invitation.Note + "\n"
+	}
+
+	msg := mailer.Message{
+		To:      invitation.Email,
+		Subject: fmt.Sprintf("You're invited to %s", s.cfg.AppName),
+		Body:    body,
+	}
+
+	if err := s.mailer.Send(msg); err != nil {
+		log.Printf("❌ Failed to send invitation email for invitation %d: %v", invitation.ID, err)
+	}
+}
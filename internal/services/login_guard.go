@@ -0,0 +1,187 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/LutfiyaAinurrahmanP/boilerplate_fiber_restful_api/internal/config"
+	"github.com/LutfiyaAinurrahmanP/boilerplate_fiber_restful_api/internal/models"
+	"github.com/LutfiyaAinurrahmanP/boilerplate_fiber_restful_api/internal/repositories"
+	"github.com/LutfiyaAinurrahmanP/boilerplate_fiber_restful_api/internal/utils"
+	"gorm.io/gorm"
+)
+
+// LoginGuard membatasi tebakan password per akun dan per IP
+// Untuk akun terdaftar key-nya adalah username (email / telepon / username berbagi satu counter),
+// identifier yang tidak terdaftar diperlakukan sama persis agar keberadaan akun tidak bocor
+// organizationID adalah organisasi tempat user dicari saat login, counter username dipisah per cakupan uniqueness-nya
+type LoginGuard interface {
+	Check(organizationID uint, username, ipAddress string) error
+	RecordFailure(organizationID uint, username, ipAddress string, userID *uint) error
+	RecordSuccess(organizationID uint, username string) error
+
+	// Admin
+	Unlock(adminID, userID uint) error
+	ListLockoutEvents(userID uint) ([]models.LockoutEvent, error)
+}
+
+type loginGuard struct {
+	userRepo    repositories.UserRepository
+	attemptRepo repositories.LoginAttemptRepository
+	cfg         *config.Config
+}
+
+func NewLoginGuard(userRepo repositories.UserRepository, attemptRepo repositories.LoginAttemptRepository, cfg *config.Config) LoginGuard {
+	return &loginGuard{
+		userRepo:    userRepo,
+		attemptRepo: attemptRepo,
+		cfg:         cfg,
+	}
+}
+
+// Check menolak login jika username atau IP sedang dalam masa backoff / terkunci
+func (g *loginGuard) Check(organizationID uint, username, ipAddress string) error {
+	attempts, err := g.attemptRepo.FindByKeys([]string{usernameKey(organizationID, username), ipKey(ipAddress)})
+	if err != nil {
+		return fmt.Errorf("failed to check login attempts: %w", err)
+	}
+
+	now := time.Now()
+	for i := range attempts {
+		if now.Before(g.blockedUntil(&attempts[i])) {
+			return errors.New("too many failed login attempts, try again later")
+		}
+	}
+	return nil
+}
+
+// RecordFailure menambah counter username dan IP, lalu mengunci key yang melewati batas
+func (g *loginGuard) RecordFailure(organizationID uint, username, ipAddress string, userID *uint) error {
+	if err := g.recordFailure(usernameKey(organizationID, username), g.cfg.LoginMaxFailures, userID, ipAddress); err != nil {
+		return err
+	}
+	return g.recordFailure(ipKey(ipAddress), g.cfg.LoginIPMaxFailures, nil, ipAddress)
+}
+
+// RecordSuccess mereset counter username, counter IP sengaja tidak di-reset
+// agar penyerang tidak bisa me-reset counter dengan login ke akunnya sendiri
+func (g *loginGuard) RecordSuccess(organizationID uint, username string) error {
+	if _, err := g.attemptRepo.Reset(usernameKey(organizationID, username)); err != nil {
+		return fmt.Errorf("failed to reset login attempts: %w", err)
+	}
+	return nil
+}
+
+func (g *loginGuard) Unlock(adminID, userID uint) error {
+	user, err := g.userRepo.FindById(userID)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return errors.New("user not found")
+		}
+		return fmt.Errorf("failed to find user: %w", err)
+	}
+
+	key := usernameKey(user.OrganizationID, user.Username)
+	existed, err := g.attemptRepo.Reset(key)
+	if err != nil {
+		return fmt.Errorf("failed to unlock account: %w", err)
+	}
+	if !existed {
+		return errors.New("account is not locked")
+	}
+
+	return g.attemptRepo.CreateEvent(&models.LockoutEvent{
+		Key:     key,
+		UserID:  &user.ID,
+		Event:   models.LockoutEventUnlocked,
+		ActorID: &adminID,
+	})
+}
+
+func (g *loginGuard) ListLockoutEvents(userID uint) ([]models.LockoutEvent, error) {
+	events, err := g.attemptRepo.FindEventsByUser(userID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch lockout events: %w", err)
+	}
+	return events, nil
+}
+
+func (g *loginGuard) recordFailure(key string, maxFailures int, userID *uint, ipAddress string) error {
+	attempt, err := g.attemptRepo.RecordFailure(key)
+	if err != nil {
+		return fmt.Errorf("failed to record login attempt: %w", err)
+	}
+
+	now := time.Now()
+	alreadyLocked := attempt.LockedUntil != nil && now.Before(*attempt.LockedUntil)
+	if attempt.Failures < maxFailures || alreadyLocked {
+		return nil
+	}
+
+	until := now.Add(g.cfg.LoginLockoutDuration)
+	if err := g.attemptRepo.Lock(key, until); err != nil {
+		return fmt.Errorf("failed to lock login: %w", err)
+	}
+
+	return g.attemptRepo.CreateEvent(&models.LockoutEvent{
+		Key:         key,
+		UserID:      userID,
+		Event:       models.LockoutEventLocked,
+		Failures:    attempt.Failures,
+		LockedUntil: &until,
+		IPAddress:   ipAddress,
+	})
+}
+
+// blockedUntil menghitung kapan key boleh mencoba login lagi
+// Backoff hanya berlaku untuk username, IP hanya dikunci setelah LoginIPMaxFailures (NAT / proxy bersama)
+func (g *loginGuard) blockedUntil(attempt *models.LoginAttempt) time.Time {
+	if attempt.LockedUntil != nil {
+		return *attempt.LockedUntil
+	}
+
+	if !strings.HasPrefix(attempt.Key, "user:") || attempt.Failures < g.cfg.LoginBackoffAfter {
+		return time.Time{}
+	}
+
+	// Jeda naik dua kali lipat setiap gagal: base, 2x base, 4x base, ... maksimal durasi lockout
+	delay := g.cfg.LoginBackoffBase
+	for i := g.cfg.LoginBackoffAfter; i < attempt.Failures && delay < g.cfg.LoginLockoutDuration; i++ {
+		delay *= 2
+	}
+	if delay > g.cfg.LoginLockoutDuration {
+		delay = g.cfg.LoginLockoutDuration
+	}
+	return attempt.LastFailureAt.Add(delay)
+}
+
+// usernameKey memakai cakupan uniqueness organisasi (models.UniqueScopeFor) agar username yang sama di tenant lain
+// punya counter sendiri, dengan uniqueness global key-nya tetap "user:<username>"
+func usernameKey(organizationID uint, username string) string {
+	username = strings.ToLower(strings.TrimSpace(username))
+	if scope := models.UniqueScopeFor(organizationID); scope != 0 {
+		return fmt.Sprintf("user:%d:%s", scope, username)
+	}
+	return "user:" + username
+}
+
+func ipKey(ipAddress string) string {
+	return "ip:" + ipAddress
+}
+
+var (
+	dummyPasswordHash     string
+	dummyPasswordHashOnce sync.Once
+)
+
+// checkDummyPassword menjalankan bcrypt untuk username yang tidak terdaftar
+// agar waktu response sama dengan username terdaftar (mencegah user enumeration via timing)
+func checkDummyPassword(password string) {
+	dummyPasswordHashOnce.Do(func() {
+		dummyPasswordHash, _ = utils.HashPassword("dummy-password-for-timing")
+	})
+	_ = utils.CheckPassword(dummyPasswordHash, password)
+}
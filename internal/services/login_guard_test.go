@@ -0,0 +1,86 @@
+package services
+
+import (
+	"testing"
+	"time"
+
+	"github.com/LutfiyaAinurrahmanP/boilerplate_fiber_restful_api/internal/config"
+	"github.com/LutfiyaAinurrahmanP/boilerplate_fiber_restful_api/internal/models"
+)
+
+func TestLoginGuardBlockedUntil(t *testing.T) {
+	guard := &loginGuard{cfg: &config.Config{
+		LoginBackoffAfter:    3,
+		LoginBackoffBase:     time.Second,
+		LoginMaxFailures:     10,
+		LoginLockoutDuration: 10 * time.Second,
+	}}
+
+	lastFailure := time.Date(2025, 1, 1, 12, 0, 0, 0, time.UTC)
+	lockedUntil := lastFailure.Add(time.Hour)
+
+	tests := []struct {
+		name     string
+		attempt  models.LoginAttempt
+		wantWait time.Duration // 0 berarti tidak diblokir
+		wantTime *time.Time
+	}{
+		{"below backoff threshold", models.LoginAttempt{Key: "user:john", Failures: 2}, 0, nil},
+		{"first backoff", models.LoginAttempt{Key: "user:john", Failures: 3}, time.Second, nil},
+		{"backoff doubles", models.LoginAttempt{Key: "user:john", Failures: 4}, 2 * time.Second, nil},
+		{"backoff doubles again", models.LoginAttempt{Key: "user:john", Failures: 5}, 4 * time.Second, nil},
+		{"backoff capped at lockout duration", models.LoginAttempt{Key: "user:john", Failures: 9}, 10 * time.Second, nil},
+		{"ip key has no backoff", models.LoginAttempt{Key: "ip:10.0.0.1", Failures: 9}, 0, nil},
+		{"locked username", models.LoginAttempt{Key: "user:john", Failures: 10, LockedUntil: &lockedUntil}, 0, &lockedUntil},
+		{"locked ip", models.LoginAttempt{Key: "ip:10.0.0.1", Failures: 50, LockedUntil: &lockedUntil}, 0, &lockedUntil},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.attempt.LastFailureAt = lastFailure
+			got := guard.blockedUntil(&tt.attempt)
+
+			want := time.Time{}
+			switch {
+			case tt.wantTime != nil:
+				want = *tt.wantTime
+			case tt.wantWait > 0:
+				want = lastFailure.Add(tt.wantWait)
+			}
+			if !got.Equal(want) {
+				t.Errorf("blockedUntil() = %v, want %v", got, want)
+			}
+		})
+	}
+}
+
+func TestUsernameKey(t *testing.T) {
+	defer models.SetUniquenessScope(models.UniquenessGlobal)
+
+	tests := []struct {
+		name           string
+		perOrg         bool
+		organizationID uint
+		username       string
+		want           string
+	}{
+		{"global uniqueness", false, 5, "john", "user:john"},
+		{"normalized", false, 5, "  John ", "user:john"},
+		{"per organization", true, 5, "John", "user:5:john"},
+		{"per organization default", true, models.DefaultOrganizationID, "john", "user:1:john"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			scope := models.UniquenessGlobal
+			if tt.perOrg {
+				scope = models.UniquenessOrganization
+			}
+			models.SetUniquenessScope(scope)
+
+			if got := usernameKey(tt.organizationID, tt.username); got != tt.want {
+				t.Errorf("usernameKey() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
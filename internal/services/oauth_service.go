@@ -0,0 +1,885 @@
+package services
+
+import (
+	"crypto/subtle"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/LutfiyaAinurrahmanP/boilerplate_fiber_restful_api/internal/config"
+	"github.com/LutfiyaAinurrahmanP/boilerplate_fiber_restful_api/internal/jwtauth"
+	"github.com/LutfiyaAinurrahmanP/boilerplate_fiber_restful_api/internal/models"
+	"github.com/LutfiyaAinurrahmanP/boilerplate_fiber_restful_api/internal/oidc"
+	"github.com/LutfiyaAinurrahmanP/boilerplate_fiber_restful_api/internal/repositories"
+	"github.com/LutfiyaAinurrahmanP/boilerplate_fiber_restful_api/internal/utils"
+	"github.com/LutfiyaAinurrahmanP/boilerplate_fiber_restful_api/internal/validators"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// OAuthError adalah error protokol OAuth2 (RFC 6749 section 5.2) yang dikirim ke client apa adanya
+// Endpoint OAuth2 memakai format {"error", "error_description"}, bukan format response standar API ini
+type OAuthError struct {
+	Status      int
+	Code        string
+	Description string
+}
+
+func (e *OAuthError) Error() string {
+	return e.Description
+}
+
+func newOAuthError(status int, code, description string) *OAuthError {
+	return &OAuthError{Status: status, Code: code, Description: description}
+}
+
+// OAuthClientCredentials adalah kredensial client dari header Basic (client_secret_basic) atau body (client_secret_post)
+type OAuthClientCredentials struct {
+	ClientID     string
+	ClientSecret string
+}
+
+// CreatedOAuthClient adalah client beserta secret-nya (hanya dikembalikan saat dibuat / rotasi secret)
+type CreatedOAuthClient struct {
+	Client       *models.OAuthClient `json:"client"`
+	ClientSecret string              `json:"client_secret,omitempty"`
+}
+
+// OAuthAuthorization adalah authorization request yang sudah divalidasi, ditampilkan di halaman consent
+// ErrorRedirect terisi jika request tidak valid tetapi redirect_uri terpercaya, user harus diarahkan ke URL tersebut
+type OAuthAuthorization struct {
+	ClientID      string   `json:"client_id"`
+	ClientName    string   `json:"client_name"`
+	RedirectURI   string   `json:"redirect_uri"`
+	Scopes        []string `json:"scopes"`
+	ErrorRedirect string   `json:"error_redirect,omitempty"`
+}
+
+// OAuthTokenResponse adalah response sukses token endpoint (RFC 6749 section 5.1)
+type OAuthTokenResponse struct {
+	AccessToken  string `json:"access_token"`
+	TokenType    string `json:"token_type"`
+	ExpiresIn    int64  `json:"expires_in"`
+	RefreshToken string `json:"refresh_token,omitempty"`
+	Scope        string `json:"scope,omitempty"`
+	IDToken      string `json:"id_token,omitempty"`
+}
+
+// OAuthIntrospection adalah response introspection endpoint (RFC 7662 section 2.2)
+type OAuthIntrospection struct {
+	Active    bool   `json:"active"`
+	Scope     string `json:"scope,omitempty"`
+	ClientID  string `json:"client_id,omitempty"`
+	Username  string `json:"username,omitempty"`
+	TokenType string `json:"token_type,omitempty"`
+	Exp       int64  `json:"exp,omitempty"`
+	Iat       int64  `json:"iat,omitempty"`
+	Sub       string `json:"sub,omitempty"`
+	Aud       string `json:"aud,omitempty"`
+	Iss       string `json:"iss,omitempty"`
+	Jti       string `json:"jti,omitempty"`
+}
+
+type OAuthService interface {
+	// Manajemen client (admin)
+	CreateClient(adminID uint, req *validators.CreateOAuthClientRequest) (*CreatedOAuthClient, error)
+	ListClients() ([]models.OAuthClient, error)
+	GetClient(id uint) (*models.OAuthClient, error)
+	UpdateClient(id uint, req *validators.UpdateOAuthClientRequest) (*models.OAuthClient, error)
+	RotateClientSecret(id uint) (*CreatedOAuthClient, error)
+	DisableClient(id uint) error
+
+	// Protokol OAuth2
+	PrepareAuthorization(req *validators.OAuthAuthorizeRequest) (*OAuthAuthorization, error)
+	Authorize(userID uint, req *validators.OAuthAuthorizeRequest) (string, error)
+	Token(creds OAuthClientCredentials, req *validators.OAuthTokenRequest) (*OAuthTokenResponse, error)
+	Introspect(creds OAuthClientCredentials, token, tokenTypeHint string) (*OAuthIntrospection, error)
+	Revoke(creds OAuthClientCredentials, token, tokenTypeHint string) error
+	Metadata() map[string]interface{}
+}
+
+type oauthService struct {
+	clientRepo   repositories.OAuthClientRepository
+	codeRepo     repositories.OAuthCodeRepository
+	tokenRepo    repositories.OAuthTokenRepository
+	userRepo     repositories.UserRepository
+	tokenManager *jwtauth.Manager
+	cfg          *config.Config
+}
+
+func NewOAuthService(clientRepo repositories.OAuthClientRepository, codeRepo repositories.OAuthCodeRepository, tokenRepo repositories.OAuthTokenRepository, userRepo repositories.UserRepository, tokenManager *jwtauth.Manager, cfg *config.Config) OAuthService {
+	return &oauthService{
+		clientRepo:   clientRepo,
+		codeRepo:     codeRepo,
+		tokenRepo:    tokenRepo,
+		userRepo:     userRepo,
+		tokenManager: tokenManager,
+		cfg:          cfg,
+	}
+}
+
+// ============================================
+// CLIENT MANAGEMENT
+// ============================================
+
+func (s *oauthService) CreateClient(adminID uint, req *validators.CreateOAuthClientRequest) (*CreatedOAuthClient, error) {
+	client := &models.OAuthClient{
+		Name:         req.Name,
+		Confidential: *req.Confidential,
+		RedirectURIs: uniqueStrings(req.RedirectURIs),
+		GrantTypes:   uniqueStrings(req.GrantTypes),
+		Scopes:       uniqueStrings(req.Scopes),
+		CreatedBy:    adminID,
+	}
+	if err := validateOAuthClient(client); err != nil {
+		return nil, err
+	}
+
+	clientID, err := utils.GenerateRandomToken(16)
+	if err != nil {
+		return nil, fmt.Errorf("failed to generate client id: %w", err)
+	}
+	client.ClientID = clientID
+
+	var secret string
+	if client.Confidential {
+		if secret, err = utils.GenerateRandomToken(32); err != nil {
+			return nil, fmt.Errorf("failed to generate client secret: %w", err)
+		}
+		client.SecretHash = utils.HashToken(secret)
+	}
+
+	if err := s.clientRepo.Create(client); err != nil {
+		return nil, fmt.Errorf("failed to create oauth client: %w", err)
+	}
+	return &CreatedOAuthClient{Client: client, ClientSecret: secret}, nil
+}
+
+func (s *oauthService) ListClients() ([]models.OAuthClient, error) {
+	clients, err := s.clientRepo.FindAll()
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch oauth clients: %w", err)
+	}
+	return clients, nil
+}
+
+func (s *oauthService) GetClient(id uint) (*models.OAuthClient, error) {
+	client, err := s.clientRepo.FindByID(id)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, errors.New("oauth client not found")
+		}
+		return nil, fmt.Errorf("failed to find oauth client: %w", err)
+	}
+	return client, nil
+}
+
+func (s *oauthService) UpdateClient(id uint, req *validators.UpdateOAuthClientRequest) (*models.OAuthClient, error) {
+	client, err := s.GetClient(id)
+	if err != nil {
+		return nil, err
+	}
+
+	if req.Name != "" {
+		client.Name = req.Name
+	}
+	if req.RedirectURIs != nil {
+		client.RedirectURIs = uniqueStrings(req.RedirectURIs)
+	}
+	if req.GrantTypes != nil {
+		client.GrantTypes = uniqueStrings(req.GrantTypes)
+	}
+	if req.Scopes != nil {
+		client.Scopes = uniqueStrings(req.Scopes)
+	}
+	if err := validateOAuthClient(client); err != nil {
+		return nil, err
+	}
+
+	if err := s.clientRepo.Update(client); err != nil {
+		return nil, fmt.Errorf("failed to update oauth client: %w", err)
+	}
+	return client, nil
+}
+
+// RotateClientSecret mengganti secret client confidential, secret lama langsung tidak berlaku
+// Token yang sudah diterbitkan tetap berlaku sampai expired atau di-revoke
+func (s *oauthService) RotateClientSecret(id uint) (*CreatedOAuthClient, error) {
+	client, err := s.GetClient(id)
+	if err != nil {
+		return nil, err
+	}
+	if !client.Confidential {
+		return nil, errors.New("public clients do not have a secret")
+	}
+
+	secret, err := utils.GenerateRandomToken(32)
+	if err != nil {
+		return nil, fmt.Errorf("failed to generate client secret: %w", err)
+	}
+	client.SecretHash = utils.HashToken(secret)
+	if err := s.clientRepo.Update(client); err != nil {
+		return nil, fmt.Errorf("failed to update oauth client: %w", err)
+	}
+	return &CreatedOAuthClient{Client: client, ClientSecret: secret}, nil
+}
+
+// DisableClient menonaktifkan client dan me-revoke semua token yang pernah diterbitkan untuknya
+func (s *oauthService) DisableClient(id uint) error {
+	client, err := s.GetClient(id)
+	if err != nil {
+		return err
+	}
+
+	disabled, err := s.clientRepo.Disable(client.ID)
+	if err != nil {
+		return fmt.Errorf("failed to disable oauth client: %w", err)
+	}
+	if !disabled {
+		return errors.New("oauth client not found")
+	}
+
+	if err := s.tokenRepo.RevokeByClient(client.ClientID); err != nil {
+		return fmt.Errorf("failed to revoke oauth tokens: %w", err)
+	}
+	return nil
+}
+
+// validateOAuthClient memastikan kombinasi tipe client, grant, dan redirect URI masuk akal
+func validateOAuthClient(client *models.OAuthClient) error {
+	if client.AllowsGrant(models.OAuthGrantAuthorizationCode) && len(client.RedirectURIs) == 0 {
+		return errors.New("redirect_uris is required for the authorization_code grant")
+	}
+	if client.AllowsGrant(models.OAuthGrantRefreshToken) && !client.AllowsGrant(models.OAuthGrantAuthorizationCode) {
+		return errors.New("refresh_token grant requires the authorization_code grant")
+	}
+	if client.AllowsGrant(models.OAuthGrantClientCredentials) && !client.Confidential {
+		return errors.New("client_credentials grant requires a confidential client")
+	}
+	for _, redirectURI := range client.RedirectURIs {
+		if err := validateRedirectURI(redirectURI); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// validateRedirectURI: https, http hanya untuk loopback (development / aplikasi native),
+// atau private-use scheme aplikasi mobile dengan format reverse domain (RFC 8252), tanpa fragment
+func validateRedirectURI(redirectURI string) error {
+	parsed, err := url.Parse(redirectURI)
+	if err != nil || parsed.Scheme == "" {
+		return fmt.Errorf("invalid redirect uri: %s", redirectURI)
+	}
+	if parsed.Fragment != "" || strings.Contains(redirectURI, "#") {
+		return fmt.Errorf("redirect uri must not contain a fragment: %s", redirectURI)
+	}
+
+	switch parsed.Scheme {
+	case "https":
+		return nil
+	case "http":
+		switch parsed.Hostname() {
+		case "localhost", "127.0.0.1", "::1":
+			return nil
+		}
+		return fmt.Errorf("redirect uri must use https (http is only allowed for localhost): %s", redirectURI)
+	default:
+		if strings.Contains(parsed.Scheme, ".") {
+			return nil
+		}
+		return fmt.Errorf("redirect uri must use https or a reverse-domain app scheme: %s", redirectURI)
+	}
+}
+
+// ============================================
+// AUTHORIZATION ENDPOINT
+// ============================================
+
+// PrepareAuthorization memvalidasi authorization request sebelum halaman consent ditampilkan
+// Client / redirect_uri tidak valid dikembalikan sebagai error (tidak boleh redirect ke URI yang tidak terdaftar),
+// error lain dikembalikan lewat ErrorRedirect ke redirect_uri client (RFC 6749 section 4.1.2.1)
+func (s *oauthService) PrepareAuthorization(req *validators.OAuthAuthorizeRequest) (*OAuthAuthorization, error) {
+	client, err := s.clientRepo.FindByClientID(req.ClientID)
+	if err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, fmt.Errorf("failed to find oauth client: %w", err)
+	}
+	if client == nil || !client.IsActive() {
+		return nil, newOAuthError(http.StatusBadRequest, "invalid_request", "unknown or disabled client_id")
+	}
+
+	redirectURI := req.RedirectURI
+	if redirectURI == "" && len(client.RedirectURIs) == 1 {
+		redirectURI = client.RedirectURIs[0]
+	}
+	if !client.AllowsRedirectURI(redirectURI) {
+		return nil, newOAuthError(http.StatusBadRequest, "invalid_request", "redirect_uri is not registered for this client")
+	}
+
+	authorization := &OAuthAuthorization{
+		ClientID:    client.ClientID,
+		ClientName:  client.Name,
+		RedirectURI: redirectURI,
+	}
+
+	scopes, oauthErr := s.validateAuthorizeParams(client, req)
+	if oauthErr != nil {
+		authorization.ErrorRedirect = buildRedirectURI(redirectURI, map[string]string{
+			"error":             oauthErr.Code,
+			"error_description": oauthErr.Description,
+			"state":             req.State,
+		})
+		return authorization, nil
+	}
+
+	authorization.Scopes = scopes
+	return authorization, nil
+}
+
+func (s *oauthService) validateAuthorizeParams(client *models.OAuthClient, req *validators.OAuthAuthorizeRequest) ([]string, *OAuthError) {
+	if req.ResponseType != "code" {
+		return nil, newOAuthError(http.StatusBadRequest, "unsupported_response_type", "only response_type=code is supported")
+	}
+	if !client.AllowsGrant(models.OAuthGrantAuthorizationCode) {
+		return nil, newOAuthError(http.StatusBadRequest, "unauthorized_client", "client is not allowed to use the authorization_code grant")
+	}
+	// PKCE wajib untuk semua client, termasuk confidential (OAuth 2.1)
+	if req.CodeChallengeMethod != "S256" || len(req.CodeChallenge) < 43 || len(req.CodeChallenge) > 128 {
+		return nil, newOAuthError(http.StatusBadRequest, "invalid_request", "PKCE is required: code_challenge with code_challenge_method=S256")
+	}
+	if len(req.State) > 500 || len(req.Nonce) > 255 {
+		return nil, newOAuthError(http.StatusBadRequest, "invalid_request", "state or nonce is too long")
+	}
+	return resolveOAuthScopes(client, req.Scope)
+}
+
+// Authorize mencatat keputusan user di halaman consent dan mengembalikan URL redirect ke client
+// Disetujui: redirect membawa code + state, ditolak: redirect membawa error=access_denied
+func (s *oauthService) Authorize(userID uint, req *validators.OAuthAuthorizeRequest) (string, error) {
+	authorization, err := s.PrepareAuthorization(req)
+	if err != nil {
+		return "", err
+	}
+	if authorization.ErrorRedirect != "" {
+		return authorization.ErrorRedirect, nil
+	}
+	if req.Approve == nil {
+		return "", newOAuthError(http.StatusBadRequest, "invalid_request", "approve is required")
+	}
+	if !*req.Approve {
+		return buildRedirectURI(authorization.RedirectURI, map[string]string{
+			"error":             "access_denied",
+			"error_description": "the user denied the request",
+			"state":             req.State,
+		}), nil
+	}
+
+	if _, err := s.userRepo.FindById(userID); err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return "", errors.New("user not found")
+		}
+		return "", fmt.Errorf("failed to find user: %w", err)
+	}
+
+	code, err := utils.GenerateRandomToken(32)
+	if err != nil {
+		return "", fmt.Errorf("failed to generate authorization code: %w", err)
+	}
+	err = s.codeRepo.Create(&models.OAuthAuthorizationCode{
+		CodeHash:      utils.HashToken(code),
+		ClientID:      authorization.ClientID,
+		UserID:        userID,
+		RedirectURI:   authorization.RedirectURI,
+		Scope:         strings.Join(authorization.Scopes, " "),
+		CodeChallenge: req.CodeChallenge,
+		Nonce:         req.Nonce,
+		ExpiresAt:     time.Now().Add(s.cfg.OAuthCodeTTL),
+	})
+	if err != nil {
+		return "", fmt.Errorf("failed to store authorization code: %w", err)
+	}
+
+	return buildRedirectURI(authorization.RedirectURI, map[string]string{
+		"code":  code,
+		"state": req.State,
+		"iss":   s.issuer(),
+	}), nil
+}
+
+// ============================================
+// TOKEN ENDPOINT
+// ============================================
+
+func (s *oauthService) Token(creds OAuthClientCredentials, req *validators.OAuthTokenRequest) (*OAuthTokenResponse, error) {
+	client, err := s.authenticateClient(creds)
+	if err != nil {
+		return nil, err
+	}
+
+	switch req.GrantType {
+	case "":
+		return nil, newOAuthError(http.StatusBadRequest, "invalid_request", "grant_type is required")
+	case models.OAuthGrantAuthorizationCode, models.OAuthGrantRefreshToken, models.OAuthGrantClientCredentials:
+	default:
+		return nil, newOAuthError(http.StatusBadRequest, "unsupported_grant_type", "unsupported grant_type")
+	}
+	if !client.AllowsGrant(req.GrantType) {
+		return nil, newOAuthError(http.StatusBadRequest, "unauthorized_client", "client is not allowed to use this grant_type")
+	}
+
+	switch req.GrantType {
+	case models.OAuthGrantAuthorizationCode:
+		return s.exchangeAuthorizationCode(client, req)
+	case models.OAuthGrantRefreshToken:
+		return s.exchangeRefreshToken(client, req)
+	default:
+		return s.issueClientCredentials(client, req)
+	}
+}
+
+func (s *oauthService) exchangeAuthorizationCode(client *models.OAuthClient, req *validators.OAuthTokenRequest) (*OAuthTokenResponse, error) {
+	if req.Code == "" || req.CodeVerifier == "" {
+		return nil, newOAuthError(http.StatusBadRequest, "invalid_request", "code and code_verifier are required")
+	}
+
+	code, err := s.codeRepo.FindByHash(utils.HashToken(req.Code))
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, newOAuthError(http.StatusBadRequest, "invalid_grant", "invalid authorization code")
+		}
+		return nil, fmt.Errorf("failed to find authorization code: %w", err)
+	}
+	if code.ClientID != client.ClientID {
+		return nil, newOAuthError(http.StatusBadRequest, "invalid_grant", "invalid authorization code")
+	}
+	if code.ConsumedAt != nil {
+		return nil, s.rejectReusedCode(code.ID)
+	}
+	if !code.IsUsable() {
+		return nil, newOAuthError(http.StatusBadRequest, "invalid_grant", "authorization code has expired")
+	}
+	if req.RedirectURI != "" && req.RedirectURI != code.RedirectURI {
+		return nil, newOAuthError(http.StatusBadRequest, "invalid_grant", "redirect_uri does not match the authorization request")
+	}
+	if subtle.ConstantTimeCompare([]byte(oidc.CodeChallengeS256(req.CodeVerifier)), []byte(code.CodeChallenge)) != 1 {
+		return nil, newOAuthError(http.StatusBadRequest, "invalid_grant", "PKCE verification failed")
+	}
+
+	consumed, err := s.codeRepo.MarkConsumed(code.ID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to consume authorization code: %w", err)
+	}
+	if !consumed {
+		return nil, s.rejectReusedCode(code.ID)
+	}
+
+	user, err := s.userRepo.FindById(code.UserID)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, newOAuthError(http.StatusBadRequest, "invalid_grant", "user no longer exists")
+		}
+		return nil, fmt.Errorf("failed to find user: %w", err)
+	}
+
+	return s.issueTokens(client, user, code.Scope, uuid.NewString(), &code.ID, code.Nonce)
+}
+
+// rejectReusedCode me-revoke token hasil code yang dipakai dua kali, kemungkinan code dicuri
+func (s *oauthService) rejectReusedCode(codeID uint) error {
+	if err := s.tokenRepo.RevokeByAuthorizationCode(codeID); err != nil {
+		return fmt.Errorf("failed to revoke oauth tokens: %w", err)
+	}
+	return newOAuthError(http.StatusBadRequest, "invalid_grant", "authorization code has already been used")
+}
+
+// exchangeRefreshToken merotasi refresh token, pemakaian ulang token lama me-revoke seluruh family
+func (s *oauthService) exchangeRefreshToken(client *models.OAuthClient, req *validators.OAuthTokenRequest) (*OAuthTokenResponse, error) {
+	if req.RefreshToken == "" {
+		return nil, newOAuthError(http.StatusBadRequest, "invalid_request", "refresh_token is required")
+	}
+
+	token, err := s.tokenRepo.FindByRefreshHash(utils.HashToken(req.RefreshToken))
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, newOAuthError(http.StatusBadRequest, "invalid_grant", "invalid refresh token")
+		}
+		return nil, fmt.Errorf("failed to find refresh token: %w", err)
+	}
+	if token.ClientID != client.ClientID || token.UserID == nil {
+		return nil, newOAuthError(http.StatusBadRequest, "invalid_grant", "invalid refresh token")
+	}
+	if token.RevokedAt != nil {
+		return nil, s.rejectReusedRefreshToken(token.FamilyID)
+	}
+	if !token.RefreshActive() {
+		return nil, newOAuthError(http.StatusBadRequest, "invalid_grant", "refresh token has expired")
+	}
+
+	// Scope boleh dipersempit, tidak boleh diperluas (RFC 6749 section 6)
+	scope := token.Scope
+	if requested := strings.Fields(req.Scope); len(requested) > 0 {
+		granted := strings.Fields(token.Scope)
+		for _, item := range requested {
+			if !containsScope(granted, item) {
+				return nil, newOAuthError(http.StatusBadRequest, "invalid_scope", fmt.Sprintf("scope %q was not granted", item))
+			}
+		}
+		scope = strings.Join(uniqueStrings(requested), " ")
+	}
+
+	revoked, err := s.tokenRepo.Revoke(token.ID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to rotate refresh token: %w", err)
+	}
+	if !revoked {
+		return nil, s.rejectReusedRefreshToken(token.FamilyID)
+	}
+
+	user, err := s.userRepo.FindById(*token.UserID)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, newOAuthError(http.StatusBadRequest, "invalid_grant", "user no longer exists")
+		}
+		return nil, fmt.Errorf("failed to find user: %w", err)
+	}
+	if user.TokenVersion != token.UserTokenVersion {
+		if err := s.tokenRepo.RevokeFamily(token.FamilyID); err != nil {
+			return nil, fmt.Errorf("failed to revoke oauth tokens: %w", err)
+		}
+		return nil, newOAuthError(http.StatusBadRequest, "invalid_grant", "refresh token has been revoked")
+	}
+
+	return s.issueTokens(client, user, scope, token.FamilyID, token.AuthorizationCodeID, "")
+}
+
+func (s *oauthService) rejectReusedRefreshToken(familyID string) error {
+	if err := s.tokenRepo.RevokeFamily(familyID); err != nil {
+		return fmt.Errorf("failed to revoke oauth tokens: %w", err)
+	}
+	return newOAuthError(http.StatusBadRequest, "invalid_grant", "refresh token has already been used")
+}
+
+// issueClientCredentials menerbitkan access token atas nama client sendiri (service-to-service), tanpa refresh token
+func (s *oauthService) issueClientCredentials(client *models.OAuthClient, req *validators.OAuthTokenRequest) (*OAuthTokenResponse, error) {
+	scopes, oauthErr := resolveOAuthScopes(client, req.Scope)
+	if oauthErr != nil {
+		return nil, oauthErr
+	}
+	if containsScope(scopes, "openid") {
+		if req.Scope != "" {
+			return nil, newOAuthError(http.StatusBadRequest, "invalid_scope", "openid scope requires a user")
+		}
+		scopes = removeScope(scopes, "openid")
+	}
+
+	return s.issueTokens(client, nil, strings.Join(scopes, " "), uuid.NewString(), nil, "")
+}
+
+// issueTokens menerbitkan access token JWT (dicatat jti-nya), refresh token untuk grant user,
+// dan ID token jika scope openid diberikan
+func (s *oauthService) issueTokens(client *models.OAuthClient, user *models.User, scope, familyID string, codeID *uint, nonce string) (*OAuthTokenResponse, error) {
+	now := time.Now()
+	accessToken, claims, err := s.tokenManager.IssueOAuthAccessToken(user, client.ClientID, scope, s.cfg.OAuthAccessTokenTTL, now)
+	if err != nil {
+		return nil, fmt.Errorf("failed to sign access token: %w", err)
+	}
+
+	record := &models.OAuthToken{
+		FamilyID:            familyID,
+		ClientID:            client.ClientID,
+		Scope:               scope,
+		AccessJTI:           claims.ID,
+		AccessExpiresAt:     claims.ExpiresAt.Time,
+		AuthorizationCodeID: codeID,
+	}
+	response := &OAuthTokenResponse{
+		AccessToken: accessToken,
+		TokenType:   "Bearer",
+		ExpiresIn:   int64(s.cfg.OAuthAccessTokenTTL.Seconds()),
+		Scope:       scope,
+	}
+
+	if user != nil {
+		record.UserID = &user.ID
+		record.UserTokenVersion = user.TokenVersion
+
+		if client.AllowsGrant(models.OAuthGrantRefreshToken) {
+			refreshToken, err := utils.GenerateRandomToken(32)
+			if err != nil {
+				return nil, fmt.Errorf("failed to generate refresh token: %w", err)
+			}
+			refreshHash := utils.HashToken(refreshToken)
+			refreshExpiresAt := now.Add(s.cfg.OAuthRefreshTokenTTL)
+			record.RefreshHash = &refreshHash
+			record.RefreshExpiresAt = &refreshExpiresAt
+			response.RefreshToken = refreshToken
+		}
+
+		if scopes := strings.Fields(scope); containsScope(scopes, "openid") {
+			idToken, err := s.tokenManager.IssueIDToken(user, client.ClientID, nonce, scopes, s.cfg.OAuthAccessTokenTTL, now)
+			if err != nil {
+				return nil, fmt.Errorf("failed to sign id token: %w", err)
+			}
+			response.IDToken = idToken
+		}
+	}
+
+	if err := s.tokenRepo.Create(record); err != nil {
+		return nil, fmt.Errorf("failed to store oauth token: %w", err)
+	}
+	return response, nil
+}
+
+// ============================================
+// INTROSPECTION & REVOCATION
+// ============================================
+
+// Introspect mengecek status token untuk resource server (RFC 7662)
+// Hanya client confidential yang boleh bertanya, token milik client lain tetap bisa dicek
+func (s *oauthService) Introspect(creds OAuthClientCredentials, token, tokenTypeHint string) (*OAuthIntrospection, error) {
+	client, err := s.authenticateClient(creds)
+	if err != nil {
+		return nil, err
+	}
+	if !client.Confidential {
+		return nil, newOAuthError(http.StatusUnauthorized, "invalid_client", "only confidential clients may introspect tokens")
+	}
+	if token == "" {
+		return nil, newOAuthError(http.StatusBadRequest, "invalid_request", "token is required")
+	}
+
+	inactive := &OAuthIntrospection{Active: false}
+	record, isRefresh, err := s.lookupToken(token, tokenTypeHint)
+	if err != nil || record == nil {
+		return inactive, err
+	}
+
+	active := record.AccessActive()
+	if isRefresh {
+		active = record.RefreshActive()
+	}
+	if !active {
+		return inactive, nil
+	}
+
+	owner, err := s.clientRepo.FindByClientID(record.ClientID)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return inactive, nil
+		}
+		return nil, fmt.Errorf("failed to find oauth client: %w", err)
+	}
+	if !owner.IsActive() {
+		return inactive, nil
+	}
+
+	result := &OAuthIntrospection{
+		Active:   true,
+		Scope:    record.Scope,
+		ClientID: record.ClientID,
+		Iat:      record.CreatedAt.Unix(),
+		Sub:      record.ClientID,
+		Aud:      record.ClientID,
+		Iss:      s.cfg.JWTIssuer,
+	}
+	if isRefresh {
+		result.Exp = record.RefreshExpiresAt.Unix()
+	} else {
+		result.TokenType = "Bearer"
+		result.Exp = record.AccessExpiresAt.Unix()
+		result.Jti = record.AccessJTI
+	}
+
+	if record.UserID != nil {
+		user, err := s.userRepo.FindById(*record.UserID)
+		if err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return inactive, nil
+			}
+			return nil, fmt.Errorf("failed to find user: %w", err)
+		}
+		if user.TokenVersion != record.UserTokenVersion {
+			return inactive, nil
+		}
+		result.Sub = strconv.FormatUint(uint64(user.ID), 10)
+		result.Username = user.Username
+	}
+
+	return result, nil
+}
+
+// Revoke mencabut token milik client pemanggil (RFC 7009)
+// Token tidak dikenal atau milik client lain diabaikan (tetap sukses) agar tidak membocorkan informasi
+// Revoke refresh token mencabut seluruh family, revoke access token juga mencabut refresh token pasangannya
+func (s *oauthService) Revoke(creds OAuthClientCredentials, token, tokenTypeHint string) error {
+	client, err := s.authenticateClient(creds)
+	if err != nil {
+		return err
+	}
+	if token == "" {
+		return newOAuthError(http.StatusBadRequest, "invalid_request", "token is required")
+	}
+
+	record, isRefresh, err := s.lookupToken(token, tokenTypeHint)
+	if err != nil {
+		return err
+	}
+	if record == nil || record.ClientID != client.ClientID {
+		return nil
+	}
+
+	if isRefresh {
+		if err := s.tokenRepo.RevokeFamily(record.FamilyID); err != nil {
+			return fmt.Errorf("failed to revoke oauth tokens: %w", err)
+		}
+		return nil
+	}
+	if _, err := s.tokenRepo.Revoke(record.ID); err != nil {
+		return fmt.Errorf("failed to revoke oauth token: %w", err)
+	}
+	return nil
+}
+
+// lookupToken mencari token sebagai access token (JWT) atau refresh token (opaque)
+// token_type_hint hanya menentukan urutan pencarian, return nil jika tidak ditemukan
+func (s *oauthService) lookupToken(token, tokenTypeHint string) (*models.OAuthToken, bool, error) {
+	findAccess := func() (*models.OAuthToken, error) {
+		claims, err := s.tokenManager.ParseOAuthAccessToken(token)
+		if err != nil {
+			return nil, nil
+		}
+		return s.tokenRepo.FindByJTI(claims.ID)
+	}
+	findRefresh := func() (*models.OAuthToken, error) {
+		return s.tokenRepo.FindByRefreshHash(utils.HashToken(token))
+	}
+
+	lookups := []func() (*models.OAuthToken, error){findAccess, findRefresh}
+	if tokenTypeHint == "refresh_token" {
+		lookups = []func() (*models.OAuthToken, error){findRefresh, findAccess}
+	}
+
+	for _, lookup := range lookups {
+		record, err := lookup()
+		if err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, false, fmt.Errorf("failed to find oauth token: %w", err)
+		}
+		if record != nil {
+			isRefresh := record.RefreshHash != nil && *record.RefreshHash == utils.HashToken(token)
+			return record, isRefresh, nil
+		}
+	}
+	return nil, false, nil
+}
+
+// authenticateClient memverifikasi client: confidential wajib secret, public hanya client_id tanpa secret
+func (s *oauthService) authenticateClient(creds OAuthClientCredentials) (*models.OAuthClient, error) {
+	if creds.ClientID == "" {
+		return nil, newOAuthError(http.StatusUnauthorized, "invalid_client", "client authentication is required")
+	}
+
+	client, err := s.clientRepo.FindByClientID(creds.ClientID)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, newOAuthError(http.StatusUnauthorized, "invalid_client", "client authentication failed")
+		}
+		return nil, fmt.Errorf("failed to find oauth client: %w", err)
+	}
+	if !client.IsActive() {
+		return nil, newOAuthError(http.StatusUnauthorized, "invalid_client", "client authentication failed")
+	}
+
+	if client.Confidential {
+		secretHash := utils.HashToken(creds.ClientSecret)
+		if creds.ClientSecret == "" || subtle.ConstantTimeCompare([]byte(secretHash), []byte(client.SecretHash)) != 1 {
+			return nil, newOAuthError(http.StatusUnauthorized, "invalid_client", "client authentication failed")
+		}
+	} else if creds.ClientSecret != "" {
+		return nil, newOAuthError(http.StatusUnauthorized, "invalid_client", "public clients must not send a client secret")
+	}
+	return client, nil
+}
+
+// ============================================
+// METADATA
+// ============================================
+
+// Metadata adalah authorization server metadata (RFC 8414)
+func (s *oauthService) Metadata() map[string]interface{} {
+	return map[string]interface{}{
+		"issuer":                                         s.issuer(),
+		"authorization_endpoint":                         s.cfg.OAuthBaseURL + "/oauth/authorize",
+		"token_endpoint":                                 s.cfg.OAuthBaseURL + "/oauth/token",
+		"introspection_endpoint":                         s.cfg.OAuthBaseURL + "/oauth/introspect",
+		"revocation_endpoint":                            s.cfg.OAuthBaseURL + "/oauth/revoke",
+		"jwks_uri":                                       s.cfg.OAuthBaseURL + "/.well-known/jwks.json",
+		"response_types_supported":                       []string{"code"},
+		"grant_types_supported":                          []string{models.OAuthGrantAuthorizationCode, models.OAuthGrantRefreshToken, models.OAuthGrantClientCredentials},
+		"code_challenge_methods_supported":               []string{"S256"},
+		"token_endpoint_auth_methods_supported":          []string{"client_secret_basic", "client_secret_post", "none"},
+		"introspection_endpoint_auth_methods_supported":  []string{"client_secret_basic", "client_secret_post"},
+		"revocation_endpoint_auth_methods_supported":     []string{"client_secret_basic", "client_secret_post", "none"},
+		"authorization_response_iss_parameter_supported": true,
+	}
+}
+
+// issuer sama dengan claim "iss" di token, fallback ke OAUTH_BASE_URL jika JWT_ISSUER tidak di-set
+func (s *oauthService) issuer() string {
+	if s.cfg.JWTIssuer != "" {
+		return s.cfg.JWTIssuer
+	}
+	return s.cfg.OAuthBaseURL
+}
+
+// resolveOAuthScopes memvalidasi scope yang diminta terhadap scope client, scope kosong berarti semua scope client
+func resolveOAuthScopes(client *models.OAuthClient, rawScope string) ([]string, *OAuthError) {
+	requested := uniqueStrings(strings.Fields(rawScope))
+	if len(requested) == 0 {
+		return append([]string{}, client.Scopes...), nil
+	}
+	for _, scope := range requested {
+		if !client.AllowsScope(scope) {
+			return nil, newOAuthError(http.StatusBadRequest, "invalid_scope", fmt.Sprintf("scope %q is not allowed for this client", scope))
+		}
+	}
+	return requested, nil
+}
+
+func containsScope(scopes []string, scope string) bool {
+	for _, s := range scopes {
+		if s == scope {
+			return true
+		}
+	}
+	return false
+}
+
+func removeScope(scopes []string, scope string) []string {
+	result := make([]string, 0, len(scopes))
+	for _, s := range scopes {
+		if s != scope {
+			result = append(result, s)
+		}
+	}
+	return result
+}
+
+// buildRedirectURI menambahkan parameter (yang tidak kosong) ke query redirect_uri client
+func buildRedirectURI(redirectURI string, params map[string]string) string {
+	parsed, err := url.Parse(redirectURI)
+	if err != nil {
+		return redirectURI
+	}
+	query := parsed.Query()
+	for key, value := range params {
+		if value != "" {
+			query.Set(key, value)
+		}
+	}
+	parsed.RawQuery = query.Encode()
+	return parsed.String()
+}
@@ -0,0 +1,386 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"strings"
+	"time"
+
+	"github.com/LutfiyaAinurrahmanP/boilerplate_fiber_restful_api/internal/config"
+	"github.com/LutfiyaAinurrahmanP/boilerplate_fiber_restful_api/internal/models"
+	"github.com/LutfiyaAinurrahmanP/boilerplate_fiber_restful_api/internal/oidc"
+	"github.com/LutfiyaAinurrahmanP/boilerplate_fiber_restful_api/internal/repositories"
+	"github.com/LutfiyaAinurrahmanP/boilerplate_fiber_restful_api/internal/utils"
+	"gorm.io/gorm"
+)
+
+// OIDCAuthorization adalah URL login identity provider beserta state yang harus diikat ke browser
+type OIDCAuthorization struct {
+	URL   string
+	State string
+}
+
+// OIDCCallbackResult adalah hasil callback OIDC
+// Login terisi untuk flow login, LinkedIdentity terisi untuk flow menghubungkan identitas ke akun yang sedang login
+type OIDCCallbackResult struct {
+	Login          *LoginResult
+	LinkedIdentity *models.UserIdentity
+}
+
+type OIDCService interface {
+	Providers() []string
+	BeginLogin(provider string) (*OIDCAuthorization, error)
+	BeginLink(provider string, userID uint) (*OIDCAuthorization, error)
+	Callback(provider, code, state string, client ClientInfo) (*OIDCCallbackResult, error)
+
+	ListIdentities(userID uint) ([]models.UserIdentity, error)
+	UnlinkIdentity(userID, identityID uint) error
+}
+
+type oidcService struct {
+	registry           *oidc.Registry
+	identityRepo       repositories.UserIdentityRepository
+	stateRepo          repositories.OIDCStateRepository
+	userRepo           repositories.UserRepository
+	authService        AuthService
+	registrationPolicy RegistrationPolicy
+	cfg                *config.Config
+}
+
+func NewOIDCService(registry *oidc.Registry, identityRepo repositories.UserIdentityRepository, stateRepo repositories.OIDCStateRepository, userRepo repositories.UserRepository, authService AuthService, registrationPolicy RegistrationPolicy, cfg *config.Config) OIDCService {
+	return &oidcService{
+		registry:           registry,
+		identityRepo:       identityRepo,
+		stateRepo:          stateRepo,
+		userRepo:           userRepo,
+		authService:        authService,
+		registrationPolicy: registrationPolicy,
+		cfg:                cfg,
+	}
+}
+
+func (s *oidcService) Providers() []string {
+	return s.registry.Names()
+}
+
+// BeginLogin memulai login OIDC untuk user yang belum login
+func (s *oidcService) BeginLogin(provider string) (*OIDCAuthorization, error) {
+	return s.begin(provider, nil)
+}
+
+// BeginLink memulai flow OIDC untuk menghubungkan identitas baru ke akun yang sedang login
+func (s *oidcService) BeginLink(provider string, userID uint) (*OIDCAuthorization, error) {
+	return s.begin(provider, &userID)
+}
+
+// begin membuat state, nonce, dan PKCE verifier lalu menyimpannya sampai callback
+func (s *oidcService) begin(providerName string, userID *uint) (*OIDCAuthorization, error) {
+	provider, ok := s.registry.Get(providerName)
+	if !ok {
+		return nil, errors.New("oidc provider not found")
+	}
+
+	state, err := utils.GenerateRandomToken(32)
+	if err != nil {
+		return nil, fmt.Errorf("failed to generate oidc state: %w", err)
+	}
+	nonce, err := utils.GenerateRandomToken(32)
+	if err != nil {
+		return nil, fmt.Errorf("failed to generate oidc nonce: %w", err)
+	}
+	codeVerifier, err := utils.GenerateRandomToken(48)
+	if err != nil {
+		return nil, fmt.Errorf("failed to generate pkce verifier: %w", err)
+	}
+
+	authURL, err := provider.AuthCodeURL(state, nonce, codeVerifier)
+	if err != nil {
+		return nil, err
+	}
+
+	loginState := &models.OIDCLoginState{
+		StateHash:    utils.HashToken(state),
+		Provider:     provider.Name(),
+		Nonce:        nonce,
+		CodeVerifier: codeVerifier,
+		UserID:       userID,
+		ExpiresAt:    time.Now().Add(s.cfg.OIDCStateTTL),
+	}
+	if err := s.stateRepo.Create(loginState); err != nil {
+		return nil, fmt.Errorf("failed to store oidc state: %w", err)
+	}
+
+	return &OIDCAuthorization{URL: authURL, State: state}, nil
+}
+
+// Callback memvalidasi state, menukar code (dengan PKCE verifier), memverifikasi ID token,
+// lalu login / membuat akun atau menghubungkan identitas ke akun yang memulai flow
+func (s *oidcService) Callback(providerName, code, state string, client ClientInfo) (*OIDCCallbackResult, error) {
+	provider, ok := s.registry.Get(providerName)
+	if !ok {
+		return nil, errors.New("oidc provider not found")
+	}
+
+	loginState, err := s.consumeState(provider.Name(), state)
+	if err != nil {
+		return nil, err
+	}
+
+	tokens, err := provider.Exchange(code, loginState.CodeVerifier)
+	if err != nil {
+		log.Printf("❌ OIDC code exchange with %s failed: %v", provider.Name(), err)
+		return nil, errors.New("oidc login failed")
+	}
+
+	claims, err := provider.VerifyIDToken(tokens.IDToken, loginState.Nonce)
+	if err != nil {
+		log.Printf("❌ OIDC id token from %s rejected: %v", provider.Name(), err)
+		return nil, errors.New("oidc login failed")
+	}
+
+	if loginState.UserID != nil {
+		identity, err := s.link(provider.Name(), *loginState.UserID, claims)
+		if err != nil {
+			return nil, err
+		}
+		return &OIDCCallbackResult{LinkedIdentity: identity}, nil
+	}
+
+	user, err := s.resolveUser(provider, claims)
+	if err != nil {
+		return nil, err
+	}
+
+	result, err := s.authService.LoginWithIdentity(user, client)
+	if err != nil {
+		return nil, err
+	}
+	return &OIDCCallbackResult{Login: result}, nil
+}
+
+func (s *oidcService) ListIdentities(userID uint) ([]models.UserIdentity, error) {
+	identities, err := s.identityRepo.FindByUser(userID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch identities: %w", err)
+	}
+	return identities, nil
+}
+
+// UnlinkIdentity memutus identitas OIDC, ditolak jika itu satu-satunya cara login user
+func (s *oidcService) UnlinkIdentity(userID, identityID uint) error {
+	user, err := s.userRepo.FindById(userID)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return errors.New("user not found")
+		}
+		return fmt.Errorf("failed to find user: %w", err)
+	}
+
+	if !user.HasPassword() {
+		count, err := s.identityRepo.CountByUser(userID)
+		if err != nil {
+			return fmt.Errorf("failed to count identities: %w", err)
+		}
+		if count <= 1 {
+			return errors.New("cannot unlink the only sign-in method, set a password first")
+		}
+	}
+
+	deleted, err := s.identityRepo.Delete(userID, identityID)
+	if err != nil {
+		return fmt.Errorf("failed to unlink identity: %w", err)
+	}
+	if !deleted {
+		return errors.New("identity not found")
+	}
+	return nil
+}
+
+// consumeState memastikan state dibuat untuk provider ini, belum expired, dan baru dipakai sekali
+func (s *oidcService) consumeState(provider, state string) (*models.OIDCLoginState, error) {
+	loginState, err := s.stateRepo.FindByHash(utils.HashToken(state))
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, errors.New("invalid or expired oidc state")
+		}
+		return nil, fmt.Errorf("failed to find oidc state: %w", err)
+	}
+
+	if loginState.Provider != provider || !loginState.IsUsable() {
+		return nil, errors.New("invalid or expired oidc state")
+	}
+
+	consumed, err := s.stateRepo.MarkConsumed(loginState.ID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to use oidc state: %w", err)
+	}
+	if !consumed {
+		return nil, errors.New("invalid or expired oidc state")
+	}
+	return loginState, nil
+}
+
+// resolveUser mencari user dari identitas yang sudah terhubung
+// Identitas baru dihubungkan ke akun dengan email sama (jika diizinkan) atau dibuatkan akun tanpa password
+func (s *oidcService) resolveUser(provider *oidc.Provider, claims *oidc.IDTokenClaims) (*models.User, error) {
+	email := utils.NormalizeEmail(claims.Email)
+
+	identity, err := s.identityRepo.FindByProviderSubject(provider.Name(), claims.Subject)
+	if err == nil {
+		user, err := s.userRepo.FindById(identity.UserID)
+		if err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return nil, errors.New("account is not available")
+			}
+			return nil, fmt.Errorf("failed to find user: %w", err)
+		}
+		if err := s.identityRepo.TouchLogin(identity.ID, email); err != nil {
+			return nil, fmt.Errorf("failed to update identity: %w", err)
+		}
+		return user, nil
+	}
+	if !errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, fmt.Errorf("failed to find identity: %w", err)
+	}
+
+	if email == "" {
+		return nil, errors.New("identity provider did not return an email address")
+	}
+
+	// Akun OIDC baru dibuat di organisasi default, email dicari di cakupan uniqueness organisasi tersebut
+	user, err := s.userRepo.ForOrganization(models.DefaultOrganizationID).FindByEmail(email)
+	switch {
+	case err == nil:
+		// Email yang belum diverifikasi IdP tidak boleh dipakai untuk mengambil alih akun lokal
+		if !provider.LinkByEmail() || !bool(claims.EmailVerified) {
+			return nil, errors.New("an account with this email already exists, sign in and link this provider first")
+		}
+	case errors.Is(err, gorm.ErrRecordNotFound):
+		if user, err = s.createUser(email, claims); err != nil {
+			return nil, err
+		}
+	default:
+		return nil, fmt.Errorf("failed to find user: %w", err)
+	}
+
+	now := time.Now()
+	newIdentity := &models.UserIdentity{
+		UserID:      user.ID,
+		Provider:    provider.Name(),
+		Subject:     claims.Subject,
+		Email:       email,
+		LastLoginAt: &now,
+	}
+	if err := s.identityRepo.Create(newIdentity); err != nil {
+		return nil, fmt.Errorf("failed to link identity: %w", err)
+	}
+	return user, nil
+}
+
+// createUser membuat akun tanpa password untuk identitas OIDC baru, tunduk pada REGISTRATION_MODE
+func (s *oidcService) createUser(email string, claims *oidc.IDTokenClaims) (*models.User, error) {
+	if err := s.registrationPolicy.AllowPublicSignup(email); err != nil {
+		return nil, err
+	}
+
+	username, err := s.uniqueUsername(claims.PreferredUsername, email)
+	if err != nil {
+		return nil, err
+	}
+
+	user := &models.User{
+		Username: username,
+		Email:    email,
+		Role:     models.RoleUser,
+	}
+	if claims.EmailVerified {
+		now := time.Now()
+		user.EmailVerifiedAt = &now
+	}
+
+	if err := s.userRepo.ForOrganization(models.DefaultOrganizationID).Create(user); err != nil {
+		return nil, fmt.Errorf("failed to create user: %w", err)
+	}
+	return user, nil
+}
+
+// link menghubungkan identitas ke akun yang memulai flow lewat BeginLink
+func (s *oidcService) link(provider string, userID uint, claims *oidc.IDTokenClaims) (*models.UserIdentity, error) {
+	if _, err := s.userRepo.FindById(userID); err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, errors.New("user not found")
+		}
+		return nil, fmt.Errorf("failed to find user: %w", err)
+	}
+
+	existing, err := s.identityRepo.FindByProviderSubject(provider, claims.Subject)
+	if err == nil {
+		if existing.UserID == userID {
+			return nil, errors.New("identity already linked to your account")
+		}
+		return nil, errors.New("identity already linked to another account")
+	}
+	if !errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, fmt.Errorf("failed to find identity: %w", err)
+	}
+
+	identity := &models.UserIdentity{
+		UserID:   userID,
+		Provider: provider,
+		Subject:  claims.Subject,
+		Email:    utils.NormalizeEmail(claims.Email),
+	}
+	if err := s.identityRepo.Create(identity); err != nil {
+		return nil, fmt.Errorf("failed to link identity: %w", err)
+	}
+	return identity, nil
+}
+
+// uniqueUsername menurunkan username dari preferred_username / email, ditambah angka acak jika sudah dipakai
+func (s *oidcService) uniqueUsername(preferred, email string) (string, error) {
+	base := sanitizeUsername(preferred)
+	if len(base) < 3 {
+		base = sanitizeUsername(email)
+	}
+	if len(base) < 3 {
+		base = "user"
+	}
+	if len(base) > 40 {
+		base = base[:40]
+	}
+
+	candidate := base
+	for i := 0; i < 5; i++ {
+		exists, err := s.userRepo.ForOrganization(models.DefaultOrganizationID).ExistsByUsername(candidate)
+		if err != nil {
+			return "", fmt.Errorf("failed to check username: %w", err)
+		}
+		if !exists {
+			return candidate, nil
+		}
+
+		suffix, err := utils.GenerateNumericCode(6)
+		if err != nil {
+			return "", fmt.Errorf("failed to generate username: %w", err)
+		}
+		candidate = base + "_" + suffix
+	}
+	return "", errors.New("failed to generate a unique username")
+}
+
+// sanitizeUsername mengambil bagian sebelum "@" dan hanya menyisakan huruf kecil, angka, ".", "_", "-"
+// Username tidak boleh mengandung "@" agar tidak tertukar dengan email saat login
+func sanitizeUsername(value string) string {
+	if at := strings.Index(value, "@"); at >= 0 {
+		value = value[:at]
+	}
+
+	var b strings.Builder
+	for _, r := range strings.ToLower(value) {
+		if (r >= 'a' && r <= 'z') || (r >= '0' && r <= '9') || r == '.' || r == '_' || r == '-' {
+			b.WriteRune(r)
+		}
+	}
+	return b.String()
+}
@@ -0,0 +1,284 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+
+	"github.com/LutfiyaAinurrahmanP/boilerplate_fiber_restful_api/internal/models"
+	"github.com/LutfiyaAinurrahmanP/boilerplate_fiber_restful_api/internal/repositories"
+	"github.com/LutfiyaAinurrahmanP/boilerplate_fiber_restful_api/internal/validators"
+	"gorm.io/gorm"
+)
+
+// organizationSlugPattern: huruf kecil, angka dan "-", dipakai client di field "organization" saat login
+var organizationSlugPattern = regexp.MustCompile(`^[a-z0-9][a-z0-9-]{1,49}$`)
+
+// OrganizationService mengelola organisasi (tenant) dan keanggotaan user
+// Anggota organisasi default bisa mengelola semua organisasi, anggota organisasi lain hanya organisasinya sendiri
+type OrganizationService interface {
+	ListOrganizations(actorOrganizationID uint) ([]models.Organization, error)
+	GetOrganization(actorOrganizationID, id uint) (*models.Organization, error)
+	CreateOrganization(actorOrganizationID uint, req *validators.CreateOrganizationRequest) (*models.Organization, error)
+	UpdateOrganization(actorOrganizationID, id uint, req *validators.UpdateOrganizationRequest) (*models.Organization, error)
+
+	ListMembers(actorOrganizationID, id uint) ([]models.OrganizationMember, error)
+	// actorRole adalah role pemanggil, role di atas level-nya tidak bisa diberikan / diubah
+	AddMember(actorOrganizationID uint, actorRole string, id uint, req *validators.AddOrganizationMemberRequest) (*models.OrganizationMember, error)
+	UpdateMember(actorOrganizationID uint, actorRole string, id, userID uint, req *validators.UpdateOrganizationMemberRequest) (*models.OrganizationMember, error)
+	RemoveMember(actorOrganizationID, id, userID uint) error
+
+	// Organisasi milik user yang sedang login
+	ListUserOrganizations(userID uint) ([]models.OrganizationMember, error)
+}
+
+type organizationService struct {
+	organizationRepo repositories.OrganizationRepository
+	userRepo         repositories.UserRepository
+	roles            RoleHierarchy
+}
+
+func NewOrganizationService(organizationRepo repositories.OrganizationRepository, userRepo repositories.UserRepository, roles RoleHierarchy) OrganizationService {
+	return &organizationService{
+		organizationRepo: organizationRepo,
+		userRepo:         userRepo,
+		roles:            roles,
+	}
+}
+
+func (s *organizationService) ListOrganizations(actorOrganizationID uint) ([]models.Organization, error) {
+	if actorOrganizationID != models.DefaultOrganizationID {
+		organization, err := s.GetOrganization(actorOrganizationID, actorOrganizationID)
+		if err != nil {
+			return nil, err
+		}
+		return []models.Organization{*organization}, nil
+	}
+
+	organizations, err := s.organizationRepo.FindAll()
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch organizations: %w", err)
+	}
+	return organizations, nil
+}
+
+// GetOrganization mengembalikan "organization not found" untuk organisasi di luar jangkauan pemanggil
+func (s *organizationService) GetOrganization(actorOrganizationID, id uint) (*models.Organization, error) {
+	if actorOrganizationID != models.DefaultOrganizationID && actorOrganizationID != id {
+		return nil, errors.New("organization not found")
+	}
+
+	organization, err := s.organizationRepo.FindByID(id)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, errors.New("organization not found")
+		}
+		return nil, fmt.Errorf("failed to find organization: %w", err)
+	}
+	return organization, nil
+}
+
+func (s *organizationService) CreateOrganization(actorOrganizationID uint, req *validators.CreateOrganizationRequest) (*models.Organization, error) {
+	if actorOrganizationID != models.DefaultOrganizationID {
+		return nil, errors.New("only the default organization can manage other organizations")
+	}
+	if !organizationSlugPattern.MatchString(req.Slug) {
+		return nil, errors.New("invalid organization slug, use 2-50 lowercase letters, digits or '-'")
+	}
+
+	if _, err := s.organizationRepo.FindBySlug(req.Slug); err == nil {
+		return nil, errors.New("organization slug already exists")
+	} else if !errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, fmt.Errorf("failed to check organization slug: %w", err)
+	}
+
+	organization := &models.Organization{
+		Name: req.Name,
+		Slug: req.Slug,
+	}
+	if err := s.organizationRepo.Create(organization); err != nil {
+		return nil, fmt.Errorf("failed to create organization: %w", err)
+	}
+	return organization, nil
+}
+
+// UpdateOrganization hanya mengubah nama, slug tetap agar client yang login dengan slug tidak rusak
+func (s *organizationService) UpdateOrganization(actorOrganizationID, id uint, req *validators.UpdateOrganizationRequest) (*models.Organization, error) {
+	organization, err := s.GetOrganization(actorOrganizationID, id)
+	if err != nil {
+		return nil, err
+	}
+
+	organization.Name = req.Name
+	if err := s.organizationRepo.Update(organization); err != nil {
+		return nil, fmt.Errorf("failed to update organization: %w", err)
+	}
+	return organization, nil
+}
+
+func (s *organizationService) ListMembers(actorOrganizationID, id uint) ([]models.OrganizationMember, error) {
+	if _, err := s.GetOrganization(actorOrganizationID, id); err != nil {
+		return nil, err
+	}
+
+	members, err := s.organizationRepo.FindMembers(id)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch organization members: %w", err)
+	}
+	return members, nil
+}
+
+// AddMember memberi user dari organisasi lain akses ke organisasi ini
+// Hanya organisasi default yang boleh, agar admin tenant tidak bisa menarik user tenant lain
+func (s *organizationService) AddMember(actorOrganizationID uint, actorRole string, id uint, req *validators.AddOrganizationMemberRequest) (*models.OrganizationMember, error) {
+	if actorOrganizationID != models.DefaultOrganizationID {
+		return nil, errors.New("only the default organization can manage other organizations")
+	}
+	if _, err := s.GetOrganization(actorOrganizationID, id); err != nil {
+		return nil, err
+	}
+	if !models.ValidateRole(req.Role) {
+		return nil, errors.New("invalid role")
+	}
+	if err := ensureCanAssignRole(s.roles, actorRole, req.Role); err != nil {
+		return nil, err
+	}
+
+	if _, err := s.userRepo.FindById(req.UserID); err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, errors.New("user not found")
+		}
+		return nil, fmt.Errorf("failed to find user: %w", err)
+	}
+
+	if _, err := s.organizationRepo.FindMember(id, req.UserID); err == nil {
+		return nil, errors.New("user is already a member of this organization")
+	} else if !errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, fmt.Errorf("failed to check membership: %w", err)
+	}
+
+	member := &models.OrganizationMember{
+		OrganizationID: id,
+		UserID:         req.UserID,
+		Role:           req.Role,
+	}
+	if err := s.organizationRepo.AddMember(member); err != nil {
+		return nil, fmt.Errorf("failed to add organization member: %w", err)
+	}
+	return member, nil
+}
+
+// UpdateMember mengubah role user di organisasi, sama dengan PUT /admin/user/update/:id dari dalam organisasi tersebut
+func (s *organizationService) UpdateMember(actorOrganizationID uint, actorRole string, id, userID uint, req *validators.UpdateOrganizationMemberRequest) (*models.OrganizationMember, error) {
+	if _, err := s.GetOrganization(actorOrganizationID, id); err != nil {
+		return nil, err
+	}
+	if !models.ValidateRole(req.Role) {
+		return nil, errors.New("invalid role")
+	}
+
+	members := s.userRepo.ForOrganization(id)
+	user, err := members.FindById(userID)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, errors.New("member not found")
+		}
+		return nil, fmt.Errorf("failed to find member: %w", err)
+	}
+
+	if user.Role != req.Role {
+		// Role anggota saat ini maupun role baru tidak boleh di atas role pemanggil
+		if err := ensureCanAssignRole(s.roles, actorRole, user.Role); err != nil {
+			return nil, err
+		}
+		if err := ensureCanAssignRole(s.roles, actorRole, req.Role); err != nil {
+			return nil, err
+		}
+
+		user.Role = req.Role
+		if err := members.Update(user); err != nil {
+			if errors.Is(err, repositories.ErrLastAdmin) {
+				return nil, errors.New("cannot remove the last active admin")
+			}
+			return nil, fmt.Errorf("failed to update organization member: %w", err)
+		}
+	}
+
+	member, err := s.organizationRepo.FindMember(id, userID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to find member: %w", err)
+	}
+	return member, nil
+}
+
+// RemoveMember mencabut akses user ke organisasi, keanggotaan organisasi asal tidak bisa dicabut (hapus user-nya)
+func (s *organizationService) RemoveMember(actorOrganizationID, id, userID uint) error {
+	if _, err := s.GetOrganization(actorOrganizationID, id); err != nil {
+		return err
+	}
+
+	if _, err := s.organizationRepo.FindMember(id, userID); err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return errors.New("member not found")
+		}
+		return fmt.Errorf("failed to find member: %w", err)
+	}
+
+	user, err := s.userRepo.FindById(userID)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return errors.New("user not found")
+		}
+		return fmt.Errorf("failed to find user: %w", err)
+	}
+	if user.OrganizationID == id {
+		return errors.New("cannot remove a user from their home organization")
+	}
+
+	if err := s.organizationRepo.RemoveMember(id, userID); err != nil {
+		if errors.Is(err, repositories.ErrLastAdmin) {
+			return errors.New("cannot remove the last active admin")
+		}
+		return fmt.Errorf("failed to remove organization member: %w", err)
+	}
+	return nil
+}
+
+func (s *organizationService) ListUserOrganizations(userID uint) ([]models.OrganizationMember, error) {
+	memberships, err := s.organizationRepo.FindMembershipsByUser(userID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch organizations: %w", err)
+	}
+	return memberships, nil
+}
+
+// ResolveOrganization mengubah slug organisasi dari request publik (login, reset password, verifikasi) menjadi ID,
+// slug kosong berarti organisasi default
+func ResolveOrganization(organizationRepo repositories.OrganizationRepository, slug string) (uint, error) {
+	if slug == "" {
+		return models.DefaultOrganizationID, nil
+	}
+
+	organization, err := organizationRepo.FindBySlug(slug)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return 0, errors.New("organization not found")
+		}
+		return 0, fmt.Errorf("failed to find organization: %w", err)
+	}
+	return organization.ID, nil
+}
+
+// organizationUsers mengembalikan UserRepository yang di-scope ke organisasi dengan slug tersebut (kosong = default)
+// Error gorm.ErrRecordNotFound dikembalikan apa adanya agar pemanggil bisa menyamakannya dengan user tidak ditemukan
+func organizationUsers(organizationRepo repositories.OrganizationRepository, userRepo repositories.UserRepository, slug string) (repositories.UserRepository, error) {
+	if slug == "" {
+		return userRepo.ForOrganization(models.DefaultOrganizationID), nil
+	}
+
+	organization, err := organizationRepo.FindBySlug(slug)
+	if err != nil {
+		return nil, err
+	}
+	return userRepo.ForOrganization(organization.ID), nil
+}
@@ -0,0 +1,161 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/LutfiyaAinurrahmanP/boilerplate_fiber_restful_api/internal/config"
+	"github.com/LutfiyaAinurrahmanP/boilerplate_fiber_restful_api/internal/mailer"
+	"github.com/LutfiyaAinurrahmanP/boilerplate_fiber_restful_api/internal/models"
+	"github.com/LutfiyaAinurrahmanP/boilerplate_fiber_restful_api/internal/repositories"
+	"github.com/LutfiyaAinurrahmanP/boilerplate_fiber_restful_api/internal/utils"
+	"github.com/LutfiyaAinurrahmanP/boilerplate_fiber_restful_api/internal/validators"
+	"gorm.io/gorm"
+)
+
+type PasswordResetService interface {
+	ForgotPassword(req *validators.ForgotPasswordRequest) error
+	ResetPassword(req *validators.ResetPasswordRequest) error
+}
+
+type passwordResetService struct {
+	userRepo       repositories.UserRepository
+	resetRepo      repositories.PasswordResetRepository
+	sessionRevoker SessionRevoker
+	mailer         mailer.Mailer
+	cfg            *config.Config
+
+	organizationRepo repositories.OrganizationRepository
+}
+
+func NewPasswordResetService(userRepo repositories.UserRepository, resetRepo repositories.PasswordResetRepository, sessionRevoker SessionRevoker, mailer mailer.Mailer, cfg *config.Config, organizationRepo repositories.OrganizationRepository) PasswordResetService {
+	return &passwordResetService{
+		userRepo:       userRepo,
+		resetRepo:      resetRepo,
+		sessionRevoker: sessionRevoker,
+		mailer:         mailer,
+		cfg:            cfg,
+
+		organizationRepo: organizationRepo,
+	}
+}
+
+// ForgotPassword mengirim link reset password jika email terdaftar
+// Selalu return nil untuk email (atau organisasi) yang tidak terdaftar agar keberadaan akun tidak bocor
+func (s *passwordResetService) ForgotPassword(req *validators.ForgotPasswordRequest) error {
+	users, err := organizationUsers(s.organizationRepo, s.userRepo, req.Organization)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil
+		}
+		return fmt.Errorf("failed to find organization: %w", err)
+	}
+
+	user, err := users.FindByEmail(req.Email)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil
+		}
+		return fmt.Errorf("failed to find user: %w", err)
+	}
+
+	// Hanya token terbaru yang berlaku
+	if err := s.resetRepo.InvalidateForUser(user.ID); err != nil {
+		return fmt.Errorf("failed to invalidate reset tokens: %w", err)
+	}
+
+	token, err := utils.GenerateRandomToken(32)
+	if err != nil {
+		return fmt.Errorf("failed to generate reset token: %w", err)
+	}
+
+	resetToken := &models.PasswordResetToken{
+		UserID:    user.ID,
+		TokenHash: utils.HashToken(token),
+		ExpiresAt: time.Now().Add(s.cfg.PasswordResetTTL),
+	}
+	if err := s.resetRepo.Create(resetToken); err != nil {
+		return fmt.Errorf("failed to store reset token: %w", err)
+	}
+
+	// Email dikirim di background agar waktu response sama untuk email terdaftar maupun tidak
+	go s.sendResetEmail(user, token)
+
+	return nil
+}
+
+// ResetPassword mengganti password dengan token sekali pakai lalu me-logout semua session
+func (s *passwordResetService) ResetPassword(req *validators.ResetPasswordRequest) error {
+	resetToken, err := s.resetRepo.FindByHash(utils.HashToken(req.Token))
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return errors.New("invalid or expired reset token")
+		}
+		return fmt.Errorf("failed to find reset token: %w", err)
+	}
+
+	if !resetToken.IsUsable() {
+		return errors.New("invalid or expired reset token")
+	}
+
+	user, err := s.userRepo.FindById(resetToken.UserID)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return errors.New("invalid or expired reset token")
+		}
+		return fmt.Errorf("failed to find user: %w", err)
+	}
+
+	// Password policy sudah dicek oleh validator (tag "password") di ResetPasswordRequest
+	hashedPassword, err := utils.HashPassword(req.NewPassword)
+	if err != nil {
+		return fmt.Errorf("failed to hash password: %w", err)
+	}
+
+	// Sama seperti ChangePassword: session di-revoke dulu, sehingga kegagalan di langkah berikutnya
+	// tidak meninggalkan password baru dengan session lama yang masih aktif
+	if err := s.sessionRevoker.RevokeOtherSessions(user.ID, ""); err != nil {
+		return fmt.Errorf("failed to revoke sessions: %w", err)
+	}
+
+	// Token dipakai dan password (beserta token_version) diubah dalam satu transaksi,
+	// link reset tidak hangus jika penyimpanan password gagal
+	used, err := s.resetRepo.ResetPassword(resetToken.ID, user.ID, hashedPassword)
+	if err != nil {
+		return fmt.Errorf("failed to reset password: %w", err)
+	}
+	if !used {
+		return errors.New("invalid or expired reset token")
+	}
+
+	return nil
+}
+
+func (s *passwordResetService) sendResetEmail(user *models.User, token string) {
+	link := withTokenQuery(s.cfg.PasswordResetURL, token)
+
+	msg := mailer.Message{
+		To:      user.Email,
+		Subject: "Reset your password",
+		Body: fmt.Sprintf(
+			"Hi %s,\n\nWe received a request to reset your password. Open the link below to choose a new one:\n\n%s\n\nThis link expires in %s and can only be used once. If you did not request this, you can ignore this email.\n",
+			user.Username, link, s.cfg.PasswordResetTTL,
+		),
+	}
+
+	if err := s.mailer.Send(msg); err != nil {
+		log.Printf("❌ Failed to send password reset email to user %d: %v", user.ID, err)
+	}
+}
+
+// withTokenQuery menambahkan token sebagai query parameter ke URL halaman frontend
+func withTokenQuery(link, token string) string {
+	if strings.Contains(link, "?") {
+		return link + "&token=" + url.QueryEscape(token)
+	}
+	return link + "?token=" + url.QueryEscape(token)
+}
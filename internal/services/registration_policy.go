@@ -0,0 +1,61 @@
+package services
+
+import (
+	"errors"
+	"strings"
+
+	"github.com/LutfiyaAinurrahmanP/boilerplate_fiber_restful_api/internal/config"
+)
+
+// RegistrationPolicy menentukan siapa yang boleh register lewat endpoint publik
+// Register publik selalu membuat role user, akun admin hanya dibuat lewat /admin/user/create atau CLI bootstrap
+type RegistrationPolicy interface {
+	Mode() string
+	AllowPublicSignup(email string) error
+}
+
+type registrationPolicy struct {
+	mode           string
+	allowedDomains map[string]struct{}
+}
+
+func NewRegistrationPolicy(cfg *config.Config) RegistrationPolicy {
+	allowed := make(map[string]struct{}, len(cfg.RegistrationAllowedDomains))
+	for _, domain := range cfg.RegistrationAllowedDomains {
+		allowed[domain] = struct{}{}
+	}
+
+	return &registrationPolicy{
+		mode:           cfg.RegistrationMode,
+		allowedDomains: allowed,
+	}
+}
+
+func (p *registrationPolicy) Mode() string {
+	return p.mode
+}
+
+func (p *registrationPolicy) AllowPublicSignup(email string) error {
+	switch p.mode {
+	case config.RegistrationOpen:
+		return nil
+	case config.RegistrationInvite:
+		return errors.New("registration is by invitation only")
+	case config.RegistrationDomain:
+		if _, ok := p.allowedDomains[emailDomain(email)]; !ok {
+			return errors.New("email domain is not allowed to register")
+		}
+		return nil
+	default:
+		return errors.New("registration is disabled")
+	}
+}
+
+// emailDomain mengambil domain (huruf kecil) setelah "@" terakhir
+func emailDomain(email string) string {
+	at := strings.LastIndex(email, "@")
+	if at < 0 {
+		return ""
+	}
+	return strings.ToLower(email[at+1:])
+}
@@ -0,0 +1,349 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"regexp"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/LutfiyaAinurrahmanP/boilerplate_fiber_restful_api/internal/models"
+	"github.com/LutfiyaAinurrahmanP/boilerplate_fiber_restful_api/internal/repositories"
+	"github.com/LutfiyaAinurrahmanP/boilerplate_fiber_restful_api/internal/validators"
+	"gorm.io/gorm"
+)
+
+// roleNamePattern mengikuti kolom users.role (varchar 20)
+var roleNamePattern = regexp.MustCompile(`^[a-z][a-z0-9_-]{1,19}$`)
+
+// RoleService mengelola role & permission sekaligus menjadi role store untuk middleware dan validasi
+// Mapping role -> permission di-cache in-memory dan dimuat ulang setiap ROLE_CACHE_TTL atau setelah perubahan lewat service ini
+type RoleService interface {
+	ListRoles() ([]models.Role, error)
+	GetRole(id uint) (*models.Role, error)
+	CreateRole(actorRole string, req *validators.CreateRoleRequest) (*models.Role, error)
+	UpdateRole(actorRole string, id uint, req *validators.UpdateRoleRequest) (*models.Role, error)
+	DeleteRole(id uint) error
+	ListPermissions() ([]models.Permission, error)
+
+	// Role store
+	RoleExists(name string) bool
+	RoleNames() []string
+	RolePermissions(role string) ([]string, error)
+	HasPermission(role, permission string) bool
+	RoleLevel(role string) (int, bool)
+	RoleAtLeast(role, minimum string) bool
+}
+
+type roleService struct {
+	roleRepo repositories.RoleRepository
+	ttl      time.Duration
+
+	mu       sync.RWMutex
+	roles    map[string]cachedRole
+	loadedAt time.Time
+}
+
+type cachedRole struct {
+	level       int
+	permissions map[string]bool
+}
+
+func NewRoleService(roleRepo repositories.RoleRepository, cacheTTL time.Duration) RoleService {
+	return &roleService{
+		roleRepo: roleRepo,
+		ttl:      cacheTTL,
+	}
+}
+
+func (s *roleService) ListRoles() ([]models.Role, error) {
+	roles, err := s.roleRepo.FindAll()
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch roles: %w", err)
+	}
+	return roles, nil
+}
+
+func (s *roleService) GetRole(id uint) (*models.Role, error) {
+	role, err := s.roleRepo.FindByID(id)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, errors.New("role not found")
+		}
+		return nil, fmt.Errorf("failed to find role: %w", err)
+	}
+	return role, nil
+}
+
+func (s *roleService) CreateRole(actorRole string, req *validators.CreateRoleRequest) (*models.Role, error) {
+	if !roleNamePattern.MatchString(req.Name) {
+		return nil, errors.New("invalid role name, use 2-20 lowercase letters, digits, '_' or '-'")
+	}
+
+	if _, err := s.roleRepo.FindByName(req.Name); err == nil {
+		return nil, errors.New("role already exists")
+	} else if !errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, fmt.Errorf("failed to check role: %w", err)
+	}
+
+	level := models.RoleLevelUser
+	if req.Level != nil {
+		level = *req.Level
+	}
+	if err := s.checkLevel(actorRole, level); err != nil {
+		return nil, err
+	}
+
+	permissions, err := s.resolvePermissions(actorRole, req.Permissions)
+	if err != nil {
+		return nil, err
+	}
+
+	role := &models.Role{
+		Name:        req.Name,
+		Description: req.Description,
+		Level:       level,
+		Permissions: permissions,
+	}
+	if err := s.roleRepo.Create(role); err != nil {
+		return nil, fmt.Errorf("failed to create role: %w", err)
+	}
+
+	s.invalidate()
+	return role, nil
+}
+
+// UpdateRole mengubah deskripsi, level dan / atau permission role, nama role tidak bisa diubah (disimpan di users.role)
+// Level role sistem tetap agar hierarki user < admin tidak bisa dibalik
+func (s *roleService) UpdateRole(actorRole string, id uint, req *validators.UpdateRoleRequest) (*models.Role, error) {
+	role, err := s.GetRole(id)
+	if err != nil {
+		return nil, err
+	}
+
+	if req.Description != nil {
+		role.Description = *req.Description
+	}
+	if req.Level != nil && *req.Level != role.Level {
+		if role.IsSystem {
+			return nil, errors.New("level of a system role cannot be changed")
+		}
+		if err := s.checkLevel(actorRole, role.Level); err != nil {
+			return nil, err
+		}
+		if err := s.checkLevel(actorRole, *req.Level); err != nil {
+			return nil, err
+		}
+		role.Level = *req.Level
+	}
+	if req.Permissions != nil {
+		if role.Name == models.RoleAdmin {
+			return nil, errors.New("permissions of the admin role cannot be changed")
+		}
+		if role.Permissions, err = s.resolvePermissions(actorRole, req.Permissions); err != nil {
+			return nil, err
+		}
+	}
+
+	if err := s.roleRepo.Update(role); err != nil {
+		return nil, fmt.Errorf("failed to update role: %w", err)
+	}
+
+	s.invalidate()
+	return role, nil
+}
+
+func (s *roleService) DeleteRole(id uint) error {
+	role, err := s.GetRole(id)
+	if err != nil {
+		return err
+	}
+	if role.IsSystem {
+		return errors.New("system role cannot be deleted")
+	}
+
+	assigned, err := s.roleRepo.CountUsers(role.Name)
+	if err != nil {
+		return fmt.Errorf("failed to count users: %w", err)
+	}
+	if assigned > 0 {
+		return errors.New("role is still assigned to users")
+	}
+
+	if err := s.roleRepo.Delete(role); err != nil {
+		return fmt.Errorf("failed to delete role: %w", err)
+	}
+
+	s.invalidate()
+	return nil
+}
+
+func (s *roleService) ListPermissions() ([]models.Permission, error) {
+	permissions, err := s.roleRepo.FindAllPermissions()
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch permissions: %w", err)
+	}
+	return permissions, nil
+}
+
+// resolvePermissions memastikan semua permission dikenal dan dimiliki role pemanggil,
+// sehingga pemegang roles.manage tidak bisa membuat role yang lebih kuat dari dirinya
+func (s *roleService) resolvePermissions(actorRole string, names []string) ([]models.Permission, error) {
+	names = uniqueStrings(names)
+	permissions, err := s.roleRepo.FindPermissionsByNames(names)
+	if err != nil {
+		return nil, fmt.Errorf("failed to find permissions: %w", err)
+	}
+
+	known := make(map[string]bool, len(permissions))
+	for _, permission := range permissions {
+		known[permission.Name] = true
+	}
+	for _, name := range names {
+		if !known[name] {
+			return nil, fmt.Errorf("unknown permission: %s", name)
+		}
+		if !s.HasPermission(actorRole, name) {
+			return nil, fmt.Errorf("cannot grant a permission you do not have: %s", name)
+		}
+	}
+	return permissions, nil
+}
+
+// RoleHierarchy membandingkan level role (RoleService), dipakai service yang memberikan role ke user
+type RoleHierarchy interface {
+	RoleAtLeast(role, minimum string) bool
+}
+
+// ensureCanAssignRole mencegah pemanggil memberikan role di atas level role-nya sendiri ke user mana pun (termasuk dirinya),
+// sama seperti checkLevel saat role didefinisikan
+func ensureCanAssignRole(roles RoleHierarchy, actorRole, role string) error {
+	if !roles.RoleAtLeast(actorRole, role) {
+		return errors.New("cannot assign a role above your own role")
+	}
+	return nil
+}
+
+// checkLevel mencegah pemegang roles.manage membuat atau mengubah role di atas level role-nya sendiri
+func (s *roleService) checkLevel(actorRole string, level int) error {
+	actorLevel, _ := s.RoleLevel(actorRole)
+	if level > actorLevel {
+		return errors.New("cannot assign a level above your own role")
+	}
+	return nil
+}
+
+// ============================================
+// ROLE STORE
+// ============================================
+
+func (s *roleService) RoleExists(name string) bool {
+	roles, err := s.snapshot()
+	if err != nil {
+		return false
+	}
+	_, ok := roles[name]
+	return ok
+}
+
+func (s *roleService) RoleNames() []string {
+	roles, err := s.snapshot()
+	if err != nil {
+		return nil
+	}
+	names := make([]string, 0, len(roles))
+	for name := range roles {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// RolePermissions mengembalikan permission sebuah role, kosong jika role tidak dikenal
+func (s *roleService) RolePermissions(role string) ([]string, error) {
+	roles, err := s.snapshot()
+	if err != nil {
+		return nil, err
+	}
+	permissions := make([]string, 0, len(roles[role].permissions))
+	for permission := range roles[role].permissions {
+		permissions = append(permissions, permission)
+	}
+	sort.Strings(permissions)
+	return permissions, nil
+}
+
+func (s *roleService) HasPermission(role, permission string) bool {
+	roles, err := s.snapshot()
+	if err != nil {
+		return false
+	}
+	return roles[role].permissions[permission]
+}
+
+// RoleLevel mengembalikan level role, false jika role tidak dikenal
+func (s *roleService) RoleLevel(role string) (int, bool) {
+	roles, err := s.snapshot()
+	if err != nil {
+		return 0, false
+	}
+	cached, ok := roles[role]
+	return cached.level, ok
+}
+
+// RoleAtLeast true jika level role >= level role minimum, role yang tidak dikenal selalu false
+func (s *roleService) RoleAtLeast(role, minimum string) bool {
+	level, ok := s.RoleLevel(role)
+	if !ok {
+		return false
+	}
+	minimumLevel, ok := s.RoleLevel(minimum)
+	return ok && level >= minimumLevel
+}
+
+// snapshot mengembalikan mapping role -> level & permission dari cache, dimuat ulang jika sudah lebih tua dari TTL
+// Jika reload gagal, data lama tetap dipakai (jika ada) agar database yang sedang bermasalah tidak mengunci semua request
+func (s *roleService) snapshot() (map[string]cachedRole, error) {
+	s.mu.RLock()
+	roles, loadedAt := s.roles, s.loadedAt
+	s.mu.RUnlock()
+
+	if roles != nil && time.Since(loadedAt) < s.ttl {
+		return roles, nil
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.roles != nil && time.Since(s.loadedAt) < s.ttl {
+		return s.roles, nil
+	}
+
+	all, err := s.roleRepo.FindAll()
+	if err != nil {
+		if s.roles != nil {
+			log.Printf("⚠️  Failed to reload roles, using cached roles: %v", err)
+			return s.roles, nil
+		}
+		return nil, fmt.Errorf("failed to load roles: %w", err)
+	}
+
+	loaded := make(map[string]cachedRole, len(all))
+	for _, role := range all {
+		permissions := make(map[string]bool, len(role.Permissions))
+		for _, permission := range role.Permissions {
+			permissions[permission.Name] = true
+		}
+		loaded[role.Name] = cachedRole{level: role.Level, permissions: permissions}
+	}
+
+	s.roles, s.loadedAt = loaded, time.Now()
+	return loaded, nil
+}
+
+func (s *roleService) invalidate() {
+	s.mu.Lock()
+	s.loadedAt = time.Time{}
+	s.mu.Unlock()
+}
@@ -0,0 +1,15 @@
+package services
+
+// uniqueStrings membuang nilai duplikat dengan tetap mempertahankan urutan kemunculan pertama
+// Dipakai untuk scope, redirect URI, grant type, dan nama permission
+func uniqueStrings(values []string) []string {
+	seen := make(map[string]bool, len(values))
+	result := make([]string, 0, len(values))
+	for _, value := range values {
+		if !seen[value] {
+			seen[value] = true
+			result = append(result, value)
+		}
+	}
+	return result
+}
@@ -0,0 +1,297 @@
+package services
+
+import (
+	"crypto/rand"
+	"encoding/base32"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/LutfiyaAinurrahmanP/boilerplate_fiber_restful_api/internal/config"
+	"github.com/LutfiyaAinurrahmanP/boilerplate_fiber_restful_api/internal/models"
+	"github.com/LutfiyaAinurrahmanP/boilerplate_fiber_restful_api/internal/repositories"
+	"github.com/LutfiyaAinurrahmanP/boilerplate_fiber_restful_api/internal/totp"
+	"github.com/LutfiyaAinurrahmanP/boilerplate_fiber_restful_api/internal/utils"
+	"github.com/LutfiyaAinurrahmanP/boilerplate_fiber_restful_api/internal/validators"
+	"gorm.io/gorm"
+)
+
+// TwoFactorEnrollment dikembalikan saat enroll, secret ditampilkan sekali untuk di-scan
+type TwoFactorEnrollment struct {
+	Secret     string `json:"secret"`
+	OTPAuthURI string `json:"otpauth_uri"`
+}
+
+type TwoFactorService interface {
+	Enroll(userID uint, req *validators.EnrollTwoFactorRequest) (*TwoFactorEnrollment, error)
+	Confirm(userID uint, sessionID string, req *validators.TwoFactorCodeRequest) ([]string, error)
+	Disable(userID uint, req *validators.DisableTwoFactorRequest) error
+	RegenerateRecoveryCodes(userID uint, req *validators.TwoFactorCodeRequest) ([]string, error)
+	VerifyCode(user *models.User, code string) (bool, error)
+
+	// Policy (admin)
+	ListPolicies() ([]models.TwoFactorPolicy, error)
+	SetPolicy(adminID uint, req *validators.TwoFactorPolicyRequest) (*models.TwoFactorPolicy, error)
+}
+
+type twoFactorService struct {
+	userRepo         repositories.UserRepository
+	recoveryCodeRepo repositories.RecoveryCodeRepository
+	policyRepo       repositories.TwoFactorPolicyRepository
+	sessionRepo      repositories.SessionRepository
+	cfg              *config.Config
+}
+
+func NewTwoFactorService(userRepo repositories.UserRepository, recoveryCodeRepo repositories.RecoveryCodeRepository, policyRepo repositories.TwoFactorPolicyRepository, sessionRepo repositories.SessionRepository, cfg *config.Config) TwoFactorService {
+	return &twoFactorService{
+		userRepo:         userRepo,
+		recoveryCodeRepo: recoveryCodeRepo,
+		policyRepo:       policyRepo,
+		sessionRepo:      sessionRepo,
+		cfg:              cfg,
+	}
+}
+
+// Enroll membuat secret baru (belum aktif sampai dikonfirmasi dengan kode dari authenticator app)
+func (s *twoFactorService) Enroll(userID uint, req *validators.EnrollTwoFactorRequest) (*TwoFactorEnrollment, error) {
+	user, err := s.findUser(userID)
+	if err != nil {
+		return nil, err
+	}
+
+	if user.TwoFactorEnabledAt != nil {
+		return nil, errors.New("two-factor authentication already enabled")
+	}
+
+	if err := utils.CheckPassword(user.Password, req.Password); err != nil {
+		return nil, errors.New("password is incorrect")
+	}
+
+	secret, err := totp.GenerateSecret()
+	if err != nil {
+		return nil, fmt.Errorf("failed to generate totp secret: %w", err)
+	}
+
+	user.TwoFactorSecret = secret
+	if err := s.userRepo.Update(user); err != nil {
+		return nil, fmt.Errorf("failed to store totp secret: %w", err)
+	}
+
+	return &TwoFactorEnrollment{
+		Secret:     secret,
+		OTPAuthURI: totp.URI(s.cfg.AppName, user.Username, secret),
+	}, nil
+}
+
+// Confirm mengaktifkan 2FA dan mengembalikan recovery code (hanya ditampilkan sekali)
+// Session yang dipakai untuk konfirmasi langsung dianggap sudah lolos 2FA
+func (s *twoFactorService) Confirm(userID uint, sessionID string, req *validators.TwoFactorCodeRequest) ([]string, error) {
+	user, err := s.findUser(userID)
+	if err != nil {
+		return nil, err
+	}
+
+	if user.TwoFactorEnabledAt != nil {
+		return nil, errors.New("two-factor authentication already enabled")
+	}
+	if user.TwoFactorSecret == "" {
+		return nil, errors.New("two-factor authentication not enrolled")
+	}
+
+	ok, err := s.verifyTOTP(user, req.Code)
+	if err != nil {
+		return nil, err
+	}
+	if !ok {
+		return nil, errors.New("invalid two-factor code")
+	}
+
+	codes, err := s.replaceRecoveryCodes(user.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	user.TwoFactorEnabledAt = &now
+	if err := s.userRepo.Update(user); err != nil {
+		return nil, fmt.Errorf("failed to enable two-factor authentication: %w", err)
+	}
+
+	if err := s.sessionRepo.MarkMFAVerified(sessionID); err != nil {
+		return nil, fmt.Errorf("failed to update session: %w", err)
+	}
+
+	return codes, nil
+}
+
+// Disable mematikan 2FA, butuh password dan kode 2FA yang valid
+func (s *twoFactorService) Disable(userID uint, req *validators.DisableTwoFactorRequest) error {
+	user, err := s.findUser(userID)
+	if err != nil {
+		return err
+	}
+
+	if user.TwoFactorEnabledAt == nil {
+		return errors.New("two-factor authentication not enabled")
+	}
+
+	required, err := s.policyRepo.IsRequired(user.Role)
+	if err != nil {
+		return fmt.Errorf("failed to check two-factor policy: %w", err)
+	}
+	if required {
+		return errors.New("two-factor authentication is required for your role")
+	}
+
+	if err := utils.CheckPassword(user.Password, req.Password); err != nil {
+		return errors.New("password is incorrect")
+	}
+
+	ok, err := s.VerifyCode(user, req.Code)
+	if err != nil {
+		return err
+	}
+	if !ok {
+		return errors.New("invalid two-factor code")
+	}
+
+	user.TwoFactorSecret = ""
+	user.TwoFactorEnabledAt = nil
+	if err := s.userRepo.Update(user); err != nil {
+		return fmt.Errorf("failed to disable two-factor authentication: %w", err)
+	}
+
+	if err := s.recoveryCodeRepo.DeleteForUser(user.ID); err != nil {
+		return fmt.Errorf("failed to delete recovery codes: %w", err)
+	}
+
+	return nil
+}
+
+// RegenerateRecoveryCodes mengganti semua recovery code lama dengan set baru
+func (s *twoFactorService) RegenerateRecoveryCodes(userID uint, req *validators.TwoFactorCodeRequest) ([]string, error) {
+	user, err := s.findUser(userID)
+	if err != nil {
+		return nil, err
+	}
+
+	if user.TwoFactorEnabledAt == nil {
+		return nil, errors.New("two-factor authentication not enabled")
+	}
+
+	ok, err := s.verifyTOTP(user, req.Code)
+	if err != nil {
+		return nil, err
+	}
+	if !ok {
+		return nil, errors.New("invalid two-factor code")
+	}
+
+	return s.replaceRecoveryCodes(user.ID)
+}
+
+// VerifyCode menerima kode TOTP 6 digit atau recovery code
+func (s *twoFactorService) VerifyCode(user *models.User, code string) (bool, error) {
+	code = strings.TrimSpace(code)
+	if len(code) == totp.Digits {
+		return s.verifyTOTP(user, code)
+	}
+
+	consumed, err := s.recoveryCodeRepo.Consume(user.ID, utils.HashToken(normalizeRecoveryCode(code)))
+	if err != nil {
+		return false, fmt.Errorf("failed to use recovery code: %w", err)
+	}
+	return consumed, nil
+}
+
+func (s *twoFactorService) ListPolicies() ([]models.TwoFactorPolicy, error) {
+	policies, err := s.policyRepo.FindAll()
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch two-factor policies: %w", err)
+	}
+	return policies, nil
+}
+
+func (s *twoFactorService) SetPolicy(adminID uint, req *validators.TwoFactorPolicyRequest) (*models.TwoFactorPolicy, error) {
+	if !models.ValidateRole(req.Role) {
+		return nil, errors.New("invalid role")
+	}
+
+	policy := &models.TwoFactorPolicy{
+		Role:      req.Role,
+		Required:  *req.Required,
+		UpdatedBy: adminID,
+	}
+	if err := s.policyRepo.Save(policy); err != nil {
+		return nil, fmt.Errorf("failed to save two-factor policy: %w", err)
+	}
+	return policy, nil
+}
+
+// verifyTOTP menolak kode yang step-nya sudah pernah dipakai (replay)
+func (s *twoFactorService) verifyTOTP(user *models.User, code string) (bool, error) {
+	if user.TwoFactorSecret == "" {
+		return false, nil
+	}
+
+	step, ok := totp.Validate(user.TwoFactorSecret, code, time.Now())
+	if !ok {
+		return false, nil
+	}
+
+	advanced, err := s.userRepo.AdvanceTwoFactorStep(user.ID, step)
+	if err != nil {
+		return false, fmt.Errorf("failed to store totp step: %w", err)
+	}
+	if advanced {
+		user.TwoFactorLastStep = step
+	}
+	return advanced, nil
+}
+
+func (s *twoFactorService) replaceRecoveryCodes(userID uint) ([]string, error) {
+	codes := make([]string, 0, s.cfg.TwoFactorRecoveryCodes)
+	hashes := make([]string, 0, s.cfg.TwoFactorRecoveryCodes)
+	for i := 0; i < s.cfg.TwoFactorRecoveryCodes; i++ {
+		code, err := generateRecoveryCode()
+		if err != nil {
+			return nil, fmt.Errorf("failed to generate recovery code: %w", err)
+		}
+		codes = append(codes, code)
+		hashes = append(hashes, utils.HashToken(normalizeRecoveryCode(code)))
+	}
+
+	if err := s.recoveryCodeRepo.ReplaceForUser(userID, hashes); err != nil {
+		return nil, fmt.Errorf("failed to store recovery codes: %w", err)
+	}
+	return codes, nil
+}
+
+func (s *twoFactorService) findUser(userID uint) (*models.User, error) {
+	user, err := s.userRepo.FindById(userID)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, errors.New("user not found")
+		}
+		return nil, fmt.Errorf("failed to find user: %w", err)
+	}
+	return user, nil
+}
+
+// generateRecoveryCode membuat kode format "xxxxx-xxxxx" (base32 huruf kecil, 50 bit)
+func generateRecoveryCode() (string, error) {
+	b := make([]byte, 10)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	encoded := strings.ToLower(base32.StdEncoding.EncodeToString(b))[:10]
+	return encoded[:5] + "-" + encoded[5:], nil
+}
+
+// normalizeRecoveryCode mengabaikan huruf besar/kecil, spasi, dan tanda "-" saat dicocokkan
+func normalizeRecoveryCode(code string) string {
+	code = strings.ToLower(code)
+	code = strings.ReplaceAll(code, "-", "")
+	return strings.ReplaceAll(code, " ", "")
+}
@@ -13,36 +13,79 @@ import (
 
 type UserService interface {
 	// Admin
-	CreateUser(req *validators.CreateUserRequest) (*models.User, error)
-	UpdateUser(id uint, req *validators.UpdateUserRequest) (*models.User, error)
+	// actorRole adalah role pemanggil, role di atas level-nya tidak bisa diberikan / diubah
+	CreateUser(actorRole string, req *validators.CreateUserRequest) (*models.User, error)
+	// PrepareUser menjalankan validasi CreateUser dan mengembalikan user (password sudah di-hash) tanpa menyimpannya
+	PrepareUser(actorRole string, req *validators.CreateUserRequest) (*models.User, error)
+	UpdateUser(actorRole string, id uint, req *validators.UpdateUserRequest) (*models.User, error)
 	DeleteUser(id uint) error
 	HardDeleteUser(id uint) error
 	RestoreUser(id uint) error
 
 	GetUserByID(id uint) (*models.User, error)
+	GetUserByIDWithDeleted(id uint) (*models.User, error)
 	GetAllUsers(query *validators.ListUserQuery) ([]models.User, *utils.PaginationMeta, error)
 	GetAllDeletedUsers(query *validators.ListUserQuery) ([]models.User, *utils.PaginationMeta, error)
 
 	// User
 	GetProfile(userID uint) (*models.User, error)
 	UpdateProfile(userID uint, req *validators.UpdateProfileRequest) (*models.User, error)
+	ChangePassword(userID uint, sessionID string, req *validators.ChangePasswordRequest) (*TokenPair, error)
+
+	// ForOrganization mengembalikan UserService yang semua query user-nya di-scope ke organisasi tersebut
+	// (lihat UserRepository.ForOrganization), dipakai handler admin dengan organisasi dari token
+	ForOrganization(organizationID uint) UserService
 }
 
 type userService struct {
-	userRepo repositories.UserRepository
+	userRepo       repositories.UserRepository
+	sessionRevoker SessionRevoker
+	roles          RoleHierarchy
+
+	// organizationID 0 berarti tidak di-scope
+	organizationID uint
+}
+
+func NewUserService(userRepo repositories.UserRepository, sessionRevoker SessionRevoker, roles RoleHierarchy) UserService {
+	return &userService{
+		userRepo:       userRepo,
+		sessionRevoker: sessionRevoker,
+		roles:          roles,
+	}
 }
 
-func NewUserService(userRepo repositories.UserRepository) UserService {
+func (s *userService) ForOrganization(organizationID uint) UserService {
 	return &userService{
-		userRepo: userRepo,
+		userRepo:       s.userRepo.ForOrganization(organizationID),
+		sessionRevoker: s.sessionRevoker,
+		roles:          s.roles,
+		organizationID: organizationID,
+	}
+}
+
+func (s *userService) CreateUser(actorRole string, req *validators.CreateUserRequest) (*models.User, error) {
+	user, err := s.PrepareUser(actorRole, req)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := s.userRepo.Create(user); err != nil {
+		return nil, fmt.Errorf("failed to created user: %w", err)
 	}
+
+	return user, nil
 }
 
-func (s *userService) CreateUser(req *validators.CreateUserRequest) (*models.User, error) {
+func (s *userService) PrepareUser(actorRole string, req *validators.CreateUserRequest) (*models.User, error) {
+	req.Normalize()
+
 	// 1. Validasi role
 	if !models.ValidateRole(req.Role) {
 		return nil, errors.New("invalid role")
 	}
+	if err := ensureCanAssignRole(s.roles, actorRole, req.Role); err != nil {
+		return nil, err
+	}
 
 	// 2. Cek uniqueness
 	exists, err := s.userRepo.ExistsByUsername(req.Username)
@@ -75,22 +118,20 @@ func (s *userService) CreateUser(req *validators.CreateUserRequest) (*models.Use
 		return nil, fmt.Errorf("failed to hash password: %w", err)
 	}
 
-	user := &models.User{
-		Username: req.Username,
-		Email:    req.Email,
-		Phone:    req.Phone,
-		Password: hashedPassword,
-		Role:     req.Role,
-	}
-
-	if err := s.userRepo.Create(user); err != nil {
-		return nil, fmt.Errorf("failed to created user: %w", err)
-	}
-
-	return user, nil
+	// Pada service yang di-scope, organisasi tersebut menjadi organisasi asal user (sama dengan UserRepository.Create)
+	return &models.User{
+		Username:       req.Username,
+		Email:          req.Email,
+		Phone:          &req.Phone,
+		Password:       hashedPassword,
+		Role:           req.Role,
+		OrganizationID: s.organizationID,
+	}, nil
 }
 
-func (s *userService) UpdateUser(id uint, req *validators.UpdateUserRequest) (*models.User, error) {
+func (s *userService) UpdateUser(actorRole string, id uint, req *validators.UpdateUserRequest) (*models.User, error) {
+	req.Normalize()
+
 	user, err := s.userRepo.FindById(id)
 	if err != nil {
 		if errors.Is(err, gorm.ErrRecordNotFound) {
@@ -99,47 +140,42 @@ func (s *userService) UpdateUser(id uint, req *validators.UpdateUserRequest) (*m
 		return nil, fmt.Errorf("failed to find user: %w", err)
 	}
 
-	if req.Username != "" && req.Username != user.Username {
-		exists, err := s.userRepo.ExistsByUsername(req.Username)
-		if err != nil {
-			return nil, fmt.Errorf("failed to check username: %w", err)
-		}
-		if exists {
-			return nil, errors.New("username already exists")
-		}
-		user.Username = req.Username
-	}
-
-	if req.Email != "" && req.Email != user.Email {
-		exists, err := s.userRepo.ExistsByEmail(req.Email)
-		if err != nil {
-			return nil, fmt.Errorf("failed to check email: %w", err)
-		}
-		if exists {
-			return nil, errors.New("email already exists")
-		}
-		user.Email = req.Email
-	}
-
-	if req.Phone != "" && req.Phone != user.Phone {
-		exists, err := s.userRepo.ExistsByPhone(req.Phone)
-		if err != nil {
-			return nil, fmt.Errorf("failed to check phone: %w", err)
+	// Data akun hanya bisa diubah oleh organisasi asalnya, organisasi lain hanya bisa mengubah role
+	contactChanged := (req.Username != "" && req.Username != user.Username) ||
+		(req.Email != "" && req.Email != user.Email) ||
+		(req.Phone != "" && req.Phone != user.PhoneNumber())
+	if contactChanged {
+		if err := s.ensureHomeOrganization(user); err != nil {
+			return nil, err
 		}
-		if exists {
-			return nil, errors.New("phone already exists")
+		if err := s.applyContactChanges(user, req.Username, req.Email, req.Phone); err != nil {
+			return nil, err
 		}
-		user.Phone = req.Phone
 	}
 
+	roleChanged := false
 	if req.Role != "" {
 		if !models.ValidateRole(req.Role) {
 			return nil, errors.New("invalid role")
 		}
+		roleChanged = req.Role != user.Role
+		if roleChanged {
+			// Role user saat ini maupun role baru tidak boleh di atas role pemanggil
+			if err := ensureCanAssignRole(s.roles, actorRole, user.Role); err != nil {
+				return nil, err
+			}
+			if err := ensureCanAssignRole(s.roles, actorRole, req.Role); err != nil {
+				return nil, err
+			}
+		}
 		user.Role = req.Role
 	}
 
+	// Repository menolak (ErrLastAdmin) jika role admin aktif terakhir organisasi diturunkan
 	if err := s.userRepo.Update(user); err != nil {
+		if errors.Is(err, repositories.ErrLastAdmin) {
+			return nil, errors.New("cannot remove the last active admin")
+		}
 		return nil, fmt.Errorf("failed to updated user: %w", err)
 	}
 
@@ -147,29 +183,77 @@ func (s *userService) UpdateUser(id uint, req *validators.UpdateUserRequest) (*m
 }
 
 func (s *userService) DeleteUser(id uint) error {
-	_, err := s.userRepo.FindById(id)
+	user, err := s.userRepo.FindById(id)
 	if err != nil {
 		if errors.Is(err, gorm.ErrRecordNotFound) {
 			return errors.New("user not found")
 		}
 		return fmt.Errorf("failed to find user: %w", err)
 	}
+	if err := s.ensureHomeOrganization(user); err != nil {
+		return err
+	}
 
+	// Admin aktif terakhir di salah satu organisasinya tidak bisa dihapus,
+	// repository mencabut token (token_version) di transaksi yang sama dengan delete
 	if err := s.userRepo.Delete(id); err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return errors.New("user not found")
+		}
+		if errors.Is(err, repositories.ErrLastAdmin) {
+			return errors.New("cannot remove the last active admin")
+		}
 		return fmt.Errorf("failed to delete userL %w", err)
 	}
 	return nil
 }
 
 func (s *userService) HardDeleteUser(id uint) error {
+	user, err := s.userRepo.FindByIdWithDeleted(id)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return errors.New("user not found")
+		}
+		return fmt.Errorf("failed to find user: %w", err)
+	}
+	if err := s.ensureHomeOrganization(user); err != nil {
+		return err
+	}
+
 	if err := s.userRepo.HardDelete(id); err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return errors.New("user not found")
+		}
+		if errors.Is(err, repositories.ErrLastAdmin) {
+			return errors.New("cannot remove the last active admin")
+		}
 		return fmt.Errorf("failed to permanently delete user: %w", err)
 	}
 	return nil
 }
 
 func (s *userService) RestoreUser(id uint) error {
+	user, err := s.userRepo.FindByIdWithDeleted(id)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return errors.New("user not found")
+		}
+		return fmt.Errorf("failed to find user: %w", err)
+	}
+	if err := s.ensureHomeOrganization(user); err != nil {
+		return err
+	}
+
+	// User yang tidak terhapus tidak perlu di-restore
+	if !user.DeletedAt.Valid {
+		return nil
+	}
+
+	// Repository menaikkan token_version di UPDATE yang sama, token sebelum user dihapus tidak boleh hidup lagi
 	if err := s.userRepo.Restore(id); err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return errors.New("user not found")
+		}
 		return fmt.Errorf("failed to respore user: %w", err)
 	}
 	return nil
@@ -186,6 +270,18 @@ func (s *userService) GetUserByID(id uint) (*models.User, error) {
 	return user, nil
 }
 
+// GetUserByIDWithDeleted dipakai untuk aksi yang juga berlaku bagi user terhapus (misalnya hard delete)
+func (s *userService) GetUserByIDWithDeleted(id uint) (*models.User, error) {
+	user, err := s.userRepo.FindByIdWithDeleted(id)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, errors.New("user not found")
+		}
+		return nil, fmt.Errorf("failed to get user: %w", err)
+	}
+	return user, nil
+}
+
 func (s *userService) GetAllUsers(query *validators.ListUserQuery) ([]models.User, *utils.PaginationMeta, error) {
 	query.SetDefaults()
 
@@ -232,6 +328,30 @@ func (s *userService) GetProfile(userID uint) (*models.User, error) {
 }
 
 func (s *userService) UpdateProfile(userID uint, req *validators.UpdateProfileRequest) (*models.User, error) {
+	req.Normalize()
+
+	user, err := s.userRepo.FindById(userID)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, errors.New("user not found")
+		}
+		return nil, fmt.Errorf("failed to find user: %w", err)
+	}
+
+	if err := s.applyContactChanges(user, req.Username, req.Email, req.Phone); err != nil {
+		return nil, err
+	}
+
+	if err := s.userRepo.Update(user); err != nil {
+		return nil, fmt.Errorf("failed to update profile: %w", err)
+	}
+
+	return user, nil
+}
+
+// ChangePassword mengganti (atau membuat, untuk akun tanpa password) password user lalu me-revoke semua session lain
+// Session saat ini tetap login dengan token baru yang dikembalikan
+func (s *userService) ChangePassword(userID uint, sessionID string, req *validators.ChangePasswordRequest) (*TokenPair, error) {
 	user, err := s.userRepo.FindById(userID)
 	if err != nil {
 		if errors.Is(err, gorm.ErrRecordNotFound) {
@@ -240,42 +360,93 @@ func (s *userService) UpdateProfile(userID uint, req *validators.UpdateProfileRe
 		return nil, fmt.Errorf("failed to find user: %w", err)
 	}
 
-	if req.Username != "" && req.Username != user.Username {
-		exists, err := s.userRepo.ExistsByUsername(req.Username)
+	// Akun tanpa password (login via OIDC) membuat password pertamanya tanpa old_password
+	if user.HasPassword() {
+		if req.OldPassword == "" {
+			return nil, errors.New("old password is required")
+		}
+		if err := utils.CheckPassword(user.Password, req.OldPassword); err != nil {
+			return nil, errors.New("old password is incorrect")
+		}
+	}
+
+	if err := utils.ValidatePasswordPolicy(req.NewPassword); err != nil {
+		return nil, err
+	}
+
+	if req.NewPassword == req.OldPassword {
+		return nil, errors.New("new password must be different from old password")
+	}
+
+	hashedPassword, err := utils.HashPassword(req.NewPassword)
+	if err != nil {
+		return nil, fmt.Errorf("failed to hash password: %w", err)
+	}
+
+	// Session lain di-revoke sebelum password diganti, sehingga kegagalan di langkah berikutnya
+	// tidak meninggalkan password baru dengan session lama yang masih aktif
+	if err := s.sessionRevoker.RevokeOtherSessions(user.ID, sessionID); err != nil {
+		return nil, fmt.Errorf("failed to revoke other sessions: %w", err)
+	}
+
+	// Password dan token_version diubah bersamaan, semua token lama (termasuk milik session ini) tidak berlaku lagi
+	if err := s.userRepo.UpdatePassword(user.ID, hashedPassword); err != nil {
+		return nil, fmt.Errorf("failed to update password: %w", err)
+	}
+
+	tokens, err := s.sessionRevoker.ReissueTokens(user.ID, sessionID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to reissue tokens: %w", err)
+	}
+	return tokens, nil
+}
+
+// applyContactChanges mengubah username / email / phone yang dikirim (tidak kosong dan berbeda)
+// Uniqueness dicek di cakupan organisasi asal user, bukan organisasi pemanggil
+func (s *userService) applyContactChanges(user *models.User, username, email, phone string) error {
+	scoped := s.userRepo.ForOrganization(user.OrganizationID)
+
+	if username != "" && username != user.Username {
+		exists, err := scoped.ExistsByUsername(username)
 		if err != nil {
-			return nil, fmt.Errorf("failed to check username: %w", err)
+			return fmt.Errorf("failed to check username: %w", err)
 		}
 		if exists {
-			return nil, errors.New("username already exists")
+			return errors.New("username already exists")
 		}
-		user.Username = req.Username
+		user.Username = username
 	}
 
-	if req.Email != "" && req.Email != user.Email {
-		exists, err := s.userRepo.ExistsByEmail(req.Email)
+	if email != "" && email != user.Email {
+		exists, err := scoped.ExistsByEmail(email)
 		if err != nil {
-			return nil, fmt.Errorf("failed to check email: %w", err)
+			return fmt.Errorf("failed to check email: %w", err)
 		}
 		if exists {
-			return nil, errors.New("email already exists")
+			return errors.New("email already exists")
 		}
-		user.Email = req.Email
+		user.Email = email
+		user.EmailVerifiedAt = nil // Alamat baru harus diverifikasi ulang
 	}
 
-	if req.Phone != "" && req.Phone != user.Phone {
-		exists, err := s.userRepo.ExistsByPhone(req.Phone)
+	if phone != "" && phone != user.PhoneNumber() {
+		exists, err := scoped.ExistsByPhone(phone)
 		if err != nil {
-			return nil, fmt.Errorf("failed to check phone: %w", err)
+			return fmt.Errorf("failed to check phone: %w", err)
 		}
 		if exists {
-			return nil, errors.New("phone already exists")
+			return errors.New("phone already exists")
 		}
-		user.Phone = req.Phone
+		user.Phone = &phone
+		user.PhoneVerifiedAt = nil // Nomor baru harus diverifikasi ulang
 	}
+	return nil
+}
 
-	if err := s.userRepo.Update(user); err != nil {
-		return nil, fmt.Errorf("failed to update profile: %w", err)
+// ensureHomeOrganization menolak perubahan akun dari organisasi yang bukan organisasi asal user
+func (s *userService) ensureHomeOrganization(user *models.User) error {
+	if s.organizationID != 0 && user.OrganizationID != s.organizationID {
+		return errors.New("user belongs to another organization")
 	}
-
-	return user, nil
+	return nil
 }
@@ -0,0 +1,239 @@
+package services
+
+import (
+	"crypto/subtle"
+	"errors"
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/LutfiyaAinurrahmanP/boilerplate_fiber_restful_api/internal/config"
+	"github.com/LutfiyaAinurrahmanP/boilerplate_fiber_restful_api/internal/mailer"
+	"github.com/LutfiyaAinurrahmanP/boilerplate_fiber_restful_api/internal/models"
+	"github.com/LutfiyaAinurrahmanP/boilerplate_fiber_restful_api/internal/repositories"
+	"github.com/LutfiyaAinurrahmanP/boilerplate_fiber_restful_api/internal/sms"
+	"github.com/LutfiyaAinurrahmanP/boilerplate_fiber_restful_api/internal/utils"
+	"github.com/LutfiyaAinurrahmanP/boilerplate_fiber_restful_api/internal/validators"
+	"gorm.io/gorm"
+)
+
+// Panjang kode OTP verifikasi
+const verificationCodeDigits = 6
+
+// Error rate limit issueCode, SendCode menelannya agar alamat terdaftar dan tidak terdaftar mendapat response yang sama
+var (
+	errVerificationResendDelay = errors.New("please wait before requesting another verification code")
+	errVerificationHourlyLimit = errors.New("too many verification codes requested, try again later")
+)
+
+type VerificationService interface {
+	SendCode(req *validators.SendVerificationRequest) error
+	ConfirmCode(req *validators.ConfirmVerificationRequest) error
+	SendInitialCodes(user *models.User)
+}
+
+type verificationService struct {
+	userRepo repositories.UserRepository
+	codeRepo repositories.VerificationCodeRepository
+	mailer   mailer.Mailer
+	sms      sms.Sender
+	cfg      *config.Config
+
+	organizationRepo repositories.OrganizationRepository
+}
+
+func NewVerificationService(userRepo repositories.UserRepository, codeRepo repositories.VerificationCodeRepository, mailer mailer.Mailer, sms sms.Sender, cfg *config.Config, organizationRepo repositories.OrganizationRepository) VerificationService {
+	return &verificationService{
+		userRepo: userRepo,
+		codeRepo: codeRepo,
+		mailer:   mailer,
+		sms:      sms,
+		cfg:      cfg,
+
+		organizationRepo: organizationRepo,
+	}
+}
+
+// SendCode mengirim (ulang) kode verifikasi ke email / nomor telepon
+// Return nil untuk alamat yang tidak terdaftar atau sudah terverifikasi agar keberadaan akun tidak bocor
+func (s *verificationService) SendCode(req *validators.SendVerificationRequest) error {
+	user, err := s.findUser(req.Organization, req.Channel, req.Target)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil
+		}
+		return fmt.Errorf("failed to find user: %w", err)
+	}
+
+	if isVerified(user, req.Channel) {
+		return nil
+	}
+
+	// Rate limit hanya di-log, response 429 akan membocorkan bahwa alamat terdaftar dan belum terverifikasi
+	if err := s.issueCode(user, req.Channel); err != nil {
+		if errors.Is(err, errVerificationResendDelay) || errors.Is(err, errVerificationHourlyLimit) {
+			log.Printf("⚠️  Verification code for user %d (%s) not sent: %v", user.ID, req.Channel, err)
+			return nil
+		}
+		return err
+	}
+	return nil
+}
+
+// ConfirmCode memverifikasi kode, setiap kode hanya bisa dicoba VerificationMaxAttempts kali
+func (s *verificationService) ConfirmCode(req *validators.ConfirmVerificationRequest) error {
+	user, err := s.findUser(req.Organization, req.Channel, req.Target)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return errors.New("invalid or expired verification code")
+		}
+		return fmt.Errorf("failed to find user: %w", err)
+	}
+
+	if isVerified(user, req.Channel) {
+		return nil
+	}
+
+	code, err := s.codeRepo.FindLatestActive(user.ID, req.Channel)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return errors.New("invalid or expired verification code")
+		}
+		return fmt.Errorf("failed to find verification code: %w", err)
+	}
+
+	// Kode tidak berlaku jika alamat user sudah berubah sejak kode dikirim
+	if !code.IsUsable(s.cfg.VerificationMaxAttempts) || code.Target != currentTarget(user, req.Channel) {
+		return errors.New("invalid or expired verification code")
+	}
+
+	if subtle.ConstantTimeCompare([]byte(utils.HashToken(req.Code)), []byte(code.CodeHash)) != 1 {
+		if err := s.codeRepo.IncrementAttempts(code.ID); err != nil {
+			return fmt.Errorf("failed to record verification attempt: %w", err)
+		}
+		return errors.New("invalid or expired verification code")
+	}
+
+	consumed, err := s.codeRepo.MarkConsumed(code.ID)
+	if err != nil {
+		return fmt.Errorf("failed to use verification code: %w", err)
+	}
+	if !consumed {
+		return errors.New("invalid or expired verification code")
+	}
+
+	now := time.Now()
+	if req.Channel == models.VerificationChannelPhone {
+		user.PhoneVerifiedAt = &now
+	} else {
+		user.EmailVerifiedAt = &now
+	}
+	if err := s.userRepo.Update(user); err != nil {
+		return fmt.Errorf("failed to update user: %w", err)
+	}
+
+	return nil
+}
+
+// SendInitialCodes dipanggil setelah registrasi, kegagalan hanya di-log agar registrasi tetap berhasil
+func (s *verificationService) SendInitialCodes(user *models.User) {
+	for _, channel := range []string{models.VerificationChannelEmail, models.VerificationChannelPhone} {
+		if err := s.issueCode(user, channel); err != nil {
+			log.Printf("❌ Failed to send %s verification code to user %d: %v", channel, user.ID, err)
+		}
+	}
+}
+
+// issueCode membuat kode baru setelah lolos rate limit lalu mengirimnya di background
+func (s *verificationService) issueCode(user *models.User, channel string) error {
+	now := time.Now()
+
+	recent, err := s.codeRepo.CountSince(user.ID, channel, now.Add(-s.cfg.VerificationResendDelay))
+	if err != nil {
+		return fmt.Errorf("failed to check verification rate limit: %w", err)
+	}
+	if recent > 0 {
+		return errVerificationResendDelay
+	}
+
+	hourly, err := s.codeRepo.CountSince(user.ID, channel, now.Add(-time.Hour))
+	if err != nil {
+		return fmt.Errorf("failed to check verification rate limit: %w", err)
+	}
+	if hourly >= int64(s.cfg.VerificationMaxPerHour) {
+		return errVerificationHourlyLimit
+	}
+
+	// Hanya kode terbaru yang berlaku
+	if err := s.codeRepo.InvalidateForUser(user.ID, channel); err != nil {
+		return fmt.Errorf("failed to invalidate verification codes: %w", err)
+	}
+
+	plain, err := utils.GenerateNumericCode(verificationCodeDigits)
+	if err != nil {
+		return fmt.Errorf("failed to generate verification code: %w", err)
+	}
+
+	target := currentTarget(user, channel)
+
+	code := &models.VerificationCode{
+		UserID:    user.ID,
+		Channel:   channel,
+		Target:    target,
+		CodeHash:  utils.HashToken(plain),
+		ExpiresAt: now.Add(s.cfg.VerificationCodeTTL),
+	}
+	if err := s.codeRepo.Create(code); err != nil {
+		return fmt.Errorf("failed to store verification code: %w", err)
+	}
+
+	go s.deliver(user, channel, target, plain)
+
+	return nil
+}
+
+func (s *verificationService) deliver(user *models.User, channel, target, code string) {
+	var err error
+	if channel == models.VerificationChannelPhone {
+		err = s.sms.Send(target, fmt.Sprintf("Your verification code is %s. It expires in %s.", code, s.cfg.VerificationCodeTTL))
+	} else {
+		err = s.mailer.Send(mailer.Message{
+			To:      target,
+			Subject: "Verify your email address",
+			Body: fmt.Sprintf(
+				"Hi %s,\n\nUse the code below to verify your email address:\n\n%s\n\nThis code expires in %s. If you did not create an account, you can ignore this email.\n",
+				user.Username, code, s.cfg.VerificationCodeTTL,
+			),
+		})
+	}
+	if err != nil {
+		log.Printf("❌ Failed to deliver %s verification code to user %d: %v", channel, user.ID, err)
+	}
+}
+
+// findUser mencari user di cakupan uniqueness organisasi, organisasi yang tidak dikenal diperlakukan seperti user tidak ditemukan
+func (s *verificationService) findUser(organization, channel, target string) (*models.User, error) {
+	users, err := organizationUsers(s.organizationRepo, s.userRepo, organization)
+	if err != nil {
+		return nil, err
+	}
+
+	if channel == models.VerificationChannelPhone {
+		return users.FindByPhone(target)
+	}
+	return users.FindByEmail(target)
+}
+
+func currentTarget(user *models.User, channel string) string {
+	if channel == models.VerificationChannelPhone {
+		return user.PhoneNumber()
+	}
+	return user.Email
+}
+
+func isVerified(user *models.User, channel string) bool {
+	if channel == models.VerificationChannelPhone {
+		return user.PhoneVerifiedAt != nil
+	}
+	return user.EmailVerifiedAt != nil
+}
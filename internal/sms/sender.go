@@ -0,0 +1,36 @@
+package sms
+
+import (
+	"fmt"
+	"log"
+
+	"github.com/LutfiyaAinurrahmanP/boilerplate_fiber_restful_api/internal/config"
+)
+
+// Sender adalah abstraksi pengiriman SMS
+// Provider SMS (Twilio, Vonage, dll) cukup mengimplementasikan interface ini
+type Sender interface {
+	Send(to, message string) error
+}
+
+// New membuat Sender sesuai SMS_DRIVER
+func New(cfg *config.Config) (Sender, error) {
+	switch cfg.SMSDriver {
+	case "", "log":
+		return NewLogSender(), nil
+	default:
+		return nil, fmt.Errorf("unsupported sms driver: %s", cfg.SMSDriver)
+	}
+}
+
+// LogSender hanya mencetak SMS ke log aplikasi (development dan testing)
+type LogSender struct{}
+
+func NewLogSender() *LogSender {
+	return &LogSender{}
+}
+
+func (s *LogSender) Send(to, message string) error {
+	log.Printf("📱 SMS to %s: %s", to, message)
+	return nil
+}
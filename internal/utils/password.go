@@ -1,6 +1,17 @@
 package utils
 
-import "golang.org/x/crypto/bcrypt"
+import (
+	"errors"
+	"unicode"
+
+	"golang.org/x/crypto/bcrypt"
+)
+
+const (
+	PasswordMinLength = 8
+	// bcrypt hanya memakai 72 byte pertama, sisanya diabaikan
+	PasswordMaxLength = 72
+)
 
 func HashPassword(password string) (string, error) {
 	hashedBytes, err := bcrypt.GenerateFromPassword([]byte(password), bcrypt.DefaultCost)
@@ -10,6 +21,28 @@ func HashPassword(password string) (string, error) {
 	return string(hashedBytes), nil
 }
 
+// ValidatePasswordPolicy memastikan password memenuhi kebijakan minimal:
+// 8-72 karakter, mengandung minimal satu huruf dan satu angka
+func ValidatePasswordPolicy(password string) error {
+	if len(password) < PasswordMinLength || len(password) > PasswordMaxLength {
+		return errors.New("password must be between 8 and 72 characters")
+	}
+
+	var hasLetter, hasDigit bool
+	for _, r := range password {
+		switch {
+		case unicode.IsLetter(r):
+			hasLetter = true
+		case unicode.IsDigit(r):
+			hasDigit = true
+		}
+	}
+	if !hasLetter || !hasDigit {
+		return errors.New("password must contain at least one letter and one number")
+	}
+	return nil
+}
+
 func CheckPassword(hashedPassword, password string) error {
 	return bcrypt.CompareHashAndPassword([]byte(hashedPassword), []byte(password))
 }
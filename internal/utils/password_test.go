@@ -0,0 +1,67 @@
+package utils
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestValidatePasswordPolicy(t *testing.T) {
+	tests := []struct {
+		name     string
+		password string
+		wantErr  bool
+	}{
+		{"letters and digits", "password1", false},
+		{"minimum length", "abcdef12", false},
+		{"maximum length", strings.Repeat("a", 71) + "1", false},
+		{"unicode letters", "pässwörd1", false},
+		{"too short", "abc1234", true},
+		{"too long", strings.Repeat("a", 72) + "1", true},
+		{"letters only", "passwordonly", true},
+		{"digits only", "1234567890", true},
+		{"symbols and digits", "!!!!1234", true},
+		{"empty", "", true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := ValidatePasswordPolicy(tt.password)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("ValidatePasswordPolicy(%q) error = %v, wantErr %v", tt.password, err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestHashAndCheckPassword(t *testing.T) {
+	hash, err := HashPassword("password1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if hash == "password1" {
+		t.Fatal("password stored in plain text")
+	}
+
+	tests := []struct {
+		password string
+		wantErr  bool
+	}{
+		{"password1", false},
+		{"password2", true},
+		{"", true},
+	}
+
+	for _, tt := range tests {
+		if err := CheckPassword(hash, tt.password); (err != nil) != tt.wantErr {
+			t.Errorf("CheckPassword(%q) error = %v, wantErr %v", tt.password, err, tt.wantErr)
+		}
+	}
+
+	other, err := HashPassword("password1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if other == hash {
+		t.Error("hashes of the same password are equal (missing salt)")
+	}
+}
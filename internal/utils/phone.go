@@ -0,0 +1,54 @@
+package utils
+
+import (
+	"errors"
+	"strings"
+)
+
+// defaultPhoneCountryCode dipakai untuk nomor format nasional (diawali 0), di-set sekali saat startup
+var defaultPhoneCountryCode = "62"
+
+// SetDefaultPhoneCountryCode mengatur kode negara default (tanpa "+"), contoh: "62"
+func SetDefaultPhoneCountryCode(code string) {
+	defaultPhoneCountryCode = strings.TrimPrefix(strings.TrimSpace(code), "+")
+}
+
+// NormalizePhone mengubah nomor telepon ke format E.164 (+<kode negara><nomor>)
+// Spasi, "-", ".", dan tanda kurung diabaikan. Prefix "00" dianggap "+",
+// nomor nasional yang diawali "0" memakai kode negara default
+func NormalizePhone(phone string) (string, error) {
+	var b strings.Builder
+	for i, r := range strings.TrimSpace(phone) {
+		switch {
+		case r >= '0' && r <= '9':
+			b.WriteRune(r)
+		case r == '+' && i == 0:
+			b.WriteRune(r)
+		case r == ' ' || r == '-' || r == '.' || r == '(' || r == ')':
+			// Separator diabaikan
+		default:
+			return "", errors.New("invalid phone number")
+		}
+	}
+
+	digits := b.String()
+	switch {
+	case strings.HasPrefix(digits, "+"):
+		digits = digits[1:]
+	case strings.HasPrefix(digits, "00"):
+		digits = digits[2:]
+	case strings.HasPrefix(digits, "0"):
+		digits = defaultPhoneCountryCode + digits[1:]
+	}
+
+	// E.164: maksimal 15 digit, kode negara tidak diawali 0
+	if len(digits) < 8 || len(digits) > 15 || digits[0] == '0' {
+		return "", errors.New("invalid phone number")
+	}
+	return "+" + digits, nil
+}
+
+// NormalizeEmail membuat email case-insensitive (disimpan dan dicari dalam huruf kecil)
+func NormalizeEmail(email string) string {
+	return strings.ToLower(strings.TrimSpace(email))
+}
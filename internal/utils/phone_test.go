@@ -0,0 +1,81 @@
+package utils
+
+import "testing"
+
+func TestNormalizePhone(t *testing.T) {
+	tests := []struct {
+		name    string
+		input   string
+		want    string
+		wantErr bool
+	}{
+		{"e164", "+6281234567890", "+6281234567890", false},
+		{"national uses default country code", "081234567890", "+6281234567890", false},
+		{"00 prefix", "006281234567890", "+6281234567890", false},
+		{"separators", " +62 (812) 3456-78.90 ", "+6281234567890", false},
+		{"without prefix", "6281234567890", "+6281234567890", false},
+		{"us number", "+1 (415) 555-2671", "+14155552671", false},
+		{"max length", "+123456789012345", "+123456789012345", false},
+		{"too short", "+1234567", "", true},
+		{"too long", "+1234567890123456", "", true},
+		{"letters", "+62812abc", "", true},
+		{"plus in the middle", "62+81234567890", "", true},
+		{"country code starting with zero", "+0812345678", "", true},
+		{"only separators", " - ", "", true},
+		{"empty", "", "", true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := NormalizePhone(tt.input)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("NormalizePhone(%q) error = %v, wantErr %v", tt.input, err, tt.wantErr)
+			}
+			if got != tt.want {
+				t.Errorf("NormalizePhone(%q) = %q, want %q", tt.input, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestSetDefaultPhoneCountryCode(t *testing.T) {
+	defer SetDefaultPhoneCountryCode(defaultPhoneCountryCode)
+
+	tests := []struct {
+		code string
+		want string
+	}{
+		{"1", "+14155552671"},
+		{"+1", "+14155552671"},
+		{" 44 ", "+444155552671"},
+	}
+
+	for _, tt := range tests {
+		SetDefaultPhoneCountryCode(tt.code)
+		got, err := NormalizePhone("04155552671")
+		if err != nil {
+			t.Fatalf("code %q: %v", tt.code, err)
+		}
+		if got != tt.want {
+			t.Errorf("code %q: NormalizePhone() = %q, want %q", tt.code, got, tt.want)
+		}
+	}
+}
+
+func TestNormalizeEmail(t *testing.T) {
+	tests := []struct {
+		input string
+		want  string
+	}{
+		{"John@Example.COM", "john@example.com"},
+		{"  john@example.com  ", "john@example.com"},
+		{"john@example.com", "john@example.com"},
+		{"", ""},
+	}
+
+	for _, tt := range tests {
+		if got := NormalizeEmail(tt.input); got != tt.want {
+			t.Errorf("NormalizeEmail(%q) = %q, want %q", tt.input, got, tt.want)
+		}
+	}
+}
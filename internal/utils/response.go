@@ -4,8 +4,14 @@ import (
 	"github.com/gofiber/fiber/v2"
 )
 
+// Kode error untuk kondisi yang perlu dibedakan client tanpa mencocokkan message
+const (
+	ErrorCodeLastAdmin = "LAST_ADMIN" // Perubahan akan menghilangkan admin aktif terakhir organisasi
+)
+
 type Response struct {
 	Success bool        `json:"success"`
+	Code    string      `json:"code,omitempty"`
 	Message string      `json:"message"`
 	Data    interface{} `json:"data,omitempty"`
 	Errors  interface{} `json:"errors,omitempty"`
@@ -69,7 +75,7 @@ func BadRequestResponse(c *fiber.Ctx, message string, errors interface{}) error
 
 // UnauthorizedResponse mengirim response error 401 (Unauthorized)
 func UnauthorizedResponse(c *fiber.Ctx, message string) error {
-	return ErrorResponse(c, fiber.StatusUnprocessableEntity, message, nil)
+	return ErrorResponse(c, fiber.StatusUnauthorized, message, nil)
 }
 
 // ForbiddenResponse mengirim response error 403 (Forbidden)
@@ -91,3 +97,17 @@ func InternalServerErrorResponse(c *fiber.Ctx, message string) error {
 func ConflictResponse(c *fiber.Ctx, message string) error {
 	return ErrorResponse(c, fiber.StatusConflict, message, nil)
 }
+
+// ConflictCodeResponse mengirim response error 409 (Conflict) dengan kode error (lihat ErrorCode*)
+func ConflictCodeResponse(c *fiber.Ctx, code, message string) error {
+	return c.Status(fiber.StatusConflict).JSON(Response{
+		Success: false,
+		Code:    code,
+		Message: message,
+	})
+}
+
+// TooManyRequestsResponse mengirim response error 429 (Too Many Requests)
+func TooManyRequestsResponse(c *fiber.Ctx, message string) error {
+	return ErrorResponse(c, fiber.StatusTooManyRequests, message, nil)
+}
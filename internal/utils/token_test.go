@@ -0,0 +1,84 @@
+package utils
+
+import (
+	"encoding/base64"
+	"strings"
+	"testing"
+)
+
+func TestGenerateRandomToken(t *testing.T) {
+	tests := []struct {
+		size    int
+		wantLen int
+	}{
+		{16, 22},
+		{32, 43},
+		{64, 86},
+	}
+
+	for _, tt := range tests {
+		token, err := GenerateRandomToken(tt.size)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(token) != tt.wantLen {
+			t.Errorf("GenerateRandomToken(%d) length = %d, want %d", tt.size, len(token), tt.wantLen)
+		}
+		if strings.ContainsAny(token, "+/=") {
+			t.Errorf("GenerateRandomToken(%d) = %q is not URL-safe", tt.size, token)
+		}
+		decoded, err := base64.RawURLEncoding.DecodeString(token)
+		if err != nil || len(decoded) != tt.size {
+			t.Errorf("GenerateRandomToken(%d) decodes to %d bytes, err %v", tt.size, len(decoded), err)
+		}
+	}
+
+	a, _ := GenerateRandomToken(32)
+	b, _ := GenerateRandomToken(32)
+	if a == b {
+		t.Error("two random tokens are equal")
+	}
+}
+
+func TestHashToken(t *testing.T) {
+	tests := []struct {
+		token string
+		want  string
+	}{
+		// SHA-256 test vector (FIPS 180-2)
+		{"abc", "ba7816bf8f01cfea414140de5dae2223b00361a396177a9cb410ff61f20015ad"},
+		{"", "e3b0c44298fc1c149afbf4c8996fb92427ae41e4649b934ca495991b7852b855"},
+	}
+
+	for _, tt := range tests {
+		got := HashToken(tt.token)
+		if got != tt.want {
+			t.Errorf("HashToken(%q) = %s, want %s", tt.token, got, tt.want)
+		}
+		// Kolom token_hash bertipe char(64)
+		if len(got) != 64 {
+			t.Errorf("HashToken(%q) length = %d, want 64", tt.token, len(got))
+		}
+	}
+
+	if HashToken("token-a") == HashToken("token-b") {
+		t.Error("different tokens have the same hash")
+	}
+}
+
+func TestGenerateNumericCode(t *testing.T) {
+	for _, digits := range []int{4, 6, 8} {
+		for i := 0; i < 50; i++ {
+			code, err := GenerateNumericCode(digits)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if len(code) != digits {
+				t.Fatalf("GenerateNumericCode(%d) = %q, wrong length", digits, code)
+			}
+			if strings.Trim(code, "0123456789") != "" {
+				t.Fatalf("GenerateNumericCode(%d) = %q, not numeric", digits, code)
+			}
+		}
+	}
+}
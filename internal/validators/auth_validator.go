@@ -5,6 +5,7 @@ import (
 	"strings"
 
 	"github.com/LutfiyaAinurrahmanP/boilerplate_fiber_restful_api/internal/models"
+	"github.com/LutfiyaAinurrahmanP/boilerplate_fiber_restful_api/internal/utils"
 	"github.com/go-playground/validator/v10"
 	"github.com/gofiber/fiber/v2"
 )
@@ -12,18 +13,129 @@ import (
 type RegisterRequest struct {
 	Username        string `json:"username" validate:"required,min=3,max=50"`
 	Email           string `json:"email" validate:"required,email"`
-	Phone           string `json:"phone" validate:"required,min=10,max=15"`
-	Password        string `json:"password" validate:"required,min=8"`
+	Phone           string `json:"phone" validate:"required,phone"`
+	Password        string `json:"password" validate:"required,password"`
 	ConfirmPassword string `json:"confirm_password" validate:"required,eqfield=Password"`
-	Role            string `json:"role" validate:"omitempty,oneof=user admin"`
 }
 
+// AcceptInvitationRequest dipakai penerima undangan, email dan role diambil dari undangan
+type AcceptInvitationRequest struct {
+	Token           string `json:"token" validate:"required"`
+	Username        string `json:"username" validate:"required,min=3,max=50"`
+	Phone           string `json:"phone" validate:"required,phone"`
+	Password        string `json:"password" validate:"required,password"`
+	ConfirmPassword string `json:"confirm_password" validate:"required,eqfield=Password"`
+}
+
+// LoginRequest menerima username, email, atau nomor telepon di field identifier
+// Field username tetap diterima untuk client lama
 type LoginRequest struct {
-	Username string `json:"username" validate:"required"`
+	Identifier string `json:"identifier" validate:"required_without=Username,max=100"`
+	Username   string `json:"username" validate:"omitempty,max=100"`
+	Password   string `json:"password" validate:"required"`
+	Device     string `json:"device" validate:"omitempty,max=100"`
+
+	// Slug organisasi, kosong berarti organisasi default (atau organisasi asal user saat uniqueness global)
+	Organization string `json:"organization" validate:"omitempty,max=50"`
+}
+
+// LoginIdentifier mengembalikan identifier, fallback ke username untuk client lama
+func (r *LoginRequest) LoginIdentifier() string {
+	if r.Identifier != "" {
+		return strings.TrimSpace(r.Identifier)
+	}
+	return strings.TrimSpace(r.Username)
+}
+
+type RefreshTokenRequest struct {
+	RefreshToken string `json:"refresh_token" validate:"required"`
+}
+
+type ForgotPasswordRequest struct {
+	Email string `json:"email" validate:"required,email"`
+
+	// Slug organisasi, hanya perlu jika uniqueness per organisasi
+	Organization string `json:"organization" validate:"omitempty,max=50"`
+}
+
+type ResetPasswordRequest struct {
+	Token           string `json:"token" validate:"required"`
+	NewPassword     string `json:"new_password" validate:"required,password"`
+	ConfirmPassword string `json:"confirm_password" validate:"required,eqfield=NewPassword"`
+}
+
+type SendVerificationRequest struct {
+	Channel string `json:"channel" validate:"required,oneof=email phone"`
+	Target  string `json:"target" validate:"required,max=100"`
+
+	// Slug organisasi, hanya perlu jika uniqueness per organisasi
+	Organization string `json:"organization" validate:"omitempty,max=50"`
+}
+
+type ConfirmVerificationRequest struct {
+	Channel string `json:"channel" validate:"required,oneof=email phone"`
+	Target  string `json:"target" validate:"required,max=100"`
+	Code    string `json:"code" validate:"required,len=6,numeric"`
+
+	// Slug organisasi, hanya perlu jika uniqueness per organisasi
+	Organization string `json:"organization" validate:"omitempty,max=50"`
+}
+
+type EnrollTwoFactorRequest struct {
 	Password string `json:"password" validate:"required"`
 }
 
-var validate = validator.New()
+type TwoFactorCodeRequest struct {
+	Code string `json:"code" validate:"required,max=32"`
+}
+
+type DisableTwoFactorRequest struct {
+	Password string `json:"password" validate:"required"`
+	Code     string `json:"code" validate:"required,max=32"`
+}
+
+// VerifyTwoFactorRequest menukar token "mfa pending" dari login dengan kode TOTP atau recovery code
+type VerifyTwoFactorRequest struct {
+	MFAToken string `json:"mfa_token" validate:"required"`
+	Code     string `json:"code" validate:"required,max=32"`
+}
+
+type TwoFactorPolicyRequest struct {
+	Role     string `json:"role" validate:"required,role"`
+	Required *bool  `json:"required" validate:"required"`
+}
+
+// CreateAPIKeyRequest membuat API key, umur maksimal dibatasi API_KEY_MAX_TTL
+type CreateAPIKeyRequest struct {
+	Name          string   `json:"name" validate:"required,max=100"`
+	Scopes        []string `json:"scopes" validate:"required,min=1,dive,oneof=read write admin"`
+	ExpiresInDays int      `json:"expires_in_days" validate:"omitempty,min=1"`
+}
+
+var validate = newValidator()
+
+// newValidator mendaftarkan custom validation tag yang dipakai di request struct
+func newValidator() *validator.Validate {
+	v := validator.New()
+
+	// "password" mengikuti utils.ValidatePasswordPolicy
+	_ = v.RegisterValidation("password", func(fl validator.FieldLevel) bool {
+		return utils.ValidatePasswordPolicy(fl.Field().String()) == nil
+	})
+
+	// "phone" memastikan nomor bisa dinormalisasi ke E.164 (utils.NormalizePhone)
+	_ = v.RegisterValidation("phone", func(fl validator.FieldLevel) bool {
+		_, err := utils.NormalizePhone(fl.Field().String())
+		return err == nil
+	})
+
+	// "role" membaca role yang tersedia dari role store (models.ValidateRole)
+	_ = v.RegisterValidation("role", func(fl validator.FieldLevel) bool {
+		return models.ValidateRole(fl.Field().String())
+	})
+
+	return v
+}
 
 func ValidateStruct(data interface{}) error {
 	return validate.Struct(data)
@@ -37,7 +149,7 @@ func FormatValidationError(err error) map[string]string {
 			field := strings.ToLower(e.Field())
 
 			switch e.Tag() {
-			case "required":
+			case "required", "required_without":
 				message = fmt.Sprintf("%s is required", field)
 			case "email":
 				message = fmt.Sprintf("%s must be a valid email address", field)
@@ -49,6 +161,16 @@ func FormatValidationError(err error) map[string]string {
 				message = fmt.Sprintf("%s must match %s", field, strings.ToLower(e.Param()))
 			case "oneof":
 				message = fmt.Sprintf("%s must be one of: %s", field, e.Param())
+			case "len":
+				message = fmt.Sprintf("%s must be exactly %s characters", field, e.Param())
+			case "numeric":
+				message = fmt.Sprintf("%s must contain only digits", field)
+			case "phone":
+				message = fmt.Sprintf("%s must be a valid phone number, e.g. +6281234567890 or 081234567890", field)
+			case "role":
+				message = fmt.Sprintf("%s must be one of: %s", field, strings.Join(models.GetAvailableRoles(), " "))
+			case "password":
+				message = fmt.Sprintf("%s must be 8-72 characters and contain letters and numbers", field)
 			default:
 				message = fmt.Sprintf("%s is invalid", field)
 			}
@@ -71,8 +193,12 @@ func ParseAndValidate(c *fiber.Ctx, data interface{}) error {
 	return nil
 }
 
-func (r *RegisterRequest) SetDefaultRole() {
-	if r.Role == "" {
-		r.Role = models.RoleUser
+func (r *RegisterRequest) Normalize() {
+	normalizeContact(&r.Email, &r.Phone)
+}
+
+func (r *AcceptInvitationRequest) Normalize() {
+	if normalized, err := utils.NormalizePhone(r.Phone); err == nil {
+		r.Phone = normalized
 	}
 }
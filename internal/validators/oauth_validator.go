@@ -0,0 +1,44 @@
+package validators
+
+// CreateOAuthClientRequest mendaftarkan aplikasi client OAuth2 (khusus admin)
+// redirect_uris wajib untuk grant authorization_code, client_credentials hanya untuk client confidential (dicek di service)
+type CreateOAuthClientRequest struct {
+	Name         string   `json:"name" validate:"required,max=100"`
+	Confidential *bool    `json:"confidential" validate:"required"`
+	RedirectURIs []string `json:"redirect_uris" validate:"omitempty,max=10,dive,url,max=500"`
+	GrantTypes   []string `json:"grant_types" validate:"required,min=1,dive,oneof=authorization_code refresh_token client_credentials"`
+	Scopes       []string `json:"scopes" validate:"omitempty,max=50,dive,min=1,max=64,excludesall= \"\\"`
+}
+
+// UpdateOAuthClientRequest: field yang tidak dikirim (null) tidak diubah
+type UpdateOAuthClientRequest struct {
+	Name         string   `json:"name" validate:"omitempty,max=100"`
+	RedirectURIs []string `json:"redirect_uris" validate:"omitempty,max=10,dive,url,max=500"`
+	GrantTypes   []string `json:"grant_types" validate:"omitempty,min=1,dive,oneof=authorization_code refresh_token client_credentials"`
+	Scopes       []string `json:"scopes" validate:"omitempty,max=50,dive,min=1,max=64,excludesall= \"\\"`
+}
+
+// OAuthAuthorizeRequest adalah parameter authorization request (RFC 6749 section 4.1.1 + PKCE)
+// Dibaca dari query string GET /oauth/authorize atau body JSON POST /oauth/authorize, divalidasi di service
+// agar error bisa dikembalikan ke redirect_uri sesuai spesifikasi
+type OAuthAuthorizeRequest struct {
+	ResponseType        string `json:"response_type" query:"response_type"`
+	ClientID            string `json:"client_id" query:"client_id"`
+	RedirectURI         string `json:"redirect_uri" query:"redirect_uri"`
+	Scope               string `json:"scope" query:"scope"`
+	State               string `json:"state" query:"state"`
+	Nonce               string `json:"nonce" query:"nonce"`
+	CodeChallenge       string `json:"code_challenge" query:"code_challenge"`
+	CodeChallengeMethod string `json:"code_challenge_method" query:"code_challenge_method"`
+	Approve             *bool  `json:"approve" query:"-"` // Keputusan user di halaman consent (hanya POST)
+}
+
+// OAuthTokenRequest adalah parameter form POST /oauth/token untuk semua grant
+type OAuthTokenRequest struct {
+	GrantType    string `form:"grant_type"`
+	Code         string `form:"code"`
+	RedirectURI  string `form:"redirect_uri"`
+	CodeVerifier string `form:"code_verifier"`
+	RefreshToken string `form:"refresh_token"`
+	Scope        string `form:"scope"`
+}
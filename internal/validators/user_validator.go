@@ -1,30 +1,85 @@
 package validators
 
+import "github.com/LutfiyaAinurrahmanP/boilerplate_fiber_restful_api/internal/utils"
+
 type CreateUserRequest struct {
 	Username        string `json:"username" validate:"required,min=3,max=50"`
 	Email           string `json:"email" validate:"required,email"`
-	Phone           string `json:"phone" validate:"required,min=10,max=15"`
-	Password        string `json:"password" validate:"required,min=8"`
+	Phone           string `json:"phone" validate:"required,phone"`
+	Password        string `json:"password" validate:"required,password"`
 	ConfirmPassword string `json:"confirm_password" validate:"required,eqfield=Password"`
-	Role            string `json:"role" validate:"required,oneof=user admin"`
+	Role            string `json:"role" validate:"required,role"`
+}
+
+type CreateInvitationRequest struct {
+	Email          string `json:"email" validate:"required,email"`
+	Role           string `json:"role" validate:"required,role"`
+	Note           string `json:"note" validate:"omitempty,max=255"`
+	ExpiresInHours int    `json:"expires_in_hours" validate:"omitempty,min=1,max=720"`
+}
+
+// CreateRoleRequest membuat role baru, permission harus ada di katalog dan dimiliki role pembuat
+// Level default sama dengan role user, tidak boleh melebihi level role pembuat
+type CreateRoleRequest struct {
+	Name        string   `json:"name" validate:"required,min=2,max=20"`
+	Description string   `json:"description" validate:"omitempty,max=255"`
+	Level       *int     `json:"level" validate:"omitempty,min=0,max=1000"`
+	Permissions []string `json:"permissions" validate:"omitempty,dive,max=100"`
+}
+
+// UpdateRoleRequest: field yang tidak dikirim (null) tidak diubah, permissions menggantikan seluruh permission role
+type UpdateRoleRequest struct {
+	Description *string  `json:"description" validate:"omitempty,max=255"`
+	Level       *int     `json:"level" validate:"omitempty,min=0,max=1000"`
+	Permissions []string `json:"permissions" validate:"omitempty,dive,max=100"`
+}
+
+// CreateOrganizationRequest membuat organisasi (tenant) baru, slug dipakai client saat login
+type CreateOrganizationRequest struct {
+	Name string `json:"name" validate:"required,min=2,max=100"`
+	Slug string `json:"slug" validate:"required,min=2,max=50"`
+}
+
+type UpdateOrganizationRequest struct {
+	Name string `json:"name" validate:"required,min=2,max=100"`
+}
+
+// AddOrganizationMemberRequest menambahkan user (dari organisasi mana pun) sebagai anggota dengan role di organisasi tersebut
+type AddOrganizationMemberRequest struct {
+	UserID uint   `json:"user_id" validate:"required"`
+	Role   string `json:"role" validate:"required,role"`
+}
+
+type UpdateOrganizationMemberRequest struct {
+	Role string `json:"role" validate:"required,role"`
+}
+
+// SwitchOrganizationRequest mengganti organisasi aktif session saat ini
+type SwitchOrganizationRequest struct {
+	OrganizationID uint `json:"organization_id" validate:"required"`
+}
+
+type ListInvitationQuery struct {
+	Status string `query:"status" validate:"omitempty,oneof=pending accepted revoked expired all"`
 }
 
 type UpdateUserRequest struct {
 	Username string `json:"username" validate:"omitempty,min=3,max=50"`
 	Email    string `json:"email" validate:"omitempty,email"`
-	Phone    string `json:"phone" validate:"omitempty,min=10,max=15"`
-	Role     string `json:"role" validate:"omitempty,oneof=user admin"`
+	Phone    string `json:"phone" validate:"omitempty,phone"`
+	Role     string `json:"role" validate:"omitempty,role"`
 }
 
 type UpdateProfileRequest struct {
 	Username string `json:"username" validate:"omitempty,min=3,max=50"`
 	Email    string `json:"email" validate:"omitempty,email"`
-	Phone    string `json:"phone" validate:"omitempty,min=10,max=20"`
+	Phone    string `json:"phone" validate:"omitempty,phone"`
 }
 
+// ChangePasswordRequest: OldPassword boleh kosong untuk akun tanpa password (dicek di service)
 type ChangePasswordRequest struct {
-	OldPassword     string `json:"old_password" validate:"required"`
-	NewPassword     string `json:"new_password" validate:"required,min=8"`
+	OldPassword     string `json:"old_password"`
+	NewPassword     string `json:"new_password" validate:"required,password"`
 	ConfirmPassword string `json:"confirm_password" validate:"required,eqfield=NewPassword"`
 }
 
@@ -32,7 +87,7 @@ type ListUserQuery struct {
 	Page   int    `query:"page" validate:"omitempty,min=1"`
 	Limit  int    `query:"limit" validate:"omitempty,min=1,max=100"`
 	Search string `query:"search" validate:"omitempty,max=100"`
-	Role   string `query:"role" validate:"omitempty,oneof=user admin"`
+	Role   string `query:"role" validate:"omitempty,role"`
 	Sort   string `query:"sort" validate:"omitempty,oneof=asc dsc"`
 	SortBy string `query:"sort_by" validate:"omitempty,oneof=id username email created_at"`
 }
@@ -58,3 +113,28 @@ func (q *ListUserQuery) SetDefaults() {
 func (q *ListUserQuery) GetOffSet() int {
 	return (q.Page - 1) * q.Limit
 }
+
+// normalizeContact menyamakan format email (huruf kecil) dan telepon (E.164) sebelum dibandingkan / disimpan
+// Nomor yang tidak valid dibiarkan apa adanya, validasi tag "phone" yang menolaknya
+func normalizeContact(email, phone *string) {
+	*email = utils.NormalizeEmail(*email)
+	if normalized, err := utils.NormalizePhone(*phone); err == nil {
+		*phone = normalized
+	}
+}
+
+func (r *CreateUserRequest) Normalize() {
+	normalizeContact(&r.Email, &r.Phone)
+}
+
+func (r *UpdateUserRequest) Normalize() {
+	normalizeContact(&r.Email, &r.Phone)
+}
+
+func (r *UpdateProfileRequest) Normalize() {
+	normalizeContact(&r.Email, &r.Phone)
+}
+
+func (r *CreateInvitationRequest) Normalize() {
+	r.Email = utils.NormalizeEmail(r.Email)
+}
@@ -0,0 +1,91 @@
+package workers
+
+import (
+	"context"
+	"log"
+	"time"
+)
+
+// ExpiredTokenCleaner diimplementasikan oleh repository yang menyimpan token dengan expires_at
+type ExpiredTokenCleaner interface {
+	CleanupExpiredTokens(batchSize int) (int64, error)
+}
+
+type cleanupTarget struct {
+	name    string
+	cleaner ExpiredTokenCleaner
+}
+
+// TokenJanitor adalah background worker yang menghapus token expired secara berkala
+// agar tabel token (blacklist, refresh token, reset token, dll) tidak tumbuh tanpa batas
+type TokenJanitor struct {
+	targets   []cleanupTarget
+	interval  time.Duration
+	batchSize int
+	done      chan struct{}
+}
+
+func NewTokenJanitor(interval time.Duration, batchSize int) *TokenJanitor {
+	return &TokenJanitor{
+		interval:  interval,
+		batchSize: batchSize,
+		done:      make(chan struct{}),
+	}
+}
+
+// AddTarget mendaftarkan tabel yang dibersihkan, harus dipanggil sebelum Start
+func (j *TokenJanitor) AddTarget(name string, cleaner ExpiredTokenCleaner) *TokenJanitor {
+	j.targets = append(j.targets, cleanupTarget{name: name, cleaner: cleaner})
+	return j
+}
+
+// Start menjalankan janitor di goroutine terpisah sampai ctx di-cancel
+func (j *TokenJanitor) Start(ctx context.Context) {
+	go func() {
+		defer close(j.done)
+
+		ticker := time.NewTicker(j.interval)
+		defer ticker.Stop()
+
+		log.Printf("🧹 Token janitor started (interval: %s, batch size: %d)", j.interval, j.batchSize)
+
+		// Jalankan sekali saat startup, lalu setiap interval
+		j.runOnce(ctx)
+		for {
+			select {
+			case <-ctx.Done():
+				log.Println("🧹 Token janitor stopped")
+				return
+			case <-ticker.C:
+				j.runOnce(ctx)
+			}
+		}
+	}()
+}
+
+// Wait menunggu goroutine janitor benar-benar berhenti
+func (j *TokenJanitor) Wait() {
+	<-j.done
+}
+
+// runOnce menghapus token expired per batch sampai habis atau ctx di-cancel
+func (j *TokenJanitor) runOnce(ctx context.Context) {
+	for _, target := range j.targets {
+		var total int64
+		for ctx.Err() == nil {
+			removed, err := target.cleaner.CleanupExpiredTokens(j.batchSize)
+			if err != nil {
+				log.Printf("❌ Token janitor failed to clean %s: %v", target.name, err)
+				break
+			}
+			total += removed
+			if removed < int64(j.batchSize) {
+				break
+			}
+		}
+
+		if total > 0 {
+			log.Printf("🧹 Token janitor removed %d expired rows from %s", total, target.name)
+		}
+	}
+}
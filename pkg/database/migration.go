@@ -4,6 +4,8 @@ import (
 	"fmt"
 	"log"
 
+	"github.com/LutfiyaAinurrahmanP/boilerplate_fiber_restful_api/internal/models"
+	"github.com/LutfiyaAinurrahmanP/boilerplate_fiber_restful_api/internal/utils"
 	"gorm.io/gorm"
 )
 
@@ -38,6 +40,127 @@ func (m *Migrator) RunMigrations(models ...interface{}) error {
 	if err := m.AutoMigrate(models...); err != nil {
 		return err
 	}
+
+	// Data migrations yang tidak bisa ditangani AutoMigrate
+	if err := m.MigrateTokenHashes(); err != nil {
+		return err
+	}
+	if err := m.NormalizeUserContacts(); err != nil {
+		return err
+	}
+	if err := m.SeedRolesAndPermissions(); err != nil {
+		return err
+	}
+	if err := m.SeedDefaultOrganization(); err != nil {
+		return err
+	}
+	if err := m.SyncUserUniqueScope(); err != nil {
+		return err
+	}
+	return nil
+}
+
+// tokenHashMigrations adalah daftar kolom token mentah yang diganti dengan SHA-256 digest
+var tokenHashMigrations = []struct {
+	table      string
+	rawColumn  string
+	hashColumn string
+}{
+	{table: "token_blacklist", rawColumn: "token", hashColumn: "token_hash"},
+	{table: "refresh_tokens", rawColumn: "access_token", hashColumn: "access_hash"},
+}
+
+// MigrateTokenHashes mengisi kolom hash dari kolom token mentah lalu menghapus kolom mentah
+// Aman dijalankan berulang kali: jika kolom mentah sudah tidak ada, migration dilewati
+func (m *Migrator) MigrateTokenHashes() error {
+	migrator := m.db.Migrator()
+
+	for _, tm := range tokenHashMigrations {
+		if !migrator.HasTable(tm.table) || !migrator.HasColumn(tm.table, tm.rawColumn) {
+			continue
+		}
+
+		log.Printf("🔄 Hashing raw tokens: %s.%s -> %s", tm.table, tm.rawColumn, tm.hashColumn)
+
+		// SHA2(x, 256) menghasilkan hex lowercase, sama dengan utils.HashToken
+		sql := fmt.Sprintf(
+			"UPDATE `%s` SET `%s` = SHA2(`%s`, 256) WHERE (`%s` = '' OR `%s` IS NULL) AND `%s` IS NOT NULL",
+			tm.table, tm.hashColumn, tm.rawColumn, tm.hashColumn, tm.hashColumn, tm.rawColumn,
+		)
+		if err := m.db.Exec(sql).Error; err != nil {
+			return fmt.Errorf("failed to hash %s.%s: %w", tm.table, tm.rawColumn, err)
+		}
+
+		if err := migrator.DropColumn(tm.table, tm.rawColumn); err != nil {
+			return fmt.Errorf("failed to drop %s.%s: %w", tm.table, tm.rawColumn, err)
+		}
+	}
+	return nil
+}
+
+// NormalizeUserContacts mengubah email lama ke huruf kecil dan nomor telepon lama ke format E.164
+// Hanya baris yang belum ternormalisasi yang diproses, jadi aman dijalankan di setiap startup
+// Baris yang bentrok dengan user lain setelah dinormalisasi dilewati dan dicatat di log
+func (m *Migrator) NormalizeUserContacts() error {
+	if !m.db.Migrator().HasTable("users") {
+		return nil
+	}
+
+	var rows []struct {
+		ID    uint
+		Email string
+		Phone *string // NULL untuk akun tanpa nomor telepon
+	}
+	err := m.db.Table("users").
+		Select("id, email, phone").
+		Where("email <> LOWER(TRIM(email)) COLLATE utf8mb4_bin OR phone NOT LIKE '+%'").
+		Scan(&rows).Error
+	if err != nil {
+		return fmt.Errorf("failed to load users for normalization: %w", err)
+	}
+
+	for _, row := range rows {
+		email := utils.NormalizeEmail(row.Email)
+		phone := row.Phone
+		if row.Phone != nil {
+			normalized, err := utils.NormalizePhone(*row.Phone)
+			if err != nil {
+				log.Printf("⚠️  Skipping phone normalization for user %d: %q is not a valid phone number", row.ID, *row.Phone)
+			} else {
+				phone = &normalized
+			}
+		}
+		if email == row.Email && (phone == nil || *phone == *row.Phone) {
+			continue
+		}
+
+		err = m.db.Table("users").
+			Where("id = ?", row.ID).
+			UpdateColumns(map[string]interface{}{"email": email, "phone": phone}).Error
+		if err != nil {
+			log.Printf("⚠️  Failed to normalize contacts for user %d: %v", row.ID, err)
+		}
+	}
+	return nil
+}
+
+// SyncUserUniqueScope menyesuaikan users.unique_scope dengan cakupan uniqueness yang dipilih (models.SetUniquenessScope)
+// Pindah dari per organisasi ke global gagal jika username / email / phone yang sama sudah dipakai di organisasi berbeda
+func (m *Migrator) SyncUserUniqueScope() error {
+	if !m.db.Migrator().HasColumn("users", "unique_scope") {
+		return nil
+	}
+
+	scope := "0"
+	if models.UniqueScopeFor(models.DefaultOrganizationID) != 0 {
+		scope = "organization_id"
+	}
+
+	// Termasuk user yang sudah di-soft delete, unique index juga berlaku untuk mereka
+	sql := fmt.Sprintf("UPDATE `users` SET `unique_scope` = %s WHERE `unique_scope` <> %s", scope, scope)
+	if err := m.db.Exec(sql).Error; err != nil {
+		return fmt.Errorf("failed to apply user uniqueness scope (duplicate username / email / phone across organizations?): %w", err)
+	}
 	return nil
 }
 
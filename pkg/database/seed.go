@@ -0,0 +1,72 @@
+package database
+
+import (
+	"fmt"
+
+	"github.com/LutfiyaAinurrahmanP/boilerplate_fiber_restful_api/internal/models"
+	"gorm.io/gorm/clause"
+)
+
+// SeedRolesAndPermissions mengisi katalog permission dan role sistem (user & admin)
+// Aman dijalankan di setiap startup: permission baru ditambahkan, deskripsi diperbarui,
+// dan role admin selalu disinkronkan agar punya semua permission
+func (m *Migrator) SeedRolesAndPermissions() error {
+	permissions := make([]models.Permission, len(models.PermissionCatalog))
+	copy(permissions, models.PermissionCatalog)
+
+	err := m.db.Clauses(clause.OnConflict{
+		Columns:   []clause.Column{{Name: "name"}},
+		DoUpdates: clause.AssignmentColumns([]string{"description"}),
+	}).Create(&permissions).Error
+	if err != nil {
+		return fmt.Errorf("failed to seed permissions: %w", err)
+	}
+
+	systemRoles := []models.Role{
+		{Name: models.RoleUser, Description: "Regular user", Level: models.RoleLevelUser, IsSystem: true},
+		{Name: models.RoleAdmin, Description: "Administrator with every permission", Level: models.RoleLevelAdmin, IsSystem: true},
+	}
+	for _, seed := range systemRoles {
+		role := seed
+		if err := m.db.Where(models.Role{Name: seed.Name}).Attrs(seed).FirstOrCreate(&role).Error; err != nil {
+			return fmt.Errorf("failed to seed role %s: %w", seed.Name, err)
+		}
+		if !role.IsSystem || role.Level != seed.Level {
+			err := m.db.Model(&role).Updates(map[string]interface{}{"is_system": true, "level": seed.Level}).Error
+			if err != nil {
+				return fmt.Errorf("failed to seed role %s: %w", seed.Name, err)
+			}
+		}
+
+		if role.Name != models.RoleAdmin {
+			continue
+		}
+		var all []models.Permission
+		if err := m.db.Find(&all).Error; err != nil {
+			return fmt.Errorf("failed to load permissions: %w", err)
+		}
+		if err := m.db.Model(&role).Omit("Permissions.*").Association("Permissions").Replace(all); err != nil {
+			return fmt.Errorf("failed to grant permissions to admin: %w", err)
+		}
+	}
+	return nil
+}
+
+// SeedDefaultOrganization membuat organisasi default (ID tetap) dan keanggotaan organisasi asal
+// untuk user yang dibuat sebelum multi-tenancy, aman dijalankan di setiap startup
+func (m *Migrator) SeedDefaultOrganization() error {
+	organization := models.Organization{ID: models.DefaultOrganizationID, Name: "Default", Slug: models.DefaultOrganizationSlug}
+	if err := m.db.Where(models.Organization{ID: models.DefaultOrganizationID}).FirstOrCreate(&organization).Error; err != nil {
+		return fmt.Errorf("failed to seed default organization: %w", err)
+	}
+
+	err := m.db.Exec(
+		"INSERT INTO `organization_members` (`organization_id`, `user_id`, `role`, `created_at`, `updated_at`) " +
+			"SELECT u.`organization_id`, u.`id`, u.`role`, NOW(), NOW() FROM `users` u " +
+			"WHERE NOT EXISTS (SELECT 1 FROM `organization_members` m WHERE m.`organization_id` = u.`organization_id` AND m.`user_id` = u.`id`)",
+	).Error
+	if err != nil {
+		return fmt.Errorf("failed to backfill organization members: %w", err)
+	}
+	return nil
+}